@@ -13,6 +13,13 @@ import (
 const (
 	TokenTypeAccess  = "access"
 	TokenTypeRefresh = "refresh"
+	// TokenTypeMFA выдается после пароля, если у пользователя включена 2FA.
+	TokenTypeMFA = "mfa"
+	// TokenTypeMFAEnroll выдается после пароля, если роль требует 2FA, а она еще не настроена.
+	TokenTypeMFAEnroll = "mfa_enroll"
+
+	// MFAChallengeTTL — срок жизни mfa_token.
+	MFAChallengeTTL = 5 * time.Minute
 )
 
 var (
@@ -22,6 +29,7 @@ var (
 type Claims struct {
 	UserID    int64  `json:"uid"`
 	Email     string `json:"email"`
+	Role      string `json:"role,omitempty"`
 	TokenType string `json:"typ"`
 	jwt.RegisteredClaims
 }
@@ -44,12 +52,24 @@ func NewJWTService(secret, issuer string, accessTTL, refreshTTL time.Duration) *
 	}
 }
 
-func (s *JWTService) GenerateAccessToken(userID int64, email string) (string, error) {
-	return s.generateToken(userID, email, TokenTypeAccess, s.accessTTL)
+func (s *JWTService) GenerateAccessToken(userID int64, email, role string) (string, error) {
+	return s.generateToken(userID, email, role, TokenTypeAccess, "", s.accessTTL)
 }
 
 func (s *JWTService) GenerateRefreshToken(userID int64, email string) (string, error) {
-	return s.generateToken(userID, email, TokenTypeRefresh, s.refreshTTL)
+	return s.generateToken(userID, email, "", TokenTypeRefresh, "", s.refreshTTL)
+}
+
+// GenerateMFAChallengeToken выдает короткоживущий токен промежуточного шага логина.
+// challengeID попадает в jti: для TokenTypeMFA по нему считаются попытки ввода кода, поэтому он обязателен.
+func (s *JWTService) GenerateMFAChallengeToken(userID int64, email, tokenType, challengeID string) (string, error) {
+	if tokenType != TokenTypeMFA && tokenType != TokenTypeMFAEnroll {
+		return "", fmt.Errorf("unsupported mfa token type %q", tokenType)
+	}
+	if tokenType == TokenTypeMFA && challengeID == "" {
+		return "", errors.New("mfa challenge id is required")
+	}
+	return s.generateToken(userID, email, "", tokenType, challengeID, MFAChallengeTTL)
 }
 
 func (s *JWTService) ParseAndValidate(tokenStr string, expectedType string) (*Claims, error) {
@@ -79,14 +99,16 @@ func (s *JWTService) ParseAndValidate(tokenStr string, expectedType string) (*Cl
 	return claims, nil
 }
 
-func (s *JWTService) generateToken(userID int64, email, tokenType string, ttl time.Duration) (string, error) {
+func (s *JWTService) generateToken(userID int64, email, role, tokenType, id string, ttl time.Duration) (string, error) {
 	now := s.nowFn()
 
 	claims := Claims{
 		UserID:    userID,
 		Email:     email,
+		Role:      role,
 		TokenType: tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
 			Issuer:    s.issuer,
 			Subject:   strconv.FormatInt(userID, 10),
 			Audience:  []string{"warranty_days_api"},
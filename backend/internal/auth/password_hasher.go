@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgorithmArgon2id = "argon2id"
+	PasswordAlgorithmBcrypt   = "bcrypt"
+)
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher — один алгоритм хеширования паролей.
+// Хеши самоописываемые: алгоритм и параметры закодированы в строке хеша.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify возвращает needsRehash=true, если хеш валиден, но параметры устарели.
+	Verify(password, encoded string) (ok bool, needsRehash bool, err error)
+	// Recognizes сообщает, что хеш создан этим алгоритмом.
+	Recognizes(encoded string) bool
+}
+
+type Argon2idParams struct {
+	MemoryKiB   uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Argon2idHasher пишет хеши в PHC-формате: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>.
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2idKey(password, salt, h.params)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.MemoryKiB,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	other := argon2idKey(password, salt, params)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash := params.MemoryKiB != h.params.MemoryKiB ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		params.KeyLength != h.params.KeyLength
+	return true, needsRehash, nil
+}
+
+func (h *Argon2idHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func argon2idKey(password string, salt []byte, p Argon2idParams) []byte {
+	return argon2.IDKey([]byte(password), salt, p.Iterations, p.MemoryKiB, p.Parallelism, p.KeyLength)
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		return Argon2idParams{}, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2idParams{}, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var params Argon2idParams
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.MemoryKiB, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return Argon2idParams{}, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, ErrUnknownPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// BcryptMaxPasswordBytes — bcrypt не принимает пароли длиннее 72 байт.
+const BcryptMaxPasswordBytes = 72
+
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(password, encoded string) (bool, bool, error) {
+	// такой пароль bcrypt не захеширует, значит, он не может совпасть; ошибка bcrypt дала бы 500 при логине
+	if len(password) > BcryptMaxPasswordBytes {
+		return false, false, nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, err
+	}
+	return true, cost != h.cost, nil
+}
+
+func (h *BcryptHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// PasswordHashers хеширует новым алгоритмом и проверяет хеши всех известных алгоритмов.
+// Хеш чужого алгоритма при успешной проверке помечается на перехеширование.
+type PasswordHashers struct {
+	preferred PasswordHasher
+	legacy    []PasswordHasher
+	// dummyHash проверяется, когда пользователь не найден, чтобы время ответа не выдавало существование email.
+	dummyHash string
+}
+
+func NewPasswordHashers(preferred PasswordHasher, legacy ...PasswordHasher) (*PasswordHashers, error) {
+	dummy, err := preferred.Hash("dummy-password-for-timing")
+	if err != nil {
+		return nil, fmt.Errorf("hash dummy password: %w", err)
+	}
+	return &PasswordHashers{preferred: preferred, legacy: legacy, dummyHash: dummy}, nil
+}
+
+// NewPasswordHashersFor собирает набор хешеров: algorithm — для новых хешей,
+// остальные известные алгоритмы остаются для проверки старых хешей.
+func NewPasswordHashersFor(algorithm string, argonParams Argon2idParams, bcryptCost int) (*PasswordHashers, error) {
+	argonHasher := NewArgon2idHasher(argonParams)
+	bcryptHasher := NewBcryptHasher(bcryptCost)
+
+	switch algorithm {
+	case PasswordAlgorithmArgon2id:
+		return NewPasswordHashers(argonHasher, bcryptHasher)
+	case PasswordAlgorithmBcrypt:
+		return NewPasswordHashers(bcryptHasher, argonHasher)
+	default:
+		return nil, fmt.Errorf("unknown password algorithm %q", algorithm)
+	}
+}
+
+func (h *PasswordHashers) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h *PasswordHashers) Verify(password, encoded string) (bool, bool, error) {
+	if h.preferred.Recognizes(encoded) {
+		return h.preferred.Verify(password, encoded)
+	}
+
+	for _, hasher := range h.legacy {
+		if hasher.Recognizes(encoded) {
+			ok, _, err := hasher.Verify(password, encoded)
+			return ok, ok, err
+		}
+	}
+
+	return false, false, ErrUnknownPasswordHash
+}
+
+// MaxPasswordBytes — предел длины нового пароля в байтах у алгоритма новых хешей; 0 — без предела.
+func (h *PasswordHashers) MaxPasswordBytes() int {
+	if _, ok := h.preferred.(*BcryptHasher); ok {
+		return BcryptMaxPasswordBytes
+	}
+	return 0
+}
+
+// VerifyDummy тратит столько же времени, сколько проверка настоящего пароля.
+func (h *PasswordHashers) VerifyDummy(password string) {
+	_, _, _ = h.preferred.Verify(password, h.dummyHash)
+}
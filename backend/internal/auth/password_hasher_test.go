@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// маленькие параметры, чтобы тесты не тратили 64 МиБ на каждый хеш
+var testArgon2Params = Argon2idParams{MemoryKiB: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHashPHCFormat(t *testing.T) {
+	encoded, err := NewArgon2idHasher(testArgon2Params).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("Hash() = %q, want PHC prefix $argon2id$v=19$m=64,t=1,p=1$", encoded)
+	}
+
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		t.Fatalf("decodeArgon2id() error = %v", err)
+	}
+	if params != testArgon2Params {
+		t.Fatalf("decodeArgon2id() params = %+v, want %+v", params, testArgon2Params)
+	}
+	if len(salt) != 16 || len(key) != 32 {
+		t.Fatalf("decodeArgon2id() salt %d bytes, key %d bytes; want 16 and 32", len(salt), len(key))
+	}
+}
+
+func TestDecodeArgon2idRejectsMalformed(t *testing.T) {
+	valid, err := NewArgon2idHasher(testArgon2Params).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, "$")
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "empty", encoded: ""},
+		{name: "bcrypt hash", encoded: "$2a$04$abcdefghijklmnopqrstuu5Jt4SSBsd7G6Vx3vLVrO7hWj9YOLvay"},
+		{name: "argon2i", encoded: strings.Replace(valid, "$argon2id$", "$argon2i$", 1)},
+		{name: "missing hash", encoded: strings.Join(parts[:5], "$")},
+		{name: "extra part", encoded: valid + "$extra"},
+		{name: "old version", encoded: strings.Replace(valid, "v=19", "v=16", 1)},
+		{name: "bad params", encoded: strings.Replace(valid, "m=64,t=1,p=1", "m=64;t=1;p=1", 1)},
+		{name: "bad salt", encoded: strings.Join([]string{"", parts[1], parts[2], parts[3], "!!!", parts[5]}, "$")},
+		{name: "bad key", encoded: strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], "!!!"}, "$")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := decodeArgon2id(tt.encoded); !errors.Is(err, ErrUnknownPasswordHash) {
+				t.Fatalf("decodeArgon2id() error = %v, want %v", err, ErrUnknownPasswordHash)
+			}
+		})
+	}
+}
+
+func TestArgon2idVerifyNeedsRehash(t *testing.T) {
+	encoded, err := NewArgon2idHasher(testArgon2Params).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	with := func(change func(p *Argon2idParams)) Argon2idParams {
+		p := testArgon2Params
+		change(&p)
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		params     Argon2idParams
+		password   string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{name: "same params", params: testArgon2Params, password: "correct horse", wantOK: true},
+		{name: "wrong password", params: testArgon2Params, password: "wrong horse"},
+		{
+			name:       "memory raised",
+			params:     with(func(p *Argon2idParams) { p.MemoryKiB = 128 }),
+			password:   "correct horse",
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:       "iterations raised",
+			params:     with(func(p *Argon2idParams) { p.Iterations = 2 }),
+			password:   "correct horse",
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:       "parallelism changed",
+			params:     with(func(p *Argon2idParams) { p.Parallelism = 2 }),
+			password:   "correct horse",
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:       "key length changed",
+			params:     with(func(p *Argon2idParams) { p.KeyLength = 64 }),
+			password:   "correct horse",
+			wantOK:     true,
+			wantRehash: true,
+		},
+		// длина соли не влияет на стойкость хеша, перехешировать из-за нее не нужно
+		{
+			name:     "salt length changed",
+			params:   with(func(p *Argon2idParams) { p.SaltLength = 32 }),
+			password: "correct horse",
+			wantOK:   true,
+		},
+		{
+			name:     "wrong password with old params",
+			params:   with(func(p *Argon2idParams) { p.MemoryKiB = 128 }),
+			password: "wrong horse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := NewArgon2idHasher(tt.params).Verify(tt.password, encoded)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if ok != tt.wantOK || needsRehash != tt.wantRehash {
+				t.Fatalf("Verify() = %v, %v; want %v, %v", ok, needsRehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
+
+// Хеш другого алгоритма проверяется его хешером и всегда помечается на перехеширование.
+func TestPasswordHashersVerifyLegacy(t *testing.T) {
+	argonHashers, err := NewPasswordHashersFor(PasswordAlgorithmArgon2id, testArgon2Params, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHashers, err := NewPasswordHashersFor(PasswordAlgorithmBcrypt, testArgon2Params, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argonHash, err := argonHashers.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := bcryptHashers.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		hashers    *PasswordHashers
+		password   string
+		encoded    string
+		wantOK     bool
+		wantRehash bool
+		wantErr    error
+	}{
+		{name: "argon2id preferred", hashers: argonHashers, password: "correct horse", encoded: argonHash, wantOK: true},
+		{
+			name:       "bcrypt legacy",
+			hashers:    argonHashers,
+			password:   "correct horse",
+			encoded:    bcryptHash,
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{name: "bcrypt legacy wrong password", hashers: argonHashers, password: "wrong horse", encoded: bcryptHash},
+		{
+			name:       "argon2id legacy",
+			hashers:    bcryptHashers,
+			password:   "correct horse",
+			encoded:    argonHash,
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{name: "bcrypt preferred", hashers: bcryptHashers, password: "correct horse", encoded: bcryptHash, wantOK: true},
+		{
+			name:     "unknown format",
+			hashers:  argonHashers,
+			password: "correct horse",
+			encoded:  "$scrypt$ln=15,r=8,p=1$c2FsdA$aGFzaA",
+			wantErr:  ErrUnknownPasswordHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := tt.hashers.Verify(tt.password, tt.encoded)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK || needsRehash != tt.wantRehash {
+				t.Fatalf("Verify() = %v, %v; want %v, %v", ok, needsRehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	_ "embed"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrPasswordTooShort      = errors.New("password is too short")
+	ErrPasswordTooLong       = errors.New("password is too long")
+	ErrPasswordTooCommon     = errors.New("password is too common")
+	ErrPasswordContainsEmail = errors.New("password must not contain the email")
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsRaw string
+
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength — в символах, ограничивает стоимость хеширования.
+	MaxLength int
+	// MaxBytes — в байтах UTF-8, для bcrypt (72 байта: 72 символа кириллицы уже не пройдут); 0 — без предела.
+	MaxBytes    int
+	CheckCommon bool
+
+	common map[string]struct{}
+}
+
+func NewPasswordPolicy(minLength, maxLength, maxBytes int, checkCommon bool) *PasswordPolicy {
+	policy := &PasswordPolicy{
+		MinLength:   minLength,
+		MaxLength:   maxLength,
+		MaxBytes:    maxBytes,
+		CheckCommon: checkCommon,
+	}
+	if checkCommon {
+		policy.common = parseCommonPasswords(commonPasswordsRaw)
+	}
+	return policy
+}
+
+// Validate проверяет пароль нового или меняющего пароль пользователя.
+func (p *PasswordPolicy) Validate(password, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return ErrPasswordTooShort
+	}
+	if (p.MaxLength > 0 && length > p.MaxLength) || (p.MaxBytes > 0 && len(password) > p.MaxBytes) {
+		return ErrPasswordTooLong
+	}
+
+	lower := strings.ToLower(password)
+	if p.CheckCommon {
+		if _, ok := p.common[lower]; ok {
+			return ErrPasswordTooCommon
+		}
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email != "" {
+		local, _, _ := strings.Cut(email, "@")
+		// короткая локальная часть (например "ab@") дает слишком много ложных срабатываний
+		if strings.Contains(lower, email) || (len(local) >= 4 && strings.Contains(lower, local)) {
+			return ErrPasswordContainsEmail
+		}
+	}
+
+	return nil
+}
+
+func parseCommonPasswords(raw string) map[string]struct{} {
+	out := make(map[string]struct{})
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out[strings.ToLower(line)] = struct{}{}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int
+		password string
+		want     error
+	}{
+		{name: "ascii at limit", maxBytes: BcryptMaxPasswordBytes, password: strings.Repeat("a", 72)},
+		{
+			name:     "cyrillic over bcrypt bytes",
+			maxBytes: BcryptMaxPasswordBytes,
+			password: strings.Repeat("ж", 72),
+			want:     ErrPasswordTooLong,
+		},
+		{name: "cyrillic within bcrypt bytes", maxBytes: BcryptMaxPasswordBytes, password: strings.Repeat("ж", 36)},
+		{name: "cyrillic without byte limit", password: strings.Repeat("ж", 72)},
+		{name: "over rune limit", password: strings.Repeat("a", 73), want: ErrPasswordTooLong},
+		{name: "too short", password: "ж", want: ErrPasswordTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPasswordPolicy(8, 72, tt.maxBytes, false)
+			if err := policy.Validate(tt.password, "user@example.com"); !errors.Is(err, tt.want) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// Пароль, прошедший политику, должен хешироваться: иначе регистрация падает с 500.
+func TestPasswordPolicyMatchesBcrypt(t *testing.T) {
+	hashers, err := NewPasswordHashersFor(PasswordAlgorithmBcrypt, Argon2idParams{}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := NewPasswordPolicy(8, 72, hashers.MaxPasswordBytes(), false)
+
+	for _, password := range []string{strings.Repeat("ж", 36), strings.Repeat("ж", 37), strings.Repeat("a", 72)} {
+		if policy.Validate(password, "") != nil {
+			continue
+		}
+		if _, err := hashers.Hash(password); err != nil {
+			t.Fatalf("policy accepted %d-byte password, Hash() error = %v", len(password), err)
+		}
+	}
+
+	ok, _, err := hashers.Verify(strings.Repeat("ж", 72), "$2a$04$abcdefghijklmnopqrstuu5Jt4SSBsd7G6Vx3vLVrO7hWj9YOLvay")
+	if ok || err != nil {
+		t.Fatalf("Verify(144-byte password) = %v, %v; want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// SecretBox шифрует секреты, которые нужно хранить в БД в обратимом виде (TOTP).
+// Ключ AES-256 получается как SHA-256 от строки ключа из конфига.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+func NewSecretBox(key string) (*SecretBox, error) {
+	sum := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretBox{aead: aead}, nil
+}
+
+func (b *SecretBox) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *SecretBox) Open(ciphertext string) (string, error) {
+	raw, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	if len(raw) < b.aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := raw[:b.aead.NonceSize()], raw[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plain), nil
+}
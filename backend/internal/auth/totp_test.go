@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// секрет из тестовых векторов RFC 6238 ("12345678901234567890") в base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      int64
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		// коды RFC 6238 (8 цифр) без двух старших разрядов
+		{name: "rfc vector 59", secret: rfcTOTPSecret, code: "287082", now: 59, wantStep: 1, wantOK: true},
+		{
+			name: "rfc vector 1111111109", secret: rfcTOTPSecret, code: "081804", now: 1111111109,
+			wantStep: 37037036, wantOK: true,
+		},
+		{
+			name: "rfc vector 1234567890", secret: rfcTOTPSecret, code: "005924", now: 1234567890,
+			wantStep: 41152263, wantOK: true,
+		},
+		{
+			name: "rfc vector 2000000000", secret: rfcTOTPSecret, code: "279037", now: 2000000000,
+			wantStep: 66666666, wantOK: true,
+		},
+		{
+			name: "lowercase secret", secret: strings.ToLower(rfcTOTPSecret), code: "287082", now: 59,
+			wantStep: 1, wantOK: true,
+		},
+		{name: "surrounding spaces", secret: rfcTOTPSecret, code: " 287082 ", now: 59, wantStep: 1, wantOK: true},
+		{name: "previous step within skew", secret: rfcTOTPSecret, code: "287082", now: 89, wantStep: 1, wantOK: true},
+		{name: "next step within skew", secret: rfcTOTPSecret, code: "287082", now: 5, wantStep: 1, wantOK: true},
+		{name: "outside skew", secret: rfcTOTPSecret, code: "287082", now: 95},
+		{name: "wrong code", secret: rfcTOTPSecret, code: "287083", now: 59},
+		{name: "short code", secret: rfcTOTPSecret, code: "28708", now: 59},
+		{name: "invalid secret", secret: "not base32!", code: "287082", now: 59},
+		{name: "step already used", secret: rfcTOTPSecret, code: "287082", now: 59, lastStep: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.now, 0), tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Fatalf("ValidateTOTP() = %d, %v; want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Код, принятый один раз, нельзя предъявить повторно, даже пока он еще в окне skew.
+func TestValidateTOTPRejectsReuse(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	code := totpCode(key, current)
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	if !ok || step != current {
+		t.Fatalf("first ValidateTOTP() = %d, %v; want %d, true", step, ok, current)
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(totpPeriod), step); ok {
+		t.Fatal("second ValidateTOTP() accepted already used step")
+	}
+	// код следующего шага после использованного по-прежнему проходит
+	next := totpCode(key, current+1)
+	if step, ok := ValidateTOTP(secret, next, now.Add(totpPeriod), current); !ok || step != current+1 {
+		t.Fatalf("next step ValidateTOTP() = %d, %v; want %d, true", step, ok, current+1)
+	}
+}
+
+func TestNewTOTPSecretAndURI(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes, err = %v; want 20 bytes", secret, len(key), err)
+	}
+
+	uri, err := url.Parse(TOTPURI("Warranty Days", "user@example.com", secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Warranty Days:user@example.com" {
+		t.Fatalf("TOTPURI() = %s", uri)
+	}
+	q := uri.Query()
+	if q.Get("secret") != secret || q.Get("issuer") != "Warranty Days" || q.Get("digits") != "6" ||
+		q.Get("period") != "30" || q.Get("algorithm") != "SHA1" {
+		t.Fatalf("TOTPURI() query = %v", q)
+	}
+}
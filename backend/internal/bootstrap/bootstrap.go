@@ -0,0 +1,456 @@
+// Package bootstrap — общая сборка API для cmd/api и cmd/api-gin: конфиг, логгер, БД, сервисы,
+// хендлеры и фоновые воркеры. Реализации различаются только роутером, который строится из App.
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/buildinfo"
+	"warranty_days/internal/config"
+	"warranty_days/internal/db"
+	"warranty_days/internal/events"
+	"warranty_days/internal/grpcapi"
+	"warranty_days/internal/health"
+	"warranty_days/internal/httpapi/handler"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/routes"
+	"warranty_days/internal/jobs"
+	"warranty_days/internal/logging"
+	"warranty_days/internal/metrics"
+	"warranty_days/internal/models"
+	"warranty_days/internal/notify"
+	"warranty_days/internal/outbox"
+	"warranty_days/internal/pgnotify"
+	"warranty_days/internal/ratelimit"
+	"warranty_days/internal/repo"
+	"warranty_days/internal/scheduler"
+	"warranty_days/internal/server"
+	"warranty_days/internal/service"
+	"warranty_days/internal/tracing"
+	"warranty_days/internal/webhook"
+	"warranty_days/internal/worker"
+	"warranty_days/migrations"
+)
+
+// App — все, что нужно фабрике роутера (router.NewMux, ginrouter.NewHandler) и запуску сервера.
+type App struct {
+	Config      config.Config
+	Logger      *slog.Logger
+	Handlers    routes.Handlers
+	JWT         *auth.JWTService
+	Deprecation middleware.Deprecation
+	RateLimits  middleware.RateLimits
+	Browser     middleware.BrowserPolicy
+
+	gormDB          *gorm.DB
+	pgxPool         *pgxpool.Pool
+	rateLimitDB     *gorm.DB
+	shutdownTracing func(context.Context) error
+	claimStream     *handler.ClaimStreamHandler
+	grpcServer      *grpcapi.Server
+	outboxRelay     *outbox.Relay
+	webhookWorker   *webhook.Worker
+	notifyWorker    *notify.Worker
+	claimListener   *pgnotify.Listener
+	jobScheduler    *scheduler.Scheduler
+}
+
+// New загружает конфиг, подключается к БД, собирает сервисы и хендлеры и запускает фоновые воркеры,
+// включенные в конфиге. HTTP-сервер запускает Run.
+func New(ctx context.Context) (*App, error) {
+	loadDotEnv(".env", "../.env", "../../.env")
+
+	bootstrapLogger := logging.New(os.Getenv("APP_ENV"), os.Getenv("LOG_LEVEL"), os.Stdout)
+	slog.SetDefault(bootstrapLogger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	logger := logging.New(cfg.AppEnv, cfg.LogLevel, os.Stdout)
+	slog.SetDefault(logger)
+
+	app := &App{Config: cfg, Logger: logger}
+
+	// трейсинг поднимаем до БД, чтобы GORM-плагин подхватил провайдер
+	app.shutdownTracing, err = tracing.Setup(ctx, tracing.Config{
+		Enabled:        cfg.TracingEnabled,
+		ServiceName:    "warranty_days",
+		ServiceVersion: buildinfo.Get().Version,
+		Environment:    cfg.AppEnv,
+		SampleRatio:    cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tracing setup: %w", err)
+	}
+
+	// БД может подняться позже сервиса (docker compose, k8s) — ждем ее с backoff
+	dbBackoff := worker.Backoff{Initial: 500 * time.Millisecond, Max: cfg.DBConnectRetryMax}
+	err = server.Retry(ctx, logger, "postgres", dbBackoff, func(context.Context) error {
+		var err error
+		app.gormDB, err = db.NewGorm(cfg.DatabaseURL())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gorm connect: %w", err)
+	}
+	gormDB := app.gormDB
+
+	// LISTEN/NOTIFY и блокировка лидера планировщика держат соединения pgx вне пула gorm
+	if cfg.ClaimNotifyEnabled || cfg.SchedulerEnabled {
+		err = server.Retry(ctx, logger, "postgres pgx pool", dbBackoff, func(ctx context.Context) error {
+			var err error
+			app.pgxPool, err = db.NewPool(ctx, cfg.DatabaseURL())
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("pgx pool connect: %w", err)
+		}
+	}
+
+	// Repositories
+	claimRepo := repo.NewClaimRepo(gormDB)
+	userRepo := repo.NewUserRepo(gormDB)
+	invitationRepo := repo.NewInvitationRepo(gormDB)
+	verificationRepo := repo.NewEmailVerificationRepo(gormDB)
+	mfaRepo := repo.NewMFARepo(gormDB)
+	preferencesRepo := repo.NewPreferencesRepo(gormDB)
+	webhookRepo := repo.NewWebhookRepo(gormDB)
+	outboxRepo := repo.NewOutboxRepo(gormDB)
+	claimChangeRepo := repo.NewClaimChangeRepo(gormDB)
+	jobRepo := repo.NewJobRepo(gormDB)
+	warrantyStatusRepo := repo.NewWarrantyStatusRepo(gormDB)
+	dataQualityRepo := repo.NewDataQualityRepo(gormDB)
+	notificationRepo := repo.NewNotificationRepo(gormDB)
+	txManager := repo.NewTxManager(gormDB)
+
+	// Services
+	app.JWT = auth.NewJWTService(
+		cfg.JWTSecret,
+		cfg.JWTIssuer,
+		cfg.JWTAccessTTL,
+		cfg.JWTRefreshTTL,
+	)
+	jwtSvc := app.JWT
+	passwordHashers, err := auth.NewPasswordHashersFor(
+		cfg.PasswordHasher,
+		auth.Argon2idParams{
+			MemoryKiB:   uint32(cfg.Argon2MemoryKiB),
+			Iterations:  uint32(cfg.Argon2Iterations),
+			Parallelism: uint8(cfg.Argon2Parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		cfg.BcryptCost,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("password hasher: %w", err)
+	}
+	passwordPolicy := auth.NewPasswordPolicy(
+		cfg.PasswordMinLength,
+		cfg.PasswordMaxLength,
+		passwordHashers.MaxPasswordBytes(),
+		cfg.PasswordCheckCommon,
+	)
+
+	// шаблоны встроены в бинарник; битый шаблон — ошибка на старте, а не при первой отправке
+	notifyTemplates, err := notify.LoadTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("notification templates: %w", err)
+	}
+	notifier := notify.NewNotifier(notify.ConfigFromApp(cfg), notificationRepo, notifyTemplates)
+
+	authSvc := service.NewAuthService(userRepo, mfaRepo, jwtSvc, passwordHashers)
+	registrationSvc := service.NewRegistrationService(
+		userRepo,
+		invitationRepo,
+		verificationRepo,
+		txManager,
+		notifier,
+		passwordHashers,
+		passwordPolicy,
+		service.RegistrationPolicy{
+			Mode:                      cfg.RegistrationMode,
+			AllowedDomains:            cfg.RegistrationDomains,
+			EmailVerificationRequired: cfg.EmailVerificationRequired,
+			EmailVerificationTTL:      cfg.EmailVerificationTTL,
+			InvitationTTL:             cfg.InvitationTTL,
+		},
+	)
+
+	mfaSecretBox, err := auth.NewSecretBox(cfg.MFAEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("mfa secret box: %w", err)
+	}
+	mfaSvc := service.NewMFAService(userRepo, mfaRepo, txManager, jwtSvc, authSvc, mfaSecretBox, cfg.MFAIssuer)
+	profileSvc := service.NewProfileService(userRepo, preferencesRepo, notificationRepo)
+	claimsSvc := service.NewClaimsService(claimRepo, txManager, profileSvc, logger)
+
+	webhookSecretBox, err := auth.NewSecretBox(cfg.WebhookSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("webhook secret box: %w", err)
+	}
+	warrantyThresholds := service.WarrantyThresholds{Warning: cfg.WarrantyDaysWarning, Limit: cfg.WarrantyDaysLimit}
+	claimWriteSvc := service.NewClaimWriteService(claimRepo, outboxRepo, txManager, warrantyThresholds)
+	webhookSvc := service.NewWebhookService(webhookRepo, webhookSecretBox)
+	// задачи известны каждому инстансу ради API администратора, выполняются только при SCHEDULER_ENABLED
+	app.jobScheduler, err = scheduler.New(
+		scheduler.ConfigFromApp(cfg),
+		scheduler.NewPostgresLocker(app.pgxPool),
+		jobRepo,
+		[]scheduler.Job{
+			jobs.WarrantyStatusRecalcJob(cfg.JobWarrantyStatusSchedule, warrantyStatusRepo, txManager, warrantyThresholds),
+			jobs.DataQualityScanJob(cfg.JobDataQualitySchedule, dataQualityRepo, logger),
+			jobs.SessionCleanupJob(
+				cfg.JobSessionCleanupSchedule,
+				verificationRepo,
+				invitationRepo,
+				mfaRepo,
+				cfg.SessionCleanupRetention,
+			),
+		},
+		logger,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler setup: %w", err)
+	}
+	jobSvc := service.NewJobService(jobRepo, app.jobScheduler)
+
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("gorm sql db: %w", err)
+	}
+
+	// Metrics
+	var appMetrics *metrics.Metrics
+	if cfg.MetricsEnabled {
+		appMetrics = metrics.New()
+		appMetrics.RegisterDBStats(sqlDB, cfg.DBName)
+		// гейдж читает vin_warranty_status, который пересчитывает задача warranty_status_recalc
+		appMetrics.RegisterVINsOverLimit(
+			func(ctx context.Context) (int64, error) {
+				return warrantyStatusRepo.CountWithStatus(ctx, models.WarrantyStatusExceeded)
+			},
+			cfg.WarrantyDaysLimit,
+			cfg.MetricsDomainRefresh,
+		)
+	}
+
+	// Handlers
+	sessionCookies := handler.SessionCookies{
+		Enabled:  cfg.AuthCookieEnabled,
+		Secure:   cfg.AuthCookieSecure,
+		SameSite: cfg.AuthCookieSameSite,
+		Domain:   cfg.AuthCookieDomain,
+		TTL:      cfg.JWTRefreshTTL,
+	}
+	claimsHandler := handler.NewClaimsHandler(claimsSvc, handler.ClaimsViewV1{}, appMetrics, logger)
+	// шина событий этого инстанса: ее наполняют слушатель изменений заявок и outbox relay,
+	// читают потоки /claims/stream
+	eventBus := events.NewBus()
+	app.claimStream = handler.NewClaimStreamHandler(
+		claimsSvc,
+		handler.ClaimsViewV1{},
+		eventBus,
+		handler.StreamConfig{
+			Heartbeat:    cfg.SSEHeartbeatInterval,
+			MaxDuration:  cfg.SSEMaxDuration,
+			WriteTimeout: cfg.HTTPWriteTimeout,
+		},
+		logger,
+	)
+
+	healthChecker := health.NewChecker(
+		cfg.HealthCheckTimeout,
+		logger,
+		health.DatabasePing(sqlDB),
+		health.SchemaVersion(sqlDB, migrations.LatestVersion()),
+		health.PoolSaturation(sqlDB, 0.9),
+	)
+
+	app.Handlers = routes.Handlers{
+		Claims:       claimsHandler,
+		ClaimStream:  app.claimStream,
+		Auth:         handler.NewAuthHandler(authSvc, registrationSvc, appMetrics, sessionCookies, logger),
+		Invitation:   handler.NewInvitationHandler(registrationSvc, logger),
+		MFA:          handler.NewMFAHandler(mfaSvc, authSvc, sessionCookies, logger),
+		Profile:      handler.NewProfileHandler(profileSvc, logger),
+		ClaimWrite:   handler.NewClaimWriteHandler(claimWriteSvc, logger),
+		Webhooks:     handler.NewWebhookHandler(webhookSvc, logger),
+		Jobs:         handler.NewJobHandler(jobSvc, logger),
+		Health:       handler.NewHealthHandler(healthChecker, logger),
+		Metrics:      appMetrics,
+		MetricsToken: cfg.MetricsToken,
+	}
+	app.Deprecation = middleware.Deprecation{
+		Enabled:      cfg.LegacyRoutesEnabled,
+		DeprecatedAt: cfg.LegacyRoutesDeprecatedAt,
+		Sunset:       cfg.LegacyRoutesSunset,
+	}
+	app.Browser = middleware.BrowserPolicy{
+		CORS: middleware.CORSPolicy{
+			AllowedOrigins:   cfg.CORSAllowedOrigins,
+			AllowedMethods:   cfg.CORSAllowedMethods,
+			AllowedHeaders:   cfg.CORSAllowedHeaders,
+			ExposedHeaders:   cfg.CORSExposedHeaders,
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		},
+		Security: middleware.SecurityPolicy{
+			HSTSMaxAge:            cfg.HSTSMaxAge,
+			HSTSIncludeSubdomains: cfg.HSTSIncludeSubdomains,
+			FrameOptions:          cfg.FrameOptions,
+			ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+		},
+	}
+
+	// лимиты запросов: postgres нужен, когда инстансов несколько и счетчик должен быть общим
+	app.RateLimits = middleware.RateLimits{
+		Groups: map[string]ratelimit.Limit{
+			routes.RateGroupAuth:   cfg.RateLimitAuth,
+			routes.RateGroupClaims: cfg.RateLimitClaims,
+			routes.RateGroupAPI:    cfg.RateLimitAPI,
+		},
+		TrustedProxies: cfg.RateLimitTrustedProxies,
+		Logger:         logger,
+	}
+	if cfg.RateLimitEnabled {
+		switch cfg.RateLimitBackend {
+		case config.RateLimitBackendPostgres:
+			// свой маленький пул: лимитер не ждет соединений, занятых запросами API, и не занимает их сам
+			app.rateLimitDB, err = db.NewGormPool(cfg.DatabaseURL(), cfg.RateLimitDBMaxConns)
+			if err != nil {
+				return nil, fmt.Errorf("rate limit db connect: %w", err)
+			}
+			app.RateLimits.Limiter = ratelimit.NewPostgresLimiter(app.rateLimitDB, cfg.RateLimitDBTimeout, logger)
+			// пока БД лимитов недоступна, каждый инстанс считает лимиты у себя
+			app.RateLimits.Fallback = ratelimit.NewMemoryLimiter()
+		default:
+			app.RateLimits.Limiter = ratelimit.NewMemoryLimiter()
+		}
+	}
+
+	// gRPC API для внутренних сервисов работает рядом с HTTP на тех же сервисах
+	if cfg.GRPCEnabled {
+		app.grpcServer = grpcapi.New(grpcapi.ConfigFromApp(cfg), claimsSvc, appMetrics, jwtSvc, healthChecker, logger)
+		if err := app.grpcServer.Start(); err != nil {
+			return nil, fmt.Errorf("grpc server: %w", err)
+		}
+	}
+
+	// события из outbox отдаются получателям из OUTBOX_PUBLISHERS
+	if cfg.OutboxRelayEnabled {
+		publishers := make([]events.Publisher, 0, len(cfg.OutboxPublishers))
+		for _, name := range cfg.OutboxPublishers {
+			switch name {
+			case config.OutboxPublisherWebhook:
+				publishers = append(publishers, webhook.NewDispatcher(webhookRepo))
+			case config.OutboxPublisherLog:
+				publishers = append(publishers, outbox.NewLogPublisher(logger))
+			case config.OutboxPublisherBus:
+				publishers = append(publishers, eventBus)
+			case config.OutboxPublisherEmail:
+				publishers = append(publishers, notifier)
+			}
+		}
+		app.outboxRelay = outbox.NewRelay(outbox.ConfigFromApp(cfg), outboxRepo, txManager, publishers, logger)
+		app.outboxRelay.Start()
+	}
+
+	// изменения заявок с любого инстанса приходят через LISTEN/NOTIFY на отдельном соединении pgx
+	if cfg.ClaimNotifyEnabled {
+		app.claimListener = pgnotify.NewListener(
+			pgnotify.ConfigFromApp(cfg),
+			app.pgxPool,
+			claimChangeRepo,
+			eventBus,
+			logger,
+		)
+		app.claimListener.Start()
+	}
+
+	// доставки вебхуков читает из очереди в БД отдельный воркер; в API-инстансе его можно выключить
+	if cfg.WebhooksEnabled {
+		app.webhookWorker = webhook.NewWorker(webhook.ConfigFromApp(cfg), webhookRepo, webhookSecretBox, logger)
+		app.webhookWorker.Start()
+	}
+
+	// письма из очереди отправляет отдельный воркер; в API-инстансе его можно выключить
+	if cfg.NotifyEnabled {
+		var sender notify.Sender
+		switch cfg.NotifySender {
+		case config.NotifySenderSMTP:
+			sender = notify.NewSMTPSender(notify.SMTPConfigFromApp(cfg))
+		case config.NotifySenderEML:
+			sender = notify.NewEMLSender(cfg.NotifyEMLDir)
+		default:
+			sender = notify.NewLogSender(logger)
+		}
+		app.notifyWorker = notify.NewWorker(notify.ConfigFromApp(cfg), notificationRepo, sender, logger)
+		app.notifyWorker.Start()
+	}
+
+	// задачи выполняет один инстанс из запущенных с SCHEDULER_ENABLED — тот, что взял блокировку лидера
+	if cfg.SchedulerEnabled {
+		app.jobScheduler.Start()
+	}
+
+	return app, nil
+}
+
+// Run обслуживает handler до отмены ctx, затем останавливает воркеры и закрывает соединения с БД.
+func (a *App) Run(ctx context.Context, handler http.Handler) error {
+	srv := server.New(server.ConfigFromApp(a.Config), handler, a.Logger)
+	srv.OnDrain(a.claimStream.Close)
+	// gRPC останавливаем до БД: активные вызовы еще читают из нее
+	if a.grpcServer != nil {
+		srv.OnShutdown("grpc", a.grpcServer.Shutdown)
+	}
+	if a.outboxRelay != nil {
+		srv.OnShutdown("outbox", a.outboxRelay.Shutdown)
+	}
+	if a.webhookWorker != nil {
+		srv.OnShutdown("webhooks", a.webhookWorker.Shutdown)
+	}
+	if a.notifyWorker != nil {
+		srv.OnShutdown("email notifications", a.notifyWorker.Shutdown)
+	}
+	if a.claimListener != nil {
+		srv.OnShutdown("claim notifications", a.claimListener.Shutdown)
+	}
+	if a.Config.SchedulerEnabled {
+		srv.OnShutdown("scheduler", a.jobScheduler.Shutdown)
+	}
+	if a.pgxPool != nil {
+		srv.OnShutdown("pgx pool", func(context.Context) error {
+			a.pgxPool.Close()
+			return nil
+		})
+	}
+	if a.rateLimitDB != nil {
+		srv.OnShutdown("rate limit postgres", func(context.Context) error { return db.CloseGorm(a.rateLimitDB) })
+	}
+	srv.OnShutdown("postgres", func(context.Context) error { return db.CloseGorm(a.gormDB) })
+	srv.OnShutdown("tracing", a.shutdownTracing)
+
+	return srv.Run(ctx)
+}
+
+func loadDotEnv(paths ...string) {
+	for _, path := range paths {
+		if err := godotenv.Load(path); err == nil {
+			return
+		}
+	}
+}
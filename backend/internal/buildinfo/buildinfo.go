@@ -0,0 +1,49 @@
+// Package buildinfo holds version data injected at link time
+package buildinfo
+
+import "runtime/debug"
+
+// Заполняются при сборке:
+//
+//	go build -ldflags "-X warranty_days/internal/buildinfo.Version=1.2.3 \
+//	  -X warranty_days/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X warranty_days/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/api
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get возвращает данные сборки. Если ldflags не передали, берет коммит из VCS-меток Go.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = bi.GoVersion
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+
+	return info
+}
@@ -4,8 +4,55 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/mail"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	// база часовых поясов для SCHEDULER_TIMEZONE, если в образе ее нет
+	_ "time/tzdata"
+
+	"warranty_days/internal/ratelimit"
+)
+
+const (
+	RegistrationModeOpen   = "open"
+	RegistrationModeDomain = "domain"
+	RegistrationModeInvite = "invite"
+)
+
+const (
+	RateLimitBackendMemory   = "memory"
+	RateLimitBackendPostgres = "postgres"
+)
+
+// Получатели событий из outbox.
+const (
+	OutboxPublisherWebhook = "webhook"
+	OutboxPublisherLog     = "log"
+	OutboxPublisherBus     = "bus"
+	OutboxPublisherEmail   = "email"
+)
+
+// Куда уходят письма.
+const (
+	NotifySenderLog  = "log"
+	NotifySenderSMTP = "smtp"
+	NotifySenderEML  = "eml"
+)
+
+// Режимы TLS для SMTP.
+const (
+	// SMTPTLSStartTLS — обычное соединение (обычно порт 587), затем STARTTLS; без поддержки STARTTLS — ошибка.
+	SMTPTLSStartTLS = "starttls"
+	// SMTPTLSImplicit — TLS с первого байта (обычно порт 465).
+	SMTPTLSImplicit = "tls"
+	// SMTPTLSNone — без шифрования, только для локального релея или тестового сервера.
+	SMTPTLSNone = "none"
 )
 
 type Config struct {
@@ -22,6 +69,136 @@ type Config struct {
 	JWTIssuer     string
 	JWTAccessTTL  time.Duration
 	JWTRefreshTTL time.Duration
+
+	HTTPReadTimeout       time.Duration
+	HTTPReadHeaderTimeout time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	HTTPMaxHeaderBytes    int
+	HTTPShutdownTimeout   time.Duration
+	HTTPCloseTimeout      time.Duration
+	DBConnectRetryMax     time.Duration
+	HealthCheckTimeout    time.Duration
+
+	RegistrationMode          string
+	RegistrationDomains       []string
+	EmailVerificationRequired bool
+	EmailVerificationTTL      time.Duration
+	InvitationTTL             time.Duration
+
+	MFAIssuer        string
+	MFAEncryptionKey string
+
+	PasswordHasher      string
+	Argon2MemoryKiB     int
+	Argon2Iterations    int
+	Argon2Parallelism   int
+	BcryptCost          int
+	PasswordMinLength   int
+	PasswordMaxLength   int
+	PasswordCheckCommon bool
+
+	MetricsEnabled       bool
+	MetricsToken         string
+	MetricsDomainRefresh time.Duration
+	WarrantyDaysLimit    int
+
+	TracingEnabled     bool
+	TracingSampleRatio float64
+
+	LegacyRoutesEnabled      bool
+	LegacyRoutesDeprecatedAt time.Time
+	LegacyRoutesSunset       time.Time
+
+	GRPCEnabled           bool
+	GRPCAddr              string
+	GRPCReflectionEnabled bool
+
+	RateLimitEnabled bool
+	RateLimitBackend string
+	// RateLimitDBMaxConns и RateLimitDBTimeout — отдельный пул и таймаут запроса для бэкенда postgres:
+	// лимитер не должен ждать соединения вместе с запросами API.
+	RateLimitDBMaxConns     int
+	RateLimitDBTimeout      time.Duration
+	RateLimitAuth           ratelimit.Limit
+	RateLimitClaims         ratelimit.Limit
+	RateLimitAPI            ratelimit.Limit
+	RateLimitTrustedProxies []netip.Prefix
+
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSExposedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	FrameOptions          string
+	ContentSecurityPolicy string
+
+	AuthCookieEnabled  bool
+	AuthCookieSecure   bool
+	AuthCookieSameSite http.SameSite
+	AuthCookieDomain   string
+
+	WarrantyDaysWarning   int
+	WebhooksEnabled       bool
+	WebhookSecretKey      string
+	WebhookPollInterval   time.Duration
+	WebhookBatchSize      int
+	WebhookTimeout        time.Duration
+	WebhookMaxAttempts    int
+	WebhookBackoffInitial time.Duration
+	WebhookBackoffMax     time.Duration
+	// WebhookAllowedNetworks — внутренние подсети, куда воркеру можно слать вебхуки; остальные
+	// частные, loopback и link-local адреса запрещены.
+	WebhookAllowedNetworks []netip.Prefix
+
+	OutboxRelayEnabled bool
+	OutboxPublishers   []string
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int
+	OutboxBackoffMax   time.Duration
+	OutboxRetention    time.Duration
+
+	SSEHeartbeatInterval time.Duration
+	SSEMaxDuration       time.Duration
+
+	ClaimNotifyEnabled      bool
+	ClaimNotifyReconnectMax time.Duration
+	ClaimChangesRetention   time.Duration
+
+	SchedulerEnabled          bool
+	SchedulerPollInterval     time.Duration
+	SchedulerLocation         *time.Location
+	SchedulerJobTimeout       time.Duration
+	SchedulerHistoryRetention time.Duration
+	JobWarrantyStatusSchedule string
+	JobDataQualitySchedule    string
+	JobSessionCleanupSchedule string
+	SessionCleanupRetention   time.Duration
+
+	NotifyEnabled         bool
+	NotifySender          string
+	NotifyFrom            mail.Address
+	NotifyDefaultLanguage string
+	NotifyVerifyEmailURL  string
+	NotifyEMLDir          string
+	NotifyPollInterval    time.Duration
+	NotifyBatchSize       int
+	NotifyMaxAttempts     int
+	NotifyBackoffInitial  time.Duration
+	NotifyBackoffMax      time.Duration
+	NotifyRetention       time.Duration
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTLS      string
+	SMTPTimeout  time.Duration
 }
 
 func Load() (Config, error) {
@@ -35,6 +212,408 @@ func Load() (Config, error) {
 		return Config{}, err
 	}
 
+	readTimeout, err := parseDurationEnv("HTTP_READ_TIMEOUT", "15s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	readHeaderTimeout, err := parseDurationEnv("HTTP_READ_HEADER_TIMEOUT", "5s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	writeTimeout, err := parseDurationEnv("HTTP_WRITE_TIMEOUT", "30s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	idleTimeout, err := parseDurationEnv("HTTP_IDLE_TIMEOUT", "120s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxHeaderBytes, err := parseIntEnv("HTTP_MAX_HEADER_BYTES", 64*1024)
+	if err != nil {
+		return Config{}, err
+	}
+
+	shutdownTimeout, err := parseDurationEnv("HTTP_SHUTDOWN_TIMEOUT", "20s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	closeTimeout, err := parseDurationEnv("HTTP_CLOSE_TIMEOUT", "10s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	dbRetryMax, err := parseDurationEnv("DB_CONNECT_RETRY_MAX", "30s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	healthTimeout, err := parseDurationEnv("HEALTH_CHECK_TIMEOUT", "2s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	verificationRequired, err := parseBoolEnv("EMAIL_VERIFICATION_REQUIRED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	verificationTTL, err := parseDurationEnv("EMAIL_VERIFICATION_TTL", "24h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	invitationTTL, err := parseDurationEnv("INVITATION_TTL", "168h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	argonMemory, err := parseIntEnv("ARGON2_MEMORY_KIB", 64*1024)
+	if err != nil {
+		return Config{}, err
+	}
+
+	argonIterations, err := parseIntEnv("ARGON2_ITERATIONS", 3)
+	if err != nil {
+		return Config{}, err
+	}
+
+	argonParallelism, err := parseIntEnv("ARGON2_PARALLELISM", 2)
+	if err != nil {
+		return Config{}, err
+	}
+
+	bcryptCost, err := parseIntEnv("BCRYPT_COST", 10)
+	if err != nil {
+		return Config{}, err
+	}
+
+	passwordMin, err := parseIntEnv("PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		return Config{}, err
+	}
+
+	passwordMax, err := parseIntEnv("PASSWORD_MAX_LENGTH", 128)
+	if err != nil {
+		return Config{}, err
+	}
+
+	passwordCheckCommon, err := parseBoolEnv("PASSWORD_CHECK_COMMON", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	metricsEnabled, err := parseBoolEnv("METRICS_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	metricsRefresh, err := parseDurationEnv("METRICS_DOMAIN_REFRESH", "5m")
+	if err != nil {
+		return Config{}, err
+	}
+
+	warrantyDaysLimit, err := parseIntEnv("WARRANTY_DAYS_LIMIT", 30)
+	if err != nil {
+		return Config{}, err
+	}
+
+	tracingEnabled, err := parseBoolEnv("TRACING_ENABLED", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	tracingSampleRatio, err := parseFloatEnv("TRACING_SAMPLE_RATIO", 1)
+	if err != nil {
+		return Config{}, err
+	}
+
+	legacyRoutesEnabled, err := parseBoolEnv("LEGACY_ROUTES_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// даты вывода из эксплуатации задает оператор: без них заголовки Deprecation и Sunset не отдаются
+	legacyDeprecatedAt, err := parseDateEnv("LEGACY_ROUTES_DEPRECATED_AT", "")
+	if err != nil {
+		return Config{}, err
+	}
+
+	legacySunset, err := parseDateEnv("LEGACY_ROUTES_SUNSET", "")
+	if err != nil {
+		return Config{}, err
+	}
+
+	grpcEnabled, err := parseBoolEnv("GRPC_ENABLED", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	grpcReflection, err := parseBoolEnv("GRPC_REFLECTION_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	rateLimitEnabled, err := parseBoolEnv("RATE_LIMIT_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	rateLimitDBMaxConns, err := parseIntEnv("RATE_LIMIT_DB_MAX_CONNS", 2)
+	if err != nil {
+		return Config{}, err
+	}
+
+	rateLimitDBTimeout, err := parseDurationEnv("RATE_LIMIT_DB_TIMEOUT", "100ms")
+	if err != nil {
+		return Config{}, err
+	}
+
+	rateLimitAuth, err := parseLimitEnv("RATE_LIMIT_AUTH", "10/1m")
+	if err != nil {
+		return Config{}, err
+	}
+
+	rateLimitClaims, err := parseLimitEnv("RATE_LIMIT_CLAIMS", "60/1m")
+	if err != nil {
+		return Config{}, err
+	}
+
+	rateLimitAPI, err := parseLimitEnv("RATE_LIMIT_API", "300/1m")
+	if err != nil {
+		return Config{}, err
+	}
+
+	trustedProxies, err := parsePrefixListEnv("RATE_LIMIT_TRUSTED_PROXIES")
+	if err != nil {
+		return Config{}, err
+	}
+
+	corsCredentials, err := parseBoolEnv("CORS_ALLOW_CREDENTIALS", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	corsMaxAge, err := parseDurationEnv("CORS_MAX_AGE", "10m")
+	if err != nil {
+		return Config{}, err
+	}
+
+	hstsMaxAge, err := parseDurationEnv("HSTS_MAX_AGE", "8760h") // 1 year
+	if err != nil {
+		return Config{}, err
+	}
+
+	hstsSubdomains, err := parseBoolEnv("HSTS_INCLUDE_SUBDOMAINS", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	authCookieEnabled, err := parseBoolEnv("AUTH_COOKIE_ENABLED", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	authCookieSecure, err := parseBoolEnv("AUTH_COOKIE_SECURE", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	authCookieSameSite, err := parseSameSiteEnv("AUTH_COOKIE_SAMESITE")
+	if err != nil {
+		return Config{}, err
+	}
+
+	// -1 — не задан: порог считается от WARRANTY_DAYS_LIMIT ниже
+	warrantyDaysWarning, err := parseIntEnv("WARRANTY_DAYS_WARNING", -1)
+	if err != nil {
+		return Config{}, err
+	}
+
+	webhooksEnabled, err := parseBoolEnv("WEBHOOKS_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	webhookPollInterval, err := parseDurationEnv("WEBHOOK_POLL_INTERVAL", "2s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	webhookBatchSize, err := parseIntEnv("WEBHOOK_BATCH_SIZE", 20)
+	if err != nil {
+		return Config{}, err
+	}
+
+	webhookTimeout, err := parseDurationEnv("WEBHOOK_TIMEOUT", "10s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	webhookMaxAttempts, err := parseIntEnv("WEBHOOK_MAX_ATTEMPTS", 10)
+	if err != nil {
+		return Config{}, err
+	}
+
+	webhookBackoffInitial, err := parseDurationEnv("WEBHOOK_BACKOFF_INITIAL", "30s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	webhookBackoffMax, err := parseDurationEnv("WEBHOOK_BACKOFF_MAX", "6h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	webhookAllowedNetworks, err := parsePrefixListEnv("WEBHOOK_ALLOWED_NETWORKS")
+	if err != nil {
+		return Config{}, err
+	}
+
+	outboxRelayEnabled, err := parseBoolEnv("OUTBOX_RELAY_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	outboxPollInterval, err := parseDurationEnv("OUTBOX_POLL_INTERVAL", "1s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	outboxBatchSize, err := parseIntEnv("OUTBOX_BATCH_SIZE", 100)
+	if err != nil {
+		return Config{}, err
+	}
+
+	outboxMaxAttempts, err := parseIntEnv("OUTBOX_MAX_ATTEMPTS", 20)
+	if err != nil {
+		return Config{}, err
+	}
+
+	outboxBackoffMax, err := parseDurationEnv("OUTBOX_BACKOFF_MAX", "1m")
+	if err != nil {
+		return Config{}, err
+	}
+
+	outboxRetention, err := parseDurationEnv("OUTBOX_RETENTION", "168h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	sseHeartbeatInterval, err := parseDurationEnv("SSE_HEARTBEAT_INTERVAL", "15s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	sseMaxDuration, err := parseDurationEnv("SSE_MAX_DURATION", "30m")
+	if err != nil {
+		return Config{}, err
+	}
+
+	claimNotifyEnabled, err := parseBoolEnv("CLAIM_NOTIFY_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	claimNotifyReconnectMax, err := parseDurationEnv("CLAIM_NOTIFY_RECONNECT_MAX", "30s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	claimChangesRetention, err := parseDurationEnv("CLAIM_CHANGES_RETENTION", "24h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	schedulerEnabled, err := parseBoolEnv("SCHEDULER_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	schedulerPollInterval, err := parseDurationEnv("SCHEDULER_POLL_INTERVAL", "5s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	// пустое значение — UTC
+	schedulerLocation, err := time.LoadLocation(strings.TrimSpace(os.Getenv("SCHEDULER_TIMEZONE")))
+	if err != nil {
+		return Config{}, fmt.Errorf("SCHEDULER_TIMEZONE: %w", err)
+	}
+
+	schedulerJobTimeout, err := parseDurationEnv("SCHEDULER_JOB_TIMEOUT", "1h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	schedulerHistoryRetention, err := parseDurationEnv("SCHEDULER_HISTORY_RETENTION", "720h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	sessionCleanupRetention, err := parseDurationEnv("SESSION_CLEANUP_RETENTION", "720h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	notifyEnabled, err := parseBoolEnv("NOTIFY_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	notifyFromRaw := strings.TrimSpace(os.Getenv("NOTIFY_FROM"))
+	if notifyFromRaw == "" {
+		notifyFromRaw = "WARRANTY_DAYS <no-reply@localhost>"
+	}
+	notifyFrom, err := mail.ParseAddress(notifyFromRaw)
+	if err != nil {
+		return Config{}, fmt.Errorf("NOTIFY_FROM has invalid address %q: %w", notifyFromRaw, err)
+	}
+
+	notifyPollInterval, err := parseDurationEnv("NOTIFY_POLL_INTERVAL", "2s")
+	if err != nil {
+		return Config{}, err
+	}
+
+	notifyBatchSize, err := parseIntEnv("NOTIFY_BATCH_SIZE", 20)
+	if err != nil {
+		return Config{}, err
+	}
+
+	notifyMaxAttempts, err := parseIntEnv("NOTIFY_MAX_ATTEMPTS", 8)
+	if err != nil {
+		return Config{}, err
+	}
+
+	notifyBackoffInitial, err := parseDurationEnv("NOTIFY_BACKOFF_INITIAL", "1m")
+	if err != nil {
+		return Config{}, err
+	}
+
+	notifyBackoffMax, err := parseDurationEnv("NOTIFY_BACKOFF_MAX", "1h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	notifyRetention, err := parseDurationEnv("NOTIFY_RETENTION", "168h")
+	if err != nil {
+		return Config{}, err
+	}
+
+	smtpPort, err := parseIntEnv("SMTP_PORT", 587)
+	if err != nil {
+		return Config{}, err
+	}
+
+	smtpTimeout, err := parseDurationEnv("SMTP_TIMEOUT", "15s")
+	if err != nil {
+		return Config{}, err
+	}
+
 	cfg := Config{
 		AppEnv:        os.Getenv("APP_ENV"),
 		LogLevel:      os.Getenv("LOG_LEVEL"),
@@ -49,11 +628,141 @@ func Load() (Config, error) {
 		JWTIssuer:     os.Getenv("JWT_ISSUER"),
 		JWTAccessTTL:  accessTTL,
 		JWTRefreshTTL: refreshTTL,
+
+		HTTPReadTimeout:       readTimeout,
+		HTTPReadHeaderTimeout: readHeaderTimeout,
+		HTTPWriteTimeout:      writeTimeout,
+		HTTPIdleTimeout:       idleTimeout,
+		HTTPMaxHeaderBytes:    maxHeaderBytes,
+		HTTPShutdownTimeout:   shutdownTimeout,
+		HTTPCloseTimeout:      closeTimeout,
+		DBConnectRetryMax:     dbRetryMax,
+		HealthCheckTimeout:    healthTimeout,
+
+		RegistrationMode:          strings.ToLower(strings.TrimSpace(os.Getenv("REGISTRATION_MODE"))),
+		RegistrationDomains:       parseListEnv("REGISTRATION_ALLOWED_DOMAINS"),
+		EmailVerificationRequired: verificationRequired,
+		EmailVerificationTTL:      verificationTTL,
+		InvitationTTL:             invitationTTL,
+
+		MFAIssuer:        os.Getenv("MFA_ISSUER"),
+		MFAEncryptionKey: os.Getenv("MFA_ENCRYPTION_KEY"),
+
+		PasswordHasher:      strings.ToLower(strings.TrimSpace(os.Getenv("PASSWORD_HASHER"))),
+		Argon2MemoryKiB:     argonMemory,
+		Argon2Iterations:    argonIterations,
+		Argon2Parallelism:   argonParallelism,
+		BcryptCost:          bcryptCost,
+		PasswordMinLength:   passwordMin,
+		PasswordMaxLength:   passwordMax,
+		PasswordCheckCommon: passwordCheckCommon,
+
+		MetricsEnabled:       metricsEnabled,
+		MetricsToken:         os.Getenv("METRICS_TOKEN"),
+		MetricsDomainRefresh: metricsRefresh,
+		WarrantyDaysLimit:    warrantyDaysLimit,
+
+		TracingEnabled:     tracingEnabled,
+		TracingSampleRatio: tracingSampleRatio,
+
+		LegacyRoutesEnabled:      legacyRoutesEnabled,
+		LegacyRoutesDeprecatedAt: legacyDeprecatedAt,
+		LegacyRoutesSunset:       legacySunset,
+
+		GRPCEnabled:           grpcEnabled,
+		GRPCAddr:              os.Getenv("GRPC_ADDR"),
+		GRPCReflectionEnabled: grpcReflection,
+
+		RateLimitEnabled:        rateLimitEnabled,
+		RateLimitBackend:        strings.ToLower(strings.TrimSpace(os.Getenv("RATE_LIMIT_BACKEND"))),
+		RateLimitDBMaxConns:     rateLimitDBMaxConns,
+		RateLimitDBTimeout:      rateLimitDBTimeout,
+		RateLimitAuth:           rateLimitAuth,
+		RateLimitClaims:         rateLimitClaims,
+		RateLimitAPI:            rateLimitAPI,
+		RateLimitTrustedProxies: trustedProxies,
+
+		CORSAllowedOrigins:   parseListEnv("CORS_ALLOWED_ORIGINS"),
+		CORSAllowedMethods:   parseListEnv("CORS_ALLOWED_METHODS"),
+		CORSAllowedHeaders:   parseListEnv("CORS_ALLOWED_HEADERS"),
+		CORSExposedHeaders:   parseListEnv("CORS_EXPOSED_HEADERS"),
+		CORSAllowCredentials: corsCredentials,
+		CORSMaxAge:           corsMaxAge,
+
+		HSTSMaxAge:            hstsMaxAge,
+		HSTSIncludeSubdomains: hstsSubdomains,
+		FrameOptions:          strings.ToUpper(strings.TrimSpace(os.Getenv("FRAME_OPTIONS"))),
+		ContentSecurityPolicy: strings.TrimSpace(os.Getenv("CONTENT_SECURITY_POLICY")),
+
+		AuthCookieEnabled:  authCookieEnabled,
+		AuthCookieSecure:   authCookieSecure,
+		AuthCookieSameSite: authCookieSameSite,
+		AuthCookieDomain:   strings.TrimSpace(os.Getenv("AUTH_COOKIE_DOMAIN")),
+
+		WarrantyDaysWarning:   warrantyDaysWarning,
+		WebhooksEnabled:       webhooksEnabled,
+		WebhookSecretKey:      os.Getenv("WEBHOOK_SECRET_KEY"),
+		WebhookPollInterval:   webhookPollInterval,
+		WebhookBatchSize:      webhookBatchSize,
+		WebhookTimeout:        webhookTimeout,
+		WebhookMaxAttempts:    webhookMaxAttempts,
+		WebhookBackoffInitial: webhookBackoffInitial,
+		WebhookBackoffMax:     webhookBackoffMax,
+
+		WebhookAllowedNetworks: webhookAllowedNetworks,
+
+		OutboxRelayEnabled: outboxRelayEnabled,
+		OutboxPublishers:   parseListEnv("OUTBOX_PUBLISHERS"),
+		OutboxPollInterval: outboxPollInterval,
+		OutboxBatchSize:    outboxBatchSize,
+		OutboxMaxAttempts:  outboxMaxAttempts,
+		OutboxBackoffMax:   outboxBackoffMax,
+		OutboxRetention:    outboxRetention,
+
+		SSEHeartbeatInterval: sseHeartbeatInterval,
+		SSEMaxDuration:       sseMaxDuration,
+
+		ClaimNotifyEnabled:      claimNotifyEnabled,
+		ClaimNotifyReconnectMax: claimNotifyReconnectMax,
+		ClaimChangesRetention:   claimChangesRetention,
+
+		SchedulerEnabled:          schedulerEnabled,
+		SchedulerPollInterval:     schedulerPollInterval,
+		SchedulerLocation:         schedulerLocation,
+		SchedulerJobTimeout:       schedulerJobTimeout,
+		SchedulerHistoryRetention: schedulerHistoryRetention,
+		JobWarrantyStatusSchedule: strings.TrimSpace(os.Getenv("JOB_WARRANTY_STATUS_SCHEDULE")),
+		JobDataQualitySchedule:    strings.TrimSpace(os.Getenv("JOB_DATA_QUALITY_SCHEDULE")),
+		JobSessionCleanupSchedule: strings.TrimSpace(os.Getenv("JOB_SESSION_CLEANUP_SCHEDULE")),
+		SessionCleanupRetention:   sessionCleanupRetention,
+
+		NotifyEnabled:         notifyEnabled,
+		NotifySender:          strings.ToLower(strings.TrimSpace(os.Getenv("NOTIFY_SENDER"))),
+		NotifyFrom:            *notifyFrom,
+		NotifyDefaultLanguage: strings.ToLower(strings.TrimSpace(os.Getenv("NOTIFY_DEFAULT_LANGUAGE"))),
+		NotifyVerifyEmailURL:  strings.TrimSpace(os.Getenv("NOTIFY_VERIFY_EMAIL_URL")),
+		NotifyEMLDir:          strings.TrimSpace(os.Getenv("NOTIFY_EML_DIR")),
+		NotifyPollInterval:    notifyPollInterval,
+		NotifyBatchSize:       notifyBatchSize,
+		NotifyMaxAttempts:     notifyMaxAttempts,
+		NotifyBackoffInitial:  notifyBackoffInitial,
+		NotifyBackoffMax:      notifyBackoffMax,
+		NotifyRetention:       notifyRetention,
+
+		SMTPHost:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		SMTPPort:     smtpPort,
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPTLS:      strings.ToLower(strings.TrimSpace(os.Getenv("SMTP_TLS"))),
+		SMTPTimeout:  smtpTimeout,
 	}
 	// дефолты
 	if cfg.HTTPAddr == "" {
 		cfg.HTTPAddr = ":8080"
 	}
+	if cfg.GRPCAddr == "" {
+		cfg.GRPCAddr = ":9090"
+	}
 	if cfg.DBHost == "" {
 		cfg.DBHost = "127.0.0.1"
 	}
@@ -66,6 +775,82 @@ func Load() (Config, error) {
 	if cfg.JWTIssuer == "" {
 		cfg.JWTIssuer = "warranty_days"
 	}
+	if cfg.RegistrationMode == "" {
+		cfg.RegistrationMode = RegistrationModeOpen
+	}
+	if cfg.MFAIssuer == "" {
+		cfg.MFAIssuer = "WARRANTY_DAYS"
+	}
+	if cfg.PasswordHasher == "" {
+		cfg.PasswordHasher = "argon2id"
+	}
+	if cfg.RateLimitBackend == "" {
+		cfg.RateLimitBackend = RateLimitBackendMemory
+	}
+	if len(cfg.CORSAllowedMethods) == 0 {
+		cfg.CORSAllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	}
+	if len(cfg.CORSAllowedHeaders) == 0 {
+		cfg.CORSAllowedHeaders = []string{
+			"Authorization", "Content-Type", "If-None-Match", "X-Request-ID", "X-CSRF-Token", "X-Token-Delivery",
+		}
+	}
+	// без Expose-Headers JavaScript видит только "простые" заголовки ответа
+	if len(cfg.CORSExposedHeaders) == 0 {
+		cfg.CORSExposedHeaders = []string{
+			"ETag", "X-Request-ID", "Retry-After",
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+			"Deprecation", "Sunset", "Link",
+		}
+	}
+	if cfg.FrameOptions == "" {
+		cfg.FrameOptions = "DENY"
+	}
+	// ответы API — JSON, им не нужны ни скрипты, ни встраивание во фреймы
+	if cfg.ContentSecurityPolicy == "" {
+		cfg.ContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+	}
+	// только в разработке: без отдельных ключей TOTP-секреты и секреты вебхуков шифруются ключом от JWT_SECRET
+	if cfg.DevEnv() {
+		if cfg.MFAEncryptionKey == "" {
+			cfg.MFAEncryptionKey = cfg.JWTSecret
+		}
+		if cfg.WebhookSecretKey == "" {
+			cfg.WebhookSecretKey = cfg.MFAEncryptionKey
+		}
+	}
+	if cfg.WarrantyDaysWarning < 0 {
+		cfg.WarrantyDaysWarning = max(cfg.WarrantyDaysLimit-5, 0)
+	}
+	if len(cfg.OutboxPublishers) == 0 {
+		// с LISTEN/NOTIFY шину наполняет слушатель изменений заявок, outbox ей не нужен
+		cfg.OutboxPublishers = []string{OutboxPublisherWebhook, OutboxPublisherEmail}
+		if !cfg.ClaimNotifyEnabled {
+			cfg.OutboxPublishers = append(cfg.OutboxPublishers, OutboxPublisherBus)
+		}
+	}
+	if cfg.JobWarrantyStatusSchedule == "" {
+		cfg.JobWarrantyStatusSchedule = "0 3 * * *"
+	}
+	if cfg.JobDataQualitySchedule == "" {
+		cfg.JobDataQualitySchedule = "30 3 * * *"
+	}
+	if cfg.JobSessionCleanupSchedule == "" {
+		cfg.JobSessionCleanupSchedule = "0 4 * * *"
+	}
+	// без настроенной почты письма пишутся в лог
+	if cfg.NotifySender == "" {
+		cfg.NotifySender = NotifySenderLog
+	}
+	if cfg.NotifyDefaultLanguage == "" {
+		cfg.NotifyDefaultLanguage = "ru"
+	}
+	if cfg.NotifyEMLDir == "" {
+		cfg.NotifyEMLDir = "mail"
+	}
+	if cfg.SMTPTLS == "" {
+		cfg.SMTPTLS = SMTPTLSStartTLS
+	}
 
 	// обязательные поля
 	if cfg.DBUser == "" {
@@ -83,16 +868,225 @@ func Load() (Config, error) {
 	if len(cfg.JWTSecret) < 32 {
 		return Config{}, errors.New("JWT_SECRET must be at least 32 characters")
 	}
+	if cfg.HTTPReadTimeout <= 0 || cfg.HTTPReadHeaderTimeout <= 0 {
+		return Config{}, errors.New("HTTP_READ_TIMEOUT and HTTP_READ_HEADER_TIMEOUT must be > 0")
+	}
+	if cfg.HTTPWriteTimeout <= 0 || cfg.HTTPIdleTimeout <= 0 {
+		return Config{}, errors.New("HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT must be > 0")
+	}
+	if cfg.HTTPMaxHeaderBytes < 4*1024 {
+		return Config{}, errors.New("HTTP_MAX_HEADER_BYTES must be >= 4096")
+	}
+	if cfg.HTTPShutdownTimeout <= 0 {
+		return Config{}, errors.New("HTTP_SHUTDOWN_TIMEOUT must be > 0")
+	}
+	if cfg.HTTPCloseTimeout <= 0 {
+		return Config{}, errors.New("HTTP_CLOSE_TIMEOUT must be > 0")
+	}
+	if cfg.DBConnectRetryMax <= 0 {
+		return Config{}, errors.New("DB_CONNECT_RETRY_MAX must be > 0")
+	}
+	if cfg.HealthCheckTimeout <= 0 {
+		return Config{}, errors.New("HEALTH_CHECK_TIMEOUT must be > 0")
+	}
+	if cfg.MetricsDomainRefresh <= 0 {
+		return Config{}, errors.New("METRICS_DOMAIN_REFRESH must be > 0")
+	}
+	if cfg.WarrantyDaysLimit < 1 {
+		return Config{}, errors.New("WARRANTY_DAYS_LIMIT must be >= 1")
+	}
+	if cfg.WarrantyDaysWarning >= cfg.WarrantyDaysLimit {
+		return Config{}, errors.New("WARRANTY_DAYS_WARNING must be < WARRANTY_DAYS_LIMIT")
+	}
+	if cfg.WebhookPollInterval <= 0 || cfg.WebhookTimeout <= 0 {
+		return Config{}, errors.New("WEBHOOK_POLL_INTERVAL and WEBHOOK_TIMEOUT must be > 0")
+	}
+	if cfg.WebhookBatchSize < 1 || cfg.WebhookMaxAttempts < 1 {
+		return Config{}, errors.New("WEBHOOK_BATCH_SIZE and WEBHOOK_MAX_ATTEMPTS must be >= 1")
+	}
+	if cfg.WebhookBackoffInitial <= 0 || cfg.WebhookBackoffMax < cfg.WebhookBackoffInitial {
+		return Config{}, errors.New("WEBHOOK_BACKOFF_INITIAL must be > 0 and <= WEBHOOK_BACKOFF_MAX")
+	}
+	if cfg.OutboxPollInterval <= 0 || cfg.OutboxBatchSize < 1 {
+		return Config{}, errors.New("OUTBOX_POLL_INTERVAL must be > 0 and OUTBOX_BATCH_SIZE must be >= 1")
+	}
+	if cfg.OutboxMaxAttempts < 1 {
+		return Config{}, errors.New("OUTBOX_MAX_ATTEMPTS must be >= 1")
+	}
+	if cfg.OutboxBackoffMax < cfg.OutboxPollInterval || cfg.OutboxRetention <= 0 {
+		return Config{}, errors.New("OUTBOX_BACKOFF_MAX must be >= OUTBOX_POLL_INTERVAL and OUTBOX_RETENTION must be > 0")
+	}
+	if cfg.SSEHeartbeatInterval <= 0 || cfg.SSEMaxDuration < cfg.SSEHeartbeatInterval {
+		return Config{}, errors.New("SSE_HEARTBEAT_INTERVAL must be > 0 and <= SSE_MAX_DURATION")
+	}
+	if cfg.ClaimNotifyReconnectMax <= 0 || cfg.ClaimChangesRetention <= 0 {
+		return Config{}, errors.New("CLAIM_NOTIFY_RECONNECT_MAX and CLAIM_CHANGES_RETENTION must be > 0")
+	}
+	if cfg.SchedulerPollInterval <= 0 || cfg.SchedulerJobTimeout <= 0 {
+		return Config{}, errors.New("SCHEDULER_POLL_INTERVAL and SCHEDULER_JOB_TIMEOUT must be > 0")
+	}
+	if cfg.SchedulerHistoryRetention <= 0 || cfg.SessionCleanupRetention < 0 {
+		return Config{}, errors.New("SCHEDULER_HISTORY_RETENTION must be > 0 and SESSION_CLEANUP_RETENTION must be >= 0")
+	}
+	if cfg.NotifyPollInterval <= 0 || cfg.SMTPTimeout <= 0 {
+		return Config{}, errors.New("NOTIFY_POLL_INTERVAL and SMTP_TIMEOUT must be > 0")
+	}
+	if cfg.NotifyBatchSize < 1 || cfg.NotifyMaxAttempts < 1 {
+		return Config{}, errors.New("NOTIFY_BATCH_SIZE and NOTIFY_MAX_ATTEMPTS must be >= 1")
+	}
+	if cfg.NotifyBackoffInitial <= 0 || cfg.NotifyBackoffMax < cfg.NotifyBackoffInitial {
+		return Config{}, errors.New("NOTIFY_BACKOFF_INITIAL must be > 0 and <= NOTIFY_BACKOFF_MAX")
+	}
+	if cfg.NotifyRetention <= 0 {
+		return Config{}, errors.New("NOTIFY_RETENTION must be > 0")
+	}
+	switch cfg.NotifySender {
+	case NotifySenderLog, NotifySenderEML:
+	case NotifySenderSMTP:
+		if cfg.SMTPHost == "" {
+			return Config{}, errors.New("SMTP_HOST is required when NOTIFY_SENDER=smtp")
+		}
+	default:
+		return Config{}, fmt.Errorf("NOTIFY_SENDER has invalid value %q", cfg.NotifySender)
+	}
+	switch cfg.NotifyDefaultLanguage {
+	case "ru", "en":
+	default:
+		return Config{}, fmt.Errorf("NOTIFY_DEFAULT_LANGUAGE has invalid value %q", cfg.NotifyDefaultLanguage)
+	}
+	if cfg.NotifyVerifyEmailURL != "" && !strings.Contains(cfg.NotifyVerifyEmailURL, "{token}") {
+		return Config{}, errors.New("NOTIFY_VERIFY_EMAIL_URL must contain {token}")
+	}
+	switch cfg.SMTPTLS {
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return Config{}, fmt.Errorf("SMTP_TLS has invalid value %q", cfg.SMTPTLS)
+	}
+	if cfg.SMTPPort < 1 || cfg.SMTPPort > 65535 {
+		return Config{}, errors.New("SMTP_PORT must be between 1 and 65535")
+	}
+	for i, publisher := range cfg.OutboxPublishers {
+		publisher = strings.ToLower(publisher)
+		switch publisher {
+		case OutboxPublisherWebhook, OutboxPublisherLog, OutboxPublisherBus, OutboxPublisherEmail:
+		default:
+			return Config{}, fmt.Errorf("OUTBOX_PUBLISHERS has invalid value %q", publisher)
+		}
+		cfg.OutboxPublishers[i] = publisher
+	}
+	if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+		return Config{}, errors.New("TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+	if !cfg.LegacyRoutesSunset.IsZero() && cfg.LegacyRoutesSunset.Before(cfg.LegacyRoutesDeprecatedAt) {
+		return Config{}, errors.New("LEGACY_ROUTES_SUNSET must not be before LEGACY_ROUTES_DEPRECATED_AT")
+	}
+	switch cfg.RateLimitBackend {
+	case RateLimitBackendMemory, RateLimitBackendPostgres:
+	default:
+		return Config{}, fmt.Errorf("RATE_LIMIT_BACKEND has invalid value %q", cfg.RateLimitBackend)
+	}
+	if cfg.RateLimitDBMaxConns < 1 {
+		return Config{}, errors.New("RATE_LIMIT_DB_MAX_CONNS must be >= 1")
+	}
+	if cfg.RateLimitDBTimeout <= 0 {
+		return Config{}, errors.New("RATE_LIMIT_DB_TIMEOUT must be > 0")
+	}
+	for i, origin := range cfg.CORSAllowedOrigins {
+		normalized, err := normalizeOrigin(origin)
+		if err != nil {
+			return Config{}, fmt.Errorf("CORS_ALLOWED_ORIGINS: %w", err)
+		}
+		cfg.CORSAllowedOrigins[i] = normalized
+	}
+	if cfg.CORSAllowCredentials && slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		return Config{}, errors.New("CORS_ALLOWED_ORIGINS=* cannot be combined with CORS_ALLOW_CREDENTIALS=true")
+	}
+	if cfg.CORSMaxAge < 0 || cfg.HSTSMaxAge < 0 {
+		return Config{}, errors.New("CORS_MAX_AGE and HSTS_MAX_AGE must be >= 0")
+	}
+	switch cfg.FrameOptions {
+	case "DENY", "SAMEORIGIN":
+	default:
+		return Config{}, fmt.Errorf("FRAME_OPTIONS has invalid value %q", cfg.FrameOptions)
+	}
+	// браузеры отбрасывают SameSite=None без Secure
+	if cfg.AuthCookieSameSite == http.SameSiteNoneMode && !cfg.AuthCookieSecure {
+		return Config{}, errors.New("AUTH_COOKIE_SAMESITE=none requires AUTH_COOKIE_SECURE=true")
+	}
 	if cfg.JWTAccessTTL <= 0 {
 		return Config{}, errors.New("JWT_ACCESS_TTL must be > 0")
 	}
 	if cfg.JWTRefreshTTL <= 0 {
 		return Config{}, errors.New("JWT_REFRESH_TTL must be > 0")
 	}
+	switch cfg.RegistrationMode {
+	case RegistrationModeOpen, RegistrationModeInvite:
+	case RegistrationModeDomain:
+		if len(cfg.RegistrationDomains) == 0 {
+			return Config{}, errors.New("REGISTRATION_ALLOWED_DOMAINS is required when REGISTRATION_MODE=domain")
+		}
+	default:
+		return Config{}, fmt.Errorf("REGISTRATION_MODE has invalid value %q", cfg.RegistrationMode)
+	}
+	if cfg.EmailVerificationTTL <= 0 {
+		return Config{}, errors.New("EMAIL_VERIFICATION_TTL must be > 0")
+	}
+	if cfg.InvitationTTL <= 0 {
+		return Config{}, errors.New("INVITATION_TTL must be > 0")
+	}
+	if len(cfg.MFAEncryptionKey) < 32 {
+		return Config{}, errors.New("MFA_ENCRYPTION_KEY is required and must be at least 32 characters")
+	}
+	// утечка ключа подписи JWT не должна раскрывать TOTP-секреты
+	if cfg.MFAEncryptionKey == cfg.JWTSecret && !cfg.DevEnv() {
+		return Config{}, errors.New("MFA_ENCRYPTION_KEY must differ from JWT_SECRET")
+	}
+	if len(cfg.WebhookSecretKey) < 32 {
+		return Config{}, errors.New("WEBHOOK_SECRET_KEY is required and must be at least 32 characters")
+	}
+	if (cfg.WebhookSecretKey == cfg.JWTSecret || cfg.WebhookSecretKey == cfg.MFAEncryptionKey) && !cfg.DevEnv() {
+		return Config{}, errors.New("WEBHOOK_SECRET_KEY must differ from JWT_SECRET and MFA_ENCRYPTION_KEY")
+	}
+	switch cfg.PasswordHasher {
+	case "argon2id":
+		if cfg.Argon2MemoryKiB < 8*1024 || cfg.Argon2Iterations < 1 {
+			return Config{}, errors.New("ARGON2_MEMORY_KIB must be >= 8192 and ARGON2_ITERATIONS >= 1")
+		}
+		if cfg.Argon2Parallelism < 1 || cfg.Argon2Parallelism > 255 {
+			return Config{}, errors.New("ARGON2_PARALLELISM must be between 1 and 255")
+		}
+	case "bcrypt":
+		if cfg.BcryptCost < 10 || cfg.BcryptCost > 31 {
+			return Config{}, errors.New("BCRYPT_COST must be between 10 and 31")
+		}
+		// bcrypt принимает не больше 72 байт; длину в байтах проверяет auth.PasswordPolicy
+		if cfg.PasswordMaxLength > 72 {
+			cfg.PasswordMaxLength = 72
+		}
+	default:
+		return Config{}, fmt.Errorf("PASSWORD_HASHER has invalid value %q", cfg.PasswordHasher)
+	}
+	if cfg.PasswordMinLength < 8 {
+		return Config{}, errors.New("PASSWORD_MIN_LENGTH must be >= 8")
+	}
+	if cfg.PasswordMaxLength < cfg.PasswordMinLength {
+		return Config{}, errors.New("PASSWORD_MAX_LENGTH must be >= PASSWORD_MIN_LENGTH")
+	}
 
 	return cfg, nil
 }
 
+// DevEnv — локальная разработка: APP_ENV=dev, development или local. Пустой APP_ENV разработкой не
+// считается, чтобы забытая переменная в проде не ослабила проверки.
+func (c Config) DevEnv() bool {
+	switch strings.ToLower(strings.TrimSpace(c.AppEnv)) {
+	case "dev", "development", "local":
+		return true
+	default:
+		return false
+	}
+}
+
 func (c Config) DatabaseURL() string {
 	return fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
@@ -117,3 +1111,143 @@ func parseDurationEnv(key, fallback string) (time.Duration, error) {
 	}
 	return d, nil
 }
+
+func parseIntEnv(key string, fallback int) (int, error) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s has invalid int %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+// parseDateEnv читает дату в формате YYYY-MM-DD (UTC). Пустое значение без fallback — нулевое время.
+func parseDateEnv(key string, fallback string) (time.Time, error) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		raw = fallback
+	}
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	v, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s has invalid date %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+func parseFloatEnv(key string, fallback float64) (float64, error) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback, nil
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s has invalid float %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+func parseBoolEnv(key string, fallback bool) (bool, error) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback, nil
+	}
+
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s has invalid bool %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+// parseLimitEnv читает лимит запросов в формате "<запросов>/<период>", например "60/1m".
+func parseLimitEnv(key, fallback string) (ratelimit.Limit, error) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		raw = fallback
+	}
+
+	v, err := ratelimit.ParseLimit(raw)
+	if err != nil {
+		return ratelimit.Limit{}, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
+
+// parsePrefixListEnv читает список подсетей (CIDR) или отдельных IP через запятую.
+func parsePrefixListEnv(key string) ([]netip.Prefix, error) {
+	values := parseListEnv(key)
+	out := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s has invalid address %q: %w", key, value, err)
+			}
+			out = append(out, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s has invalid subnet %q: %w", key, value, err)
+		}
+		out = append(out, prefix.Masked())
+	}
+	return out, nil
+}
+
+// parseSameSiteEnv читает атрибут SameSite для cookie: strict (по умолчанию), lax или none.
+func parseSameSiteEnv(key string) (http.SameSite, error) {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	switch raw {
+	case "", "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("%s has invalid value %q: want strict, lax or none", key, raw)
+	}
+}
+
+// normalizeOrigin приводит origin к виду, который присылает браузер: scheme://host[:port] в нижнем регистре.
+func normalizeOrigin(origin string) (string, error) {
+	if origin == "*" {
+		return origin, nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("invalid origin %q: want scheme://host[:port]", origin)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// parseListEnv читает список значений через запятую, пустые элементы отбрасываются.
+func parseListEnv(key string) []string {
+	raw := os.Getenv(key)
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	parts := strings.Split(raw, ",")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testJWTSecret  = "jwt-secret-0123456789abcdef0123456789"
+	testMFAKey     = "mfa-key-0123456789abcdef0123456789abcd"
+	testWebhookKey = "webhook-key-0123456789abcdef0123456789"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("DB_USER", "warranty")
+	t.Setenv("DB_PASSWORD", "warranty")
+	t.Setenv("DB_NAME", "warranty_days")
+	t.Setenv("JWT_SECRET", testJWTSecret)
+}
+
+func TestLoadMFAEncryptionKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		appEnv  string
+		mfaKey  string
+		want    string
+		wantErr string
+	}{
+		{name: "dev falls back to JWT_SECRET", appEnv: "dev", want: testJWTSecret},
+		{name: "dev with own key", appEnv: "local", mfaKey: testMFAKey, want: testMFAKey},
+		{name: "prod requires key", appEnv: "production", wantErr: "MFA_ENCRYPTION_KEY is required"},
+		{name: "empty APP_ENV requires key", wantErr: "MFA_ENCRYPTION_KEY is required"},
+		{
+			name:    "prod rejects JWT_SECRET reuse",
+			appEnv:  "production",
+			mfaKey:  testJWTSecret,
+			wantErr: "MFA_ENCRYPTION_KEY must differ from JWT_SECRET",
+		},
+		{name: "prod with own key", appEnv: "production", mfaKey: testMFAKey, want: testMFAKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("APP_ENV", tt.appEnv)
+			t.Setenv("MFA_ENCRYPTION_KEY", tt.mfaKey)
+			t.Setenv("WEBHOOK_SECRET_KEY", testWebhookKey)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.MFAEncryptionKey != tt.want {
+				t.Fatalf("MFAEncryptionKey = %q, want %q", cfg.MFAEncryptionKey, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadWebhookSecretKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		appEnv     string
+		webhookKey string
+		want       string
+		wantErr    string
+	}{
+		{name: "dev falls back to MFA key", appEnv: "dev", want: testMFAKey},
+		{name: "prod requires key", appEnv: "production", wantErr: "WEBHOOK_SECRET_KEY is required"},
+		{
+			name:       "prod rejects MFA key reuse",
+			appEnv:     "production",
+			webhookKey: testMFAKey,
+			wantErr:    "WEBHOOK_SECRET_KEY must differ",
+		},
+		{
+			name:       "prod rejects JWT_SECRET reuse",
+			appEnv:     "production",
+			webhookKey: testJWTSecret,
+			wantErr:    "WEBHOOK_SECRET_KEY must differ",
+		},
+		{name: "prod with own key", appEnv: "production", webhookKey: testWebhookKey, want: testWebhookKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("APP_ENV", tt.appEnv)
+			t.Setenv("MFA_ENCRYPTION_KEY", testMFAKey)
+			t.Setenv("WEBHOOK_SECRET_KEY", tt.webhookKey)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.WebhookSecretKey != tt.want {
+				t.Fatalf("WebhookSecretKey = %q, want %q", cfg.WebhookSecretKey, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLegacyRouteDates(t *testing.T) {
+	tests := []struct {
+		name         string
+		deprecatedAt string
+		sunset       string
+		wantErr      string
+	}{
+		// без явных дат заголовки Deprecation и Sunset не отдаются, а не берут дату из кода
+		{name: "not announced"},
+		{name: "both dates", deprecatedAt: "2026-10-19", sunset: "2027-04-19"},
+		{name: "deprecation without sunset", deprecatedAt: "2026-10-19"},
+		{
+			name:         "sunset before deprecation",
+			deprecatedAt: "2026-10-19",
+			sunset:       "2026-10-18",
+			wantErr:      "LEGACY_ROUTES_SUNSET must not be before LEGACY_ROUTES_DEPRECATED_AT",
+		},
+		{name: "invalid date", deprecatedAt: "19.10.2026", wantErr: "LEGACY_ROUTES_DEPRECATED_AT has invalid date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("APP_ENV", "dev")
+			t.Setenv("LEGACY_ROUTES_DEPRECATED_AT", tt.deprecatedAt)
+			t.Setenv("LEGACY_ROUTES_SUNSET", tt.sunset)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := formatDate(cfg.LegacyRoutesDeprecatedAt); got != tt.deprecatedAt {
+				t.Fatalf("LegacyRoutesDeprecatedAt = %q, want %q", got, tt.deprecatedAt)
+			}
+			if got := formatDate(cfg.LegacyRoutesSunset); got != tt.sunset {
+				t.Fatalf("LegacyRoutesSunset = %q, want %q", got, tt.sunset)
+			}
+		})
+	}
+}
+
+// formatDate — дата как в переменной окружения; нулевая дата — пустая строка.
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
@@ -7,13 +7,30 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 func NewGorm(dsn string) (*gorm.DB, error) {
+	return NewGormPool(dsn, 10)
+}
+
+// NewGormPool — как NewGorm, но с пулом не больше maxOpenConns соединений. Нужен для отдельного пула
+// под задачу, которая не должна делить соединения с запросами API.
+func NewGormPool(dsn string, maxOpenConns int) (*gorm.DB, error) {
 	gdb, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Warn),
 	})
 	if err != nil {
+		// gorm.Open возвращает открытый пул даже при неудачном ping, закрываем его
+		if gdb != nil {
+			_ = CloseGorm(gdb)
+		}
+		return nil, err
+	}
+
+	// спаны запросов без значений параметров: в них бывают email и хэши паролей
+	if err := gdb.Use(tracing.NewPlugin(tracing.WithoutQueryVariables(), tracing.WithoutMetrics())); err != nil {
+		_ = CloseGorm(gdb)
 		return nil, err
 	}
 
@@ -21,10 +38,18 @@ func NewGorm(dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	sqlDB.SetMaxOpenConns(10)
-	sqlDB.SetMaxIdleConns(5)
+	sqlDB.SetMaxOpenConns(maxOpenConns)
+	sqlDB.SetMaxIdleConns(max(maxOpenConns/2, 1))
 	sqlDB.SetConnMaxIdleTime(5 * time.Minute)
 	sqlDB.SetConnMaxLifetime(30 * time.Minute)
 
 	return gdb, nil
 }
+
+func CloseGorm(gdb *gorm.DB) error {
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
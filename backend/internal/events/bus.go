@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Bus раздает события подписчикам внутри процесса (живые обновления для клиентов этого инстанса).
+// Доставка без гарантий: если подписчик не успевает разбирать канал, событие для него пропускается.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[chan Event]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[chan Event]struct{})}
+}
+
+// Subscribe возвращает канал событий и функцию отписки; после отписки канал закрывается.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			close(ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+func (b *Bus) Publish(_ context.Context, published ...Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, event := range published {
+		for ch := range b.subs {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Package events — доменные события: что случилось с заявками VIN. Их получают подписчики вебхуков
+// и клиенты внутри процесса через Bus.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ClaimCreated = "claim.created"
+	ClaimUpdated = "claim.updated"
+	ClaimDeleted = "claim.deleted"
+	// ThresholdApproaching — сумма дней ремонта в текущем гарантийном году дошла до порога предупреждения.
+	ThresholdApproaching = "warranty.threshold_approaching"
+	// ThresholdExceeded — сумма дней ремонта превысила законный лимит.
+	ThresholdExceeded = "warranty.threshold_exceeded"
+)
+
+// Types — все типы событий в порядке документации.
+var Types = []string{ClaimCreated, ClaimUpdated, ClaimDeleted, ThresholdApproaching, ThresholdExceeded}
+
+func KnownType(eventType string) bool {
+	return slices.Contains(Types, eventType)
+}
+
+// Event — то, что уходит подписчикам. ID стабилен между повторными доставками: по нему получатель
+// отбрасывает дубликаты.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	VIN        string          `json:"vin"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func New(eventType, vin string, data any, now time.Time) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		VIN:        vin,
+		OccurredAt: now.UTC(),
+		Data:       raw,
+	}, nil
+}
+
+// Affects — событие меняет заявки или расчет VIN. claim.updated с переносом заявки затрагивает и прежний VIN.
+func (e Event) Affects(vin string) bool {
+	if strings.EqualFold(e.VIN, vin) {
+		return true
+	}
+	if e.Type != ClaimUpdated {
+		return false
+	}
+	var data ClaimData
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return false
+	}
+	return data.PreviousVIN != "" && strings.EqualFold(data.PreviousVIN, vin)
+}
+
+// Publisher — получатель событий, их отдает outbox.Relay после коммита изменения заявок.
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// ClaimData — data событий claim.*. Для claim.deleted — заявка до удаления.
+type ClaimData struct {
+	ID          int64  `json:"id"`
+	VIN         string `json:"vin"`
+	RetailDate  string `json:"retail_date"`
+	RoOpenDate  string `json:"ro_open_date"`
+	RoCloseDate string `json:"ro_close_date"`
+	// PreviousVIN — у claim.updated, если заявку перенесли на другой VIN.
+	PreviousVIN string `json:"previous_vin,omitempty"`
+}
+
+// ThresholdData — data событий warranty.threshold_*.
+type ThresholdData struct {
+	WarrantyStart string `json:"warranty_start"`
+	WarrantyEnd   string `json:"warranty_end"`
+	TotalDays     int    `json:"total_days"`
+	PreviousDays  int    `json:"previous_days"`
+	Threshold     int    `json:"threshold"`
+	Limit         int    `json:"limit"`
+}
@@ -0,0 +1,187 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/logging"
+)
+
+// requestIDMetadata — то же, что X-Request-ID в HTTP (ключи метаданных gRPC всегда в нижнем регистре).
+const requestIDMetadata = "x-request-id"
+
+// публичные сервисы: проверки здоровья и reflection доступны без токена
+var publicMethodPrefixes = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+// serverStream подменяет контекст потока: у grpc.ServerStream нет WithContext.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	var incoming string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadata); len(values) > 0 {
+			incoming = values[0]
+		}
+	}
+
+	requestID := middleware.ResolveRequestID(incoming)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+	return logging.WithRequestID(ctx, requestID)
+}
+
+func unaryRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+func streamRequestID() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+func logCall(ctx context.Context, logger *slog.Logger, method string, startedAt time.Time, err error) {
+	if isPublicMethod(method) {
+		return
+	}
+	logger.InfoContext(ctx, "grpc request",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration_ms", time.Since(startedAt).Milliseconds(),
+	)
+}
+
+func unaryLogging(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		startedAt := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, logger, info.FullMethod, startedAt, err)
+		return resp, err
+	}
+}
+
+func streamLogging(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		startedAt := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), logger, info.FullMethod, startedAt, err)
+		return err
+	}
+}
+
+// recoverCall превращает панику в codes.Internal, как middleware.Recovery в HTTP.
+func recoverCall(ctx context.Context, logger *slog.Logger, method string, err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	logger.ErrorContext(ctx, "panic recovered",
+		"method", method,
+		"error", fmt.Errorf("panic: %v", recovered),
+		"stack", string(debug.Stack()),
+	)
+	*err = status.Error(codes.Internal, "internal error")
+}
+
+func unaryRecovery(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		defer recoverCall(ctx, logger, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+func streamRecovery(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverCall(ss.Context(), logger, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+// authenticate проверяет access-токен из метаданных authorization той же функцией, что и HTTP middleware.Auth.
+func authenticate(ctx context.Context, jwtSvc middleware.AccessTokenValidator) (context.Context, error) {
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+
+	user, err := middleware.Authenticate(jwtSvc, authorization, []string{auth.TokenTypeAccess})
+	switch {
+	case errors.Is(err, middleware.ErrAuthNotConfigured):
+		return nil, status.Error(codes.Internal, "auth is not configured")
+	case errors.Is(err, middleware.ErrMissingToken):
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid authorization metadata")
+	case err != nil:
+		return nil, status.Error(codes.Unauthenticated, "invalid access token")
+	}
+
+	logging.SetUserID(ctx, user.UserID)
+	return middleware.ContextWithUser(ctx, user), nil
+}
+
+func unaryAuth(jwtSvc middleware.AccessTokenValidator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, jwtSvc)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(jwtSvc middleware.AccessTokenValidator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), jwtSvc)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func isPublicMethod(method string) bool {
+	for _, prefix := range publicMethodPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,342 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/grpcapi/warrantyv1"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/logging"
+	"warranty_days/internal/models"
+)
+
+const testClaimsMethod = warrantyv1.WarrantyService_GetClaimsByVIN_FullMethodName
+
+// testStream — серверный поток без транспорта: интерсепторам нужен только его контекст.
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func incomingContext(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	jwtSvc := auth.NewJWTService("grpc-test-secret-grpc-test-secret", "warranty_days", time.Hour, time.Hour)
+	accessToken, err := jwtSvc.GenerateAccessToken(7, "user@example.com", models.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshToken, err := jwtSvc.GenerateRefreshToken(7, "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		jwtSvc   middleware.AccessTokenValidator
+		method   string
+		ctx      context.Context
+		wantCode codes.Code
+		wantUser int64
+	}{
+		{
+			name:     "valid access token",
+			jwtSvc:   jwtSvc,
+			method:   testClaimsMethod,
+			ctx:      incomingContext("authorization", "Bearer "+accessToken),
+			wantCode: codes.OK,
+			wantUser: 7,
+		},
+		{
+			name:     "missing metadata",
+			jwtSvc:   jwtSvc,
+			method:   testClaimsMethod,
+			ctx:      context.Background(),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "not a bearer token",
+			jwtSvc:   jwtSvc,
+			method:   testClaimsMethod,
+			ctx:      incomingContext("authorization", "Basic dXNlcjpwYXNz"),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "malformed token",
+			jwtSvc:   jwtSvc,
+			method:   testClaimsMethod,
+			ctx:      incomingContext("authorization", "Bearer not-a-jwt"),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "refresh token",
+			jwtSvc:   jwtSvc,
+			method:   testClaimsMethod,
+			ctx:      incomingContext("authorization", "Bearer "+refreshToken),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "auth not configured",
+			method:   testClaimsMethod,
+			ctx:      incomingContext("authorization", "Bearer "+accessToken),
+			wantCode: codes.Internal,
+		},
+		{
+			name:     "health check without token",
+			jwtSvc:   jwtSvc,
+			method:   healthpb.Health_Check_FullMethodName,
+			ctx:      context.Background(),
+			wantCode: codes.OK,
+		},
+		{
+			name:     "reflection without token",
+			jwtSvc:   jwtSvc,
+			method:   reflectionpb.ServerReflection_ServerReflectionInfo_FullMethodName,
+			ctx:      context.Background(),
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(t *testing.T, err error, user middleware.UserContext) {
+				t.Helper()
+				if got := status.Code(err); got != tt.wantCode {
+					t.Fatalf("code = %s, want %s (err = %v)", got, tt.wantCode, err)
+				}
+				if user.UserID != tt.wantUser {
+					t.Fatalf("user id in handler context = %d, want %d", user.UserID, tt.wantUser)
+				}
+			}
+
+			t.Run("unary", func(t *testing.T) {
+				var user middleware.UserContext
+				_, err := unaryAuth(tt.jwtSvc)(
+					tt.ctx,
+					nil,
+					&grpc.UnaryServerInfo{FullMethod: tt.method},
+					func(ctx context.Context, _ any) (any, error) {
+						user, _ = middleware.UserFromContext(ctx)
+						return nil, nil
+					},
+				)
+				check(t, err, user)
+			})
+
+			t.Run("stream", func(t *testing.T) {
+				var user middleware.UserContext
+				err := streamAuth(tt.jwtSvc)(
+					nil,
+					&testStream{ctx: tt.ctx},
+					&grpc.StreamServerInfo{FullMethod: tt.method},
+					func(_ any, ss grpc.ServerStream) error {
+						user, _ = middleware.UserFromContext(ss.Context())
+						return nil
+					},
+				)
+				check(t, err, user)
+			})
+		})
+	}
+}
+
+// Публичны только проверки здоровья и reflection, все методы API требуют токен. Имена методов берем
+// из сгенерированного кода: переименование сервиса в proto сломает тест, а не тихо откроет метод.
+func TestPublicMethods(t *testing.T) {
+	public := []string{
+		healthpb.Health_Check_FullMethodName,
+		healthpb.Health_Watch_FullMethodName,
+		healthpb.Health_List_FullMethodName,
+		reflectionpb.ServerReflection_ServerReflectionInfo_FullMethodName,
+		reflectionv1alphapb.ServerReflection_ServerReflectionInfo_FullMethodName,
+	}
+	for _, method := range public {
+		if !isPublicMethod(method) {
+			t.Errorf("%s requires a token, want public", method)
+		}
+	}
+
+	desc := warrantyv1.WarrantyService_ServiceDesc
+	for _, m := range desc.Methods {
+		if method := "/" + desc.ServiceName + "/" + m.MethodName; isPublicMethod(method) {
+			t.Errorf("%s is public, want token required", method)
+		}
+	}
+	for _, s := range desc.Streams {
+		if method := "/" + desc.ServiceName + "/" + s.StreamName; isPublicMethod(method) {
+			t.Errorf("%s is public, want token required", method)
+		}
+	}
+}
+
+func TestRequestIDInterceptors(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantSame string
+	}{
+		{name: "incoming id kept", ctx: incomingContext(requestIDMetadata, "req-123.abc:1"), wantSame: "req-123.abc:1"},
+		{name: "missing id generated", ctx: context.Background()},
+		{name: "unsafe id replaced", ctx: incomingContext(requestIDMetadata, "bad id\nInjected: 1")},
+		{name: "too long id replaced", ctx: incomingContext(requestIDMetadata, strings.Repeat("a", 1000))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(t *testing.T, requestID string) {
+				t.Helper()
+				if tt.wantSame != "" {
+					if requestID != tt.wantSame {
+						t.Fatalf("request id = %q, want %q", requestID, tt.wantSame)
+					}
+					return
+				}
+				if !generated.MatchString(requestID) {
+					t.Fatalf("request id = %q, want generated 32 hex chars", requestID)
+				}
+			}
+
+			t.Run("unary", func(t *testing.T) {
+				var requestID string
+				_, _ = unaryRequestID()(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: testClaimsMethod},
+					func(ctx context.Context, _ any) (any, error) {
+						requestID = logging.RequestIDFromContext(ctx)
+						return nil, nil
+					})
+				check(t, requestID)
+			})
+
+			t.Run("stream", func(t *testing.T) {
+				var requestID string
+				_ = streamRequestID()(nil, &testStream{ctx: tt.ctx}, &grpc.StreamServerInfo{FullMethod: testClaimsMethod},
+					func(_ any, ss grpc.ServerStream) error {
+						requestID = logging.RequestIDFromContext(ss.Context())
+						return nil
+					})
+				check(t, requestID)
+			})
+		})
+	}
+}
+
+func TestRecoveryInterceptors(t *testing.T) {
+	errNotFound := status.Error(codes.NotFound, "claim not found")
+
+	tests := []struct {
+		name     string
+		call     func() error
+		wantCode codes.Code
+		wantLog  bool
+	}{
+		{name: "panic", call: func() error { panic("boom") }, wantCode: codes.Internal, wantLog: true},
+		{name: "handler error passes through", call: func() error { return errNotFound }, wantCode: codes.NotFound},
+		{name: "success", call: func() error { return nil }, wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(t *testing.T, err error, logs *bytes.Buffer) {
+				t.Helper()
+				if got := status.Code(err); got != tt.wantCode {
+					t.Fatalf("code = %s, want %s (err = %v)", got, tt.wantCode, err)
+				}
+				if logged := strings.Contains(logs.String(), "panic recovered"); logged != tt.wantLog {
+					t.Fatalf("panic logged = %v, want %v; logs: %s", logged, tt.wantLog, logs)
+				}
+			}
+
+			t.Run("unary", func(t *testing.T) {
+				var logs bytes.Buffer
+				_, err := unaryRecovery(slog.New(slog.NewTextHandler(&logs, nil)))(
+					context.Background(),
+					nil,
+					&grpc.UnaryServerInfo{FullMethod: testClaimsMethod},
+					func(context.Context, any) (any, error) { return nil, tt.call() },
+				)
+				check(t, err, &logs)
+			})
+
+			t.Run("stream", func(t *testing.T) {
+				var logs bytes.Buffer
+				err := streamRecovery(slog.New(slog.NewTextHandler(&logs, nil)))(
+					nil,
+					&testStream{ctx: context.Background()},
+					&grpc.StreamServerInfo{FullMethod: testClaimsMethod},
+					func(any, grpc.ServerStream) error { return tt.call() },
+				)
+				check(t, err, &logs)
+			})
+		})
+	}
+}
+
+func TestLoggingInterceptorsSkipPublicMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		err     error
+		wantLog string
+	}{
+		{name: "ok call", method: testClaimsMethod, wantLog: "code=OK"},
+		{
+			name:    "failed call",
+			method:  testClaimsMethod,
+			err:     status.Error(codes.PermissionDenied, "forbidden"),
+			wantLog: "code=PermissionDenied",
+		},
+		{name: "plain error", method: testClaimsMethod, err: errors.New("db down"), wantLog: "code=Unknown"},
+		{name: "health check", method: healthpb.Health_Check_FullMethodName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+			_, err := unaryLogging(logger)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(context.Context, any) (any, error) { return nil, tt.err })
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			err = streamLogging(logger)(nil, &testStream{ctx: context.Background()},
+				&grpc.StreamServerInfo{FullMethod: tt.method},
+				func(any, grpc.ServerStream) error { return tt.err })
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+
+			if tt.wantLog == "" {
+				if logs.Len() != 0 {
+					t.Fatalf("public method logged: %s", logs.String())
+				}
+				return
+			}
+			if got := strings.Count(logs.String(), tt.wantLog); got != 2 {
+				t.Fatalf("logs contain %q %d times, want 2 (unary and stream); logs: %s", tt.wantLog, got, logs.String())
+			}
+		})
+	}
+}
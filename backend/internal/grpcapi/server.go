@@ -0,0 +1,176 @@
+// Package grpcapi — gRPC API расчета гарантийных дней для внутренних сервисов. Работает рядом
+// с HTTP-сервером на тех же сервисах и с той же проверкой JWT.
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"warranty_days/internal/config"
+	"warranty_days/internal/grpcapi/warrantyv1"
+	"warranty_days/internal/health"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/metrics"
+	"warranty_days/internal/service"
+)
+
+// healthCheckInterval — как часто пересчитывать статус grpc.health.v1 по проверкам зависимостей.
+const healthCheckInterval = 10 * time.Second
+
+type Config struct {
+	Addr       string
+	Reflection bool
+}
+
+func ConfigFromApp(cfg config.Config) Config {
+	return Config{
+		Addr:       cfg.GRPCAddr,
+		Reflection: cfg.GRPCReflectionEnabled,
+	}
+}
+
+type Server struct {
+	grpcServer *grpc.Server
+	health     *grpchealth.Server
+	checker    *health.Checker
+	addr       string
+	logger     *slog.Logger
+	stopHealth context.CancelFunc
+}
+
+func New(
+	cfg Config,
+	claimsSvc *service.ClaimsService,
+	appMetrics *metrics.Metrics,
+	jwtSvc middleware.AccessTokenValidator,
+	checker *health.Checker,
+	logger *slog.Logger,
+) *Server {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	grpcServer := grpc.NewServer(
+		// проверки здоровья не трейсим, как и не логируем: их дергают каждые несколько секунд
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+		grpc.ChainUnaryInterceptor(
+			unaryRequestID(),
+			unaryLogging(logger),
+			unaryRecovery(logger),
+			unaryAuth(jwtSvc),
+		),
+		grpc.ChainStreamInterceptor(
+			streamRequestID(),
+			streamLogging(logger),
+			streamRecovery(logger),
+			streamAuth(jwtSvc),
+		),
+	)
+
+	warrantyv1.RegisterWarrantyServiceServer(grpcServer, &warrantyService{
+		claimsSvc: claimsSvc,
+		metrics:   appMetrics,
+		logger:    logger,
+	})
+
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	if cfg.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	return &Server{
+		grpcServer: grpcServer,
+		health:     healthServer,
+		checker:    checker,
+		addr:       cfg.Addr,
+		logger:     logger,
+	}
+}
+
+// Start занимает порт и обслуживает запросы в фоне. Ошибка — только если порт занять не удалось.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", s.addr, err)
+	}
+
+	s.Serve(listener)
+	return nil
+}
+
+// Serve обслуживает запросы на готовом listener в фоне.
+func (s *Server) Serve(listener net.Listener) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopHealth = cancel
+	go s.watchHealth(ctx)
+
+	go func() {
+		s.logger.Info("grpc server listening", "grpc_addr", listener.Addr().String())
+		if err := s.grpcServer.Serve(listener); err != nil {
+			s.logger.Error("grpc server stopped", "error", err)
+		}
+	}()
+}
+
+// Shutdown переводит health в NOT_SERVING и ждет активные вызовы (включая потоки) до дедлайна ctx,
+// после чего закрывает соединения.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.stopHealth != nil {
+		s.stopHealth()
+	}
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return fmt.Errorf("grpc graceful stop: %w", ctx.Err())
+	}
+}
+
+func (s *Server) watchHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.updateHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateHealth выставляет статус как /health/ready: NOT_SERVING только при проваленной проверке.
+func (s *Server) updateHealth(ctx context.Context) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if s.checker != nil {
+		if report := s.checker.Run(ctx); report.Status == health.StatusFail {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			s.logger.WarnContext(ctx, "grpc readiness check failed", "checks", report.Checks)
+		}
+	}
+
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(warrantyv1.WarrantyService_ServiceDesc.ServiceName, status)
+}
@@ -0,0 +1,189 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"warranty_days/internal/grpcapi/warrantyv1"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/metrics"
+	"warranty_days/internal/models"
+	"warranty_days/internal/service"
+)
+
+const asOfLayout = "2006-01-02"
+
+// maxBatchVINs ограничивает BatchWarrantyYear: каждый VIN — отдельный расчет в БД.
+const maxBatchVINs = 500
+
+type warrantyService struct {
+	warrantyv1.UnimplementedWarrantyServiceServer
+
+	claimsSvc *service.ClaimsService
+	metrics   *metrics.Metrics
+	logger    *slog.Logger
+}
+
+func (s *warrantyService) GetClaimsByVIN(
+	ctx context.Context,
+	req *warrantyv1.GetClaimsByVINRequest,
+) (*warrantyv1.GetClaimsByVINResponse, error) {
+	result, err := s.claimsSvc.ClaimsByVIN(ctx, currentUserID(ctx), req.GetVin())
+	if err != nil {
+		return nil, s.statusError(ctx, req.GetVin(), err)
+	}
+
+	claims := make([]*warrantyv1.Claim, 0, len(result.Claims))
+	for _, claim := range result.Claims {
+		claims = append(claims, toClaim(claim))
+	}
+	return &warrantyv1.GetClaimsByVINResponse{Claims: claims}, nil
+}
+
+func (s *warrantyService) GetWarrantyYear(
+	ctx context.Context,
+	req *warrantyv1.GetWarrantyYearRequest,
+) (*warrantyv1.GetWarrantyYearResponse, error) {
+	asOf, err := parseAsOf(req.GetAsOf())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.claimsSvc.WarrantyYear(ctx, currentUserID(ctx), req.GetVin(), asOf)
+	if err != nil {
+		return nil, s.statusError(ctx, req.GetVin(), err)
+	}
+	s.metrics.WarrantyYearCalculated()
+
+	return &warrantyv1.GetWarrantyYearResponse{Report: toReport(result)}, nil
+}
+
+func (s *warrantyService) BatchWarrantyYear(
+	req *warrantyv1.BatchWarrantyYearRequest,
+	stream grpc.ServerStreamingServer[warrantyv1.BatchWarrantyYearResponse],
+) error {
+	if len(req.GetVins()) > maxBatchVINs {
+		return status.Errorf(codes.InvalidArgument, "too many vins, max %d per call", maxBatchVINs)
+	}
+	asOf, err := parseAsOf(req.GetAsOf())
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	userID := currentUserID(ctx)
+	for _, vin := range req.GetVins() {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		resp := &warrantyv1.BatchWarrantyYearResponse{Vin: vin}
+		result, err := s.claimsSvc.WarrantyYear(ctx, userID, vin, asOf)
+		if err != nil {
+			resp.Result = &warrantyv1.BatchWarrantyYearResponse_Error{Error: s.batchError(ctx, vin, err)}
+		} else {
+			s.metrics.WarrantyYearCalculated()
+			resp.Result = &warrantyv1.BatchWarrantyYearResponse_Report{Report: toReport(result)}
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *warrantyService) statusError(ctx context.Context, vin string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrVINRequired):
+		return status.Error(codes.InvalidArgument, "vin is required")
+	case errors.Is(err, service.ErrClaimsNotFound):
+		return status.Error(codes.NotFound, "claims not found for vin")
+	default:
+		s.logger.ErrorContext(ctx, "grpc claims request failed", "vin", vin, "error", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func (s *warrantyService) batchError(ctx context.Context, vin string, err error) *warrantyv1.BatchError {
+	switch {
+	case errors.Is(err, service.ErrVINRequired):
+		return &warrantyv1.BatchError{Code: problem.CodeVINRequired, Message: "vin is required"}
+	case errors.Is(err, service.ErrClaimsNotFound):
+		return &warrantyv1.BatchError{Code: problem.CodeClaimsNotFound, Message: "claims not found for vin"}
+	default:
+		s.logger.ErrorContext(ctx, "grpc batch warranty-year failed", "vin", vin, "error", err)
+		return &warrantyv1.BatchError{Code: problem.CodeInternal, Message: "internal error"}
+	}
+}
+
+func parseAsOf(raw string) (*time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(asOfLayout, raw)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "as_of must be a date in YYYY-MM-DD format")
+	}
+	return &parsed, nil
+}
+
+func currentUserID(ctx context.Context) int64 {
+	user, _ := middleware.UserFromContext(ctx)
+	return user.UserID
+}
+
+func toClaim(claim models.Claim) *warrantyv1.Claim {
+	return &warrantyv1.Claim{
+		Id:          claim.ID,
+		Vin:         claim.VIN,
+		RetailDate:  timestamppb.New(claim.RetailDate),
+		RoOpenDate:  timestamppb.New(claim.RoOpenDate),
+		RoCloseDate: timestamppb.New(claim.RoCloseDate),
+		CreatedAt:   timestamppb.New(claim.CreatedAt),
+		UpdatedAt:   timestamppb.New(claim.UpdatedAt),
+	}
+}
+
+func toReport(result service.WarrantyYearResult) *warrantyv1.WarrantyYearReport {
+	report := result.Report
+
+	periods := make([]*warrantyv1.WarrantyPeriod, 0, len(report.Periods))
+	for _, period := range report.Periods {
+		items := make([]*warrantyv1.RepairItem, 0, len(period.Items))
+		for _, item := range period.Items {
+			items = append(items, &warrantyv1.RepairItem{
+				ClaimId:     item.Claim.ID,
+				RoOpenDate:  timestamppb.New(item.Claim.RoOpenDate),
+				RoCloseDate: timestamppb.New(item.Claim.RoCloseDate),
+				RepairDays:  int32(item.RepairDays),
+			})
+		}
+
+		periods = append(periods, &warrantyv1.WarrantyPeriod{
+			Start:     timestamppb.New(period.WarrantyStart),
+			End:       timestamppb.New(period.WarrantyEnd),
+			TotalDays: int32(period.TotalDays),
+			Items:     items,
+		})
+	}
+
+	return &warrantyv1.WarrantyYearReport{
+		Vin:        report.VIN,
+		RetailDate: timestamppb.New(report.RetailDate),
+		AsOf:       result.AsOf.Format(asOfLayout),
+		Periods:    periods,
+	}
+}
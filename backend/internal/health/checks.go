@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DatabasePing проверяет, что БД отвечает.
+func DatabasePing(sqlDB *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Run: func(ctx context.Context) CheckResult {
+			if err := sqlDB.PingContext(ctx); err != nil {
+				return fail("database unavailable", err)
+			}
+			return CheckResult{Status: StatusOK}
+		},
+	}
+}
+
+// SchemaVersion сверяет версию схемы в schema_migrations с версией, которую ждет бинарник.
+// Схема новее бинарника — нормальная ситуация при rolling deploy, старее — нет.
+func SchemaVersion(sqlDB *sql.DB, expected int) Check {
+	return Check{
+		Name: "schema",
+		Run: func(ctx context.Context) CheckResult {
+			var current sql.NullInt64
+			err := sqlDB.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&current)
+			if err != nil {
+				return fail("schema version unavailable", err)
+			}
+
+			details := map[string]any{"current": current.Int64, "expected": expected}
+			if current.Int64 < int64(expected) {
+				return CheckResult{
+					Status:  StatusFail,
+					Error:   fmt.Sprintf("schema version %d is older than expected %d", current.Int64, expected),
+					Details: details,
+				}
+			}
+			return CheckResult{Status: StatusOK, Details: details}
+		},
+	}
+}
+
+// PoolSaturation показывает загрузку пула соединений.
+// При занятости выше threshold инстанс помечается degraded, но остается готовым принимать трафик.
+func PoolSaturation(sqlDB *sql.DB, threshold float64) Check {
+	return Check{
+		Name: "db_pool",
+		Run: func(context.Context) CheckResult {
+			stats := sqlDB.Stats()
+
+			saturation := 0.0
+			if stats.MaxOpenConnections > 0 {
+				saturation = float64(stats.InUse) / float64(stats.MaxOpenConnections)
+			}
+
+			result := CheckResult{
+				Status: StatusOK,
+				Details: map[string]any{
+					"max_open":         stats.MaxOpenConnections,
+					"open":             stats.OpenConnections,
+					"in_use":           stats.InUse,
+					"idle":             stats.Idle,
+					"wait_count":       stats.WaitCount,
+					"wait_duration_ms": stats.WaitDuration.Milliseconds(),
+					"saturation":       saturation,
+				},
+			}
+			if saturation >= threshold {
+				result.Status = StatusDegraded
+			}
+			return result
+		},
+	}
+}
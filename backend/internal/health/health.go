@@ -0,0 +1,97 @@
+// Package health runs dependency checks for readiness probes
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusFail     Status = "fail"
+)
+
+type CheckResult struct {
+	Status    Status         `json:"status"`
+	LatencyMs float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+
+	// err — исходная ошибка проверки. Пишется только в лог: ответ /health/ready доступен без авторизации.
+	err error
+}
+
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) CheckResult
+}
+
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker запускает проверки параллельно, каждую со своим таймаутом.
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+	logger  *slog.Logger
+}
+
+func NewChecker(timeout time.Duration, logger *slog.Logger, checks ...Check) *Checker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Checker{checks: checks, timeout: timeout, logger: logger}
+}
+
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range c.checks {
+		wg.Go(func() {
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			startedAt := time.Now()
+			result := check.Run(checkCtx)
+			result.LatencyMs = float64(time.Since(startedAt).Microseconds()) / 1000
+			if result.err != nil {
+				c.logger.WarnContext(ctx, "health check failed", "check", check.Name, "error", result.err)
+			}
+
+			mu.Lock()
+			report.Checks[check.Name] = result
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch {
+		case result.Status == StatusFail:
+			report.Status = StatusFail
+		case result.Status == StatusDegraded && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// fail отдает наружу фиксированное сообщение, а текст err только логирует.
+func fail(message string, err error) CheckResult {
+	return CheckResult{Status: StatusFail, Error: message, err: err}
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCheckerAggregatesStatus(t *testing.T) {
+	check := func(name string, status Status) Check {
+		return Check{Name: name, Run: func(context.Context) CheckResult {
+			return CheckResult{Status: status}
+		}}
+	}
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+	}{
+		{name: "no checks", want: StatusOK},
+		{name: "all ok", checks: []Check{check("a", StatusOK), check("b", StatusOK)}, want: StatusOK},
+		{name: "degraded", checks: []Check{check("a", StatusOK), check("b", StatusDegraded)}, want: StatusDegraded},
+		// fail важнее degraded независимо от порядка проверок
+		{name: "fail wins", checks: []Check{check("a", StatusFail), check("b", StatusDegraded)}, want: StatusFail},
+		{name: "fail after degraded", checks: []Check{check("a", StatusDegraded), check("b", StatusFail)}, want: StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewChecker(time.Second, slog.New(slog.DiscardHandler), tt.checks...).Run(context.Background())
+			if report.Status != tt.want {
+				t.Fatalf("status = %q, want %q", report.Status, tt.want)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("got %d check results, want %d", len(report.Checks), len(tt.checks))
+			}
+		})
+	}
+}
+
+func TestCheckerAppliesTimeout(t *testing.T) {
+	slow := Check{Name: "slow", Run: func(ctx context.Context) CheckResult {
+		<-ctx.Done()
+		return fail("slow check timed out", ctx.Err())
+	}}
+
+	report := NewChecker(10*time.Millisecond, slog.New(slog.DiscardHandler), slow).Run(context.Background())
+	result := report.Checks["slow"]
+	if report.Status != StatusFail || !errors.Is(result.err, context.DeadlineExceeded) {
+		t.Fatalf("report = %+v, want slow check failed by timeout", report)
+	}
+}
+
+func TestDatabasePingHidesErrorText(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	pingErr := errors.New(`dial tcp 10.0.3.7:5432: password authentication failed for user "warranty"`)
+	mock.ExpectPing().WillReturnError(pingErr)
+
+	var logs bytes.Buffer
+	report := NewChecker(time.Second, slog.New(slog.NewTextHandler(&logs, nil)), DatabasePing(sqlDB)).
+		Run(context.Background())
+
+	// ответ без авторизации: адрес БД и имя пользователя уходят только в лог
+	if result := report.Checks["database"]; result.Status != StatusFail || result.Error != "database unavailable" {
+		t.Fatalf("database check = %+v, want a fixed error message", result)
+	}
+	if !strings.Contains(logs.String(), "password authentication failed") {
+		t.Fatalf("log = %q, want the original error", logs.String())
+	}
+}
@@ -3,18 +3,53 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/metrics"
 	"warranty_days/internal/service"
 )
 
 type AuthHandler struct {
-	authSvc *service.AuthService
+	authSvc         *service.AuthService
+	registrationSvc *service.RegistrationService
+	metrics         *metrics.Metrics
+	cookies         SessionCookies
+	logger          *slog.Logger
 }
 
 type registerRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	InviteCode string `json:"invite_code"`
+}
+
+func (req registerRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("email", req.Email)
+	errs.required("password", req.Password)
+	return errs
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func (req verifyEmailRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("token", req.Token)
+	return errs
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+func (req resendVerificationRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("email", req.Email)
+	return errs
 }
 
 type loginRequest struct {
@@ -22,121 +57,268 @@ type loginRequest struct {
 	Password string `json:"password"`
 }
 
+func (req loginRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("email", req.Email)
+	errs.required("password", req.Password)
+	return errs
+}
+
 type refreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+func (req refreshRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("refresh_token", req.RefreshToken)
+	return errs
+}
+
+// authTokensResponse — в браузерном режиме refresh_token нет в теле, зато есть csrf_token.
 type authTokensResponse struct {
 	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 	TokenType    string `json:"token_type"`
+	CSRFToken    string `json:"csrf_token,omitempty"`
+}
+
+type mfaChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	MFAToken           string `json:"mfa_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
 }
 
 type userResponse struct {
-	ID       int64  `json:"id"`
-	Email    string `json:"email"`
-	IsActive bool   `json:"is_active"`
+	ID                   int64  `json:"id"`
+	Email                string `json:"email"`
+	Role                 string `json:"role"`
+	IsActive             bool   `json:"is_active"`
+	VerificationRequired bool   `json:"verification_required"`
 }
 
-func NewAuthHandler(authSvc *service.AuthService) *AuthHandler {
-	return &AuthHandler{authSvc: authSvc}
+func NewAuthHandler(
+	authSvc *service.AuthService,
+	registrationSvc *service.RegistrationService,
+	metrics *metrics.Metrics,
+	cookies SessionCookies,
+	logger *slog.Logger,
+) *AuthHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &AuthHandler{
+		authSvc:         authSvc,
+		registrationSvc: registrationSvc,
+		metrics:         metrics,
+		cookies:         cookies,
+		logger:          logger,
+	}
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req registerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid json body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	user, err := h.authSvc.Register(r.Context(), req.Email, req.Password)
+	user, err := h.registrationSvc.Register(r.Context(), req.Email, req.Password, req.InviteCode)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrInvalidEmail):
-			writeJSONError(w, http.StatusBadRequest, "invalid email")
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidEmail, "invalid email")
 			return
 		case errors.Is(err, service.ErrWeakPassword):
-			writeJSONError(w, http.StatusBadRequest, "weak password")
+			// текст собран из фиксированных сообщений политики паролей, его можно отдавать клиенту
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeWeakPassword, err.Error())
 			return
 		case errors.Is(err, service.ErrEmailAlreadyExists):
-			writeJSONError(w, http.StatusConflict, "email already exists")
+			problem.Write(w, r, http.StatusConflict, problem.CodeEmailAlreadyExists, "email already exists")
+			return
+		case errors.Is(err, service.ErrRegistrationInviteOnly):
+			problem.Write(
+				w, r, http.StatusForbidden, problem.CodeInviteRequired,
+				"registration is invite-only, invite_code is required",
+			)
+			return
+		case errors.Is(err, service.ErrInvalidInvitation):
+			problem.Write(w, r, http.StatusForbidden, problem.CodeInvalidInvitation, "invalid or expired invite code")
+			return
+		case errors.Is(err, service.ErrEmailDomainNotAllowed):
+			problem.Write(w, r, http.StatusForbidden, problem.CodeEmailDomainNotAllowed, "email domain is not allowed")
 			return
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal error")
+			problem.Internal(w, r, h.logger, "registration failed", err)
 			return
 		}
 	}
 
 	resp := userResponse{
-		ID:       user.ID,
-		Email:    user.Email,
-		IsActive: user.IsActive,
+		ID:                   user.ID,
+		Email:                user.Email,
+		Role:                 user.Role,
+		IsActive:             user.IsActive,
+		VerificationRequired: user.IsPendingVerification(),
 	}
 
 	writeJSON(w, http.StatusCreated, resp)
 }
 
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.registrationSvc.VerifyEmail(r.Context(), req.Token); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidVerificationToken):
+			problem.Write(
+				w, r, http.StatusBadRequest, problem.CodeInvalidVerificationToken,
+				"invalid or expired verification token",
+			)
+			return
+		default:
+			problem.Internal(w, r, h.logger, "email verification failed", err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req resendVerificationRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.registrationSvc.ResendVerification(r.Context(), req.Email); err != nil {
+		problem.Internal(w, r, h.logger, "failed to resend verification", err)
+		return
+	}
+
+	// Ответ одинаковый для любого email, чтобы нельзя было проверить существование аккаунта.
+	w.WriteHeader(http.StatusAccepted)
+}
+
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if !h.cookies.allowed(w, r) {
+		return
+	}
+
 	var req loginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid json body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	tokens, err := h.authSvc.Login(r.Context(), req.Email, req.Password)
+	result, err := h.authSvc.Login(r.Context(), req.Email, req.Password)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrInvalidCredentials):
-			writeJSONError(w, http.StatusUnauthorized, "invalid credentials")
+			h.metrics.LoginAttempt(metrics.LoginFailure)
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidCredentials, "invalid credentials")
+			return
+		case errors.Is(err, service.ErrEmailNotVerified):
+			h.metrics.LoginAttempt(metrics.LoginRejected)
+			problem.Write(
+				w, r, http.StatusForbidden, problem.CodeEmailNotVerified,
+				"email verification is pending, check your inbox",
+			)
 			return
 		case errors.Is(err, service.ErrUserInactive):
-			writeJSONError(w, http.StatusForbidden, "user is inactive")
+			h.metrics.LoginAttempt(metrics.LoginRejected)
+			problem.Write(w, r, http.StatusForbidden, problem.CodeUserInactive, "user is inactive")
 			return
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal error")
+			h.metrics.LoginAttempt(metrics.LoginError)
+			problem.Internal(w, r, h.logger, "login failed", err)
 			return
 		}
 	}
 
-	resp := authTokensResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
-		TokenType:    "Bearer",
+	if result.MFA != nil {
+		h.metrics.LoginAttempt(metrics.LoginMFARequired)
+		writeJSON(w, http.StatusOK, mfaChallengeResponse{
+			MFARequired:        true,
+			MFAToken:           result.MFA.Token,
+			EnrollmentRequired: result.MFA.EnrollmentRequired,
+		})
+		return
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	h.metrics.LoginAttempt(metrics.LoginSuccess)
+	writeTokens(w, r, h.cookies, result.Tokens)
 }
 
+// Refresh в браузерном режиме берет refresh-токен из cookie, а не из тела, и требует CSRF-токен:
+// cookie браузер отправит и с чужого сайта.
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
-	var req refreshRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid json body")
+	if !h.cookies.allowed(w, r) {
 		return
 	}
 
-	tokens, err := h.authSvc.Refresh(r.Context(), req.RefreshToken)
+	var refreshToken string
+	if cookieMode(r) {
+		if !checkCSRF(w, r) {
+			return
+		}
+		if cookie, err := r.Cookie(RefreshCookieName); err == nil {
+			refreshToken = cookie.Value
+		}
+		if refreshToken == "" {
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidRefreshToken, "missing refresh token cookie")
+			return
+		}
+	} else {
+		var req refreshRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		refreshToken = req.RefreshToken
+	}
+
+	tokens, err := h.authSvc.Refresh(r.Context(), refreshToken)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrInvalidCredentials):
-			writeJSONError(w, http.StatusUnauthorized, "invalid refresh token")
+			if cookieMode(r) {
+				h.cookies.clear(w)
+			}
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidRefreshToken, "invalid refresh token")
+			return
+		case errors.Is(err, service.ErrEmailNotVerified):
+			problem.Write(
+				w, r, http.StatusForbidden, problem.CodeEmailNotVerified,
+				"email verification is pending, check your inbox",
+			)
+			return
+		case errors.Is(err, service.ErrUserInactive):
+			problem.Write(w, r, http.StatusForbidden, problem.CodeUserInactive, "user is inactive")
+			return
+		case errors.Is(err, service.ErrMFAEnrollRequired):
+			problem.Write(
+				w, r, http.StatusForbidden, problem.CodeMFAEnrollRequired,
+				"two-factor authentication is required, log in again to enroll",
+			)
 			return
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "internal error")
+			problem.Internal(w, r, h.logger, "token refresh failed", err)
 			return
 		}
 	}
 
-	resp := authTokensResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
-		TokenType:    "Bearer",
-	}
-
-	writeJSON(w, http.StatusOK, resp)
+	writeTokens(w, r, h.cookies, tokens)
 }
 
-func writeJSONError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
+// Logout удаляет cookie браузерного режима. Refresh-токены не хранятся на сервере, так что
+// выданный токен доживает свой срок, но браузер его больше не отправит.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if _, err := r.Cookie(CSRFCookieName); err == nil && !checkCSRF(w, r) {
+		return
+	}
+
+	h.cookies.clear(w)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload any) {
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"warranty_days/internal/events"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/service"
+)
+
+const (
+	// streamBuffer — сколько событий шины ждет разбора; при переполнении спасает проверка на heartbeat.
+	streamBuffer = 16
+	// streamRetry — через сколько миллисекунд клиент переподключается после разрыва.
+	streamRetry = 3000
+
+	// события потока
+	streamEventWarrantyYear   = "warranty-year"
+	streamEventClaimsNotFound = "claims-not-found"
+)
+
+// StreamConfig — параметры потока Server-Sent Events.
+type StreamConfig struct {
+	// Heartbeat — как часто слать комментарий-пинг и заново сверять версию расчета.
+	Heartbeat time.Duration
+	// MaxDuration — после этого поток закрывается, клиент переподключается с Last-Event-ID.
+	MaxDuration time.Duration
+	// WriteTimeout — дедлайн каждой записи в поток, как HTTP_WRITE_TIMEOUT у обычных ответов.
+	WriteTimeout time.Duration
+}
+
+// ClaimStreamHandler — поток расчетов по гарантийным годам VIN: новый расчет отправляется при каждом
+// изменении заявок VIN. id события — ETag расчета, поэтому по Last-Event-ID видно, что клиент уже знает.
+type ClaimStreamHandler struct {
+	claimsSvc *service.ClaimsService
+	view      ClaimsView
+	bus       *events.Bus
+	cfg       StreamConfig
+	logger    *slog.Logger
+
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// streamState — последнее отправленное состояние: id расчета или признак, что заявок нет.
+type streamState struct {
+	id       string
+	notFound bool
+}
+
+func NewClaimStreamHandler(
+	claimsSvc *service.ClaimsService,
+	view ClaimsView,
+	bus *events.Bus,
+	cfg StreamConfig,
+	logger *slog.Logger,
+) *ClaimStreamHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ClaimStreamHandler{
+		claimsSvc: claimsSvc,
+		view:      view,
+		bus:       bus,
+		cfg:       cfg,
+		logger:    logger,
+		closing:   make(chan struct{}),
+	}
+}
+
+// Close завершает все открытые потоки — при остановке сервера клиенты переподключатся к другому инстансу.
+func (h *ClaimStreamHandler) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+func (h *ClaimStreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vin := strings.TrimSpace(r.URL.Query().Get("vin"))
+	userID := currentUserID(r)
+
+	// подписываемся до первого расчета, чтобы не пропустить изменение между ними
+	updates, unsubscribe := h.bus.Subscribe(streamBuffer)
+	defer unsubscribe()
+
+	// первый расчет до заголовков: ошибки уходят обычным problem+json, как у /claims/warranty-year
+	etag, payload, err := h.snapshot(ctx, userID, vin, r.Header.Get("Last-Event-ID"))
+	state := streamState{id: etag}
+	switch {
+	case errors.Is(err, service.ErrClaimsNotFound) && service.WellFormedVIN(vin):
+		// у VIN еще нет заявок: поток открывается с пустым расчетом, warranty-year придет с первой заявкой
+		state = streamState{notFound: true}
+	case err != nil:
+		writeClaimsError(w, r, h.logger, vin, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-store")
+	// nginx и подобные прокси иначе буферизуют поток
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	first := fmt.Sprintf("retry: %d\n\n", streamRetry)
+	switch {
+	case state.notFound:
+		first += streamEvent("", streamEventClaimsNotFound, []byte("{}"))
+	case payload != nil:
+		first += streamEvent(etag, streamEventWarrantyYear, payload)
+	}
+	if err := h.write(w, rc, first); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.cfg.Heartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(h.streamDuration(r))
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case <-h.closing:
+			return
+		case event, ok := <-updates:
+			if !ok {
+				return
+			}
+			if !event.Affects(vin) {
+				continue
+			}
+			// изменение заявки дает пачку событий (claim.* и порог) — считаем один раз
+			drain(updates)
+
+			msg, next, err := h.refresh(ctx, userID, vin, state)
+			if err != nil {
+				h.logger.WarnContext(ctx, "claim stream refresh failed", "vin", vin, "error", err)
+				continue
+			}
+			state = next
+			if msg != "" {
+				if err := h.write(w, rc, msg); err != nil {
+					return
+				}
+			}
+		case <-heartbeat.C:
+			// шина может пропустить изменение (переполненный буфер, CLAIM_NOTIFY_ENABLED=false) — ловим сверкой версии
+			msg, next, err := h.refresh(ctx, userID, vin, state)
+			if err != nil {
+				h.logger.WarnContext(ctx, "claim stream refresh failed", "vin", vin, "error", err)
+			}
+			state = next
+			if msg == "" {
+				msg = ": heartbeat\n\n"
+			}
+			if err := h.write(w, rc, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamDuration — MaxDuration, но не дольше срока access-токена: проверка доступа была только
+// при подключении, после истечения токена клиент должен переподключиться с новым.
+func (h *ClaimStreamHandler) streamDuration(r *http.Request) time.Duration {
+	d := h.cfg.MaxDuration
+	if user, ok := middleware.UserFromContext(r.Context()); ok && !user.ExpiresAt.IsZero() {
+		d = min(d, time.Until(user.ExpiresAt))
+	}
+	return d
+}
+
+// refresh сверяет версию расчета с отправленной и возвращает событие, если она изменилась.
+func (h *ClaimStreamHandler) refresh(
+	ctx context.Context,
+	userID int64,
+	vin string,
+	state streamState,
+) (string, streamState, error) {
+	etag, payload, err := h.snapshot(ctx, userID, vin, state.id)
+	if errors.Is(err, service.ErrClaimsNotFound) {
+		if state.notFound {
+			return "", state, nil
+		}
+		return streamEvent("", streamEventClaimsNotFound, []byte("{}")),
+			streamState{notFound: true}, nil
+	}
+	if err != nil {
+		return "", state, err
+	}
+	if payload == nil {
+		return "", state, nil
+	}
+	return streamEvent(etag, streamEventWarrantyYear, payload), streamState{id: etag}, nil
+}
+
+// snapshot возвращает id текущей версии расчета и сам расчет в формате GET /claims/warranty-year одной строкой
+// JSON. Если версия равна knownID, расчет не выполняется и payload пустой. Версия и расчет читаются
+// в одном снимке БД.
+func (h *ClaimStreamHandler) snapshot(
+	ctx context.Context,
+	userID int64,
+	vin string,
+	knownID string,
+) (string, []byte, error) {
+	var (
+		etag    string
+		payload []byte
+	)
+	err := h.claimsSvc.ReadSnapshot(ctx, vin, func(ctx context.Context) error {
+		stamp, err := h.claimsSvc.WarrantyYearStamp(ctx, userID, vin, nil)
+		if err != nil {
+			return err
+		}
+		etag = streamID(warrantyYearETag(vin, stamp))
+		if etag == knownID {
+			return nil
+		}
+
+		result, err := h.claimsSvc.WarrantyYear(ctx, userID, vin, &stamp.AsOf)
+		if err != nil {
+			return err
+		}
+		payload, err = json.Marshal(h.view.WarrantyYear(result))
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return etag, payload, nil
+}
+
+// write отправляет кусок потока с собственным дедлайном: медленный клиент отваливается через WriteTimeout,
+// а общий дедлайн ответа поток не обрывает.
+func (h *ClaimStreamHandler) write(w http.ResponseWriter, rc *http.ResponseController, msg string) error {
+	if h.cfg.WriteTimeout > 0 {
+		_ = rc.SetWriteDeadline(time.Now().Add(h.cfg.WriteTimeout))
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+// streamID — ETag без кавычек: id события передается клиентом обратно в Last-Event-ID как есть.
+func streamID(etag string) string {
+	return strings.Trim(etag, `"`)
+}
+
+// streamEvent форматирует событие SSE. data — одна строка JSON, поэтому одно поле data.
+func streamEvent(id, name string, data []byte) string {
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	b.WriteString("event: " + name + "\n")
+	b.WriteString("data: ")
+	b.Write(data)
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+func drain(updates <-chan events.Event) {
+	for {
+		select {
+		case _, ok := <-updates:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
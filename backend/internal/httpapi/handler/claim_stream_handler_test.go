@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"warranty_days/internal/events"
+	"warranty_days/internal/httpapi/middleware"
+)
+
+func TestStreamDurationStopsAtTokenExpiry(t *testing.T) {
+	h := NewClaimStreamHandler(nil, ClaimsViewV1{}, nil, StreamConfig{MaxDuration: 30 * time.Minute}, nil)
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		want      time.Duration
+	}{
+		{name: "token outlives max duration", expiresIn: time.Hour, want: 30 * time.Minute},
+		{name: "token expires first", expiresIn: 5 * time.Minute, want: 5 * time.Minute},
+		{name: "token without exp", want: 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := middleware.UserContext{UserID: 1}
+			if tt.expiresIn > 0 {
+				user.ExpiresAt = time.Now().Add(tt.expiresIn)
+			}
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/claims/stream?vin=X", nil)
+			r = r.WithContext(middleware.ContextWithUser(r.Context(), user))
+
+			// time.Until считается внутри, поэтому допускаем секунду расхождения
+			if got := h.streamDuration(r); got > tt.want || got < tt.want-time.Second {
+				t.Fatalf("streamDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamResumesFromLastEventID(t *testing.T) {
+	expectReport := func(mock sqlmock.Sqlmock, updatedAt time.Time) {
+		mock.ExpectBegin()
+		expectStamp(mock, 1, updatedAt)
+		mock.ExpectQuery(retailDateSQL).WithArgs(testVIN, 1).WillReturnRows(claimRows(updatedAt))
+		mock.ExpectQuery(yearClaimsSQL).WillReturnRows(claimRows(updatedAt))
+		mock.ExpectCommit()
+	}
+	expectUnchanged := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		expectStamp(mock, 1, testUpdatedAt)
+		mock.ExpectCommit()
+	}
+
+	// первое подключение без Last-Event-ID получает расчет сразу
+	svc, mock := newMockClaimsService(t)
+	expectReport(mock, testUpdatedAt)
+	first := openStream(t, NewClaimStreamHandler(svc, ClaimsViewV1{}, events.NewBus(),
+		StreamConfig{Heartbeat: time.Hour, MaxDuration: time.Hour}, slog.New(slog.DiscardHandler)), testVIN, "")
+	if msg := first.next(); msg != "retry: 3000" {
+		t.Fatalf("first message = %q, want retry", msg)
+	}
+	event := first.next()
+	id, ok := strings.CutPrefix(strings.SplitN(event, "\n", 2)[0], "id: ")
+	if !ok || !strings.Contains(event, "event: "+streamEventWarrantyYear) {
+		t.Fatalf("first event = %q, want warranty-year with id", event)
+	}
+	first.close()
+
+	// переподключение с тем же id: расчет не повторяется, дальше только heartbeat, пока версия та же;
+	// изменение, пропущенное шиной, приходит на очередной сверке
+	svc, mock = newMockClaimsService(t)
+	expectUnchanged(mock)
+	expectUnchanged(mock)
+	expectReport(mock, testUpdatedAt.Add(time.Minute))
+	resumed := openStream(t, NewClaimStreamHandler(svc, ClaimsViewV1{}, events.NewBus(),
+		StreamConfig{Heartbeat: 10 * time.Millisecond, MaxDuration: time.Hour}, slog.New(slog.DiscardHandler)), testVIN, id)
+	defer resumed.close()
+	if msg := resumed.next(); msg != "retry: 3000" {
+		t.Fatalf("resumed first message = %q, want retry only", msg)
+	}
+	if msg := resumed.next(); msg != ": heartbeat" {
+		t.Fatalf("resumed second message = %q, want heartbeat", msg)
+	}
+	changed := resumed.next()
+	if !strings.Contains(changed, "event: "+streamEventWarrantyYear) || strings.Contains(changed, "id: "+id+"\n") {
+		t.Fatalf("event after change = %q, want warranty-year with a new id", changed)
+	}
+}
+
+func TestStreamOpensEmptyForVINWithoutClaims(t *testing.T) {
+	const newVIN = "XWENE81BBM0000385"
+	svc, mock := newMockClaimsService(t)
+	mock.ExpectBegin()
+	expectVINStamp(mock, newVIN, 0, time.Time{})
+	mock.ExpectRollback()
+	// первая заявка появилась — следующая сверка версии отдает расчет
+	mock.ExpectBegin()
+	expectVINStamp(mock, newVIN, 1, testUpdatedAt)
+	mock.ExpectQuery(retailDateSQL).WithArgs(newVIN, 1).WillReturnRows(claimRows(testUpdatedAt))
+	mock.ExpectQuery(yearClaimsSQL).WillReturnRows(claimRows(testUpdatedAt))
+	mock.ExpectCommit()
+
+	stream := openStream(t, NewClaimStreamHandler(svc, ClaimsViewV1{}, events.NewBus(),
+		StreamConfig{Heartbeat: 10 * time.Millisecond, MaxDuration: time.Hour}, slog.New(slog.DiscardHandler)), newVIN, "")
+	defer stream.close()
+	if msg := stream.next(); msg != "retry: 3000" {
+		t.Fatalf("first message = %q, want retry", msg)
+	}
+	if msg := stream.next(); msg != "event: "+streamEventClaimsNotFound+"\ndata: {}" {
+		t.Fatalf("second message = %q, want empty snapshot", msg)
+	}
+	if msg := stream.next(); !strings.Contains(msg, "event: "+streamEventWarrantyYear) {
+		t.Fatalf("message after first claim = %q, want warranty-year", msg)
+	}
+}
+
+func TestStreamRejectsUnknownVIN(t *testing.T) {
+	// VIN не по ISO 3779 и без заявок: такой машины нет, поток не открывается
+	svc, mock := newMockClaimsService(t)
+	mock.ExpectBegin()
+	expectStamp(mock, 0, time.Time{})
+	mock.ExpectRollback()
+	h := NewClaimStreamHandler(svc, ClaimsViewV1{}, events.NewBus(),
+		StreamConfig{Heartbeat: time.Hour, MaxDuration: time.Hour}, slog.New(slog.DiscardHandler))
+
+	w := httptest.NewRecorder()
+	h.Stream(w, httptest.NewRequest(http.MethodGet, "/api/v1/claims/stream?vin="+testVIN, nil))
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("response = %d %s, want 404 problem+json", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+type testStream struct {
+	t      *testing.T
+	server *httptest.Server
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+func openStream(t *testing.T, h *ClaimStreamHandler, vin, lastEventID string) *testStream {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(h.Stream))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/claims/stream?vin="+vin, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream response = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return &testStream{t: t, server: server, body: resp.Body, reader: bufio.NewReader(resp.Body)}
+}
+
+// next читает одно сообщение потока — строки до пустой.
+func (s *testStream) next() string {
+	s.t.Helper()
+	var lines []string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			s.t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return strings.Join(lines, "\n")
+		}
+		lines = append(lines, line)
+	}
+}
+
+// close отключает клиента и ждет, пока обработчик завершит поток.
+func (s *testStream) close() {
+	_ = s.body.Close()
+	s.server.Close()
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/service"
+)
+
+// ClaimWriteHandler — ввод и правка заявок администратором.
+type ClaimWriteHandler struct {
+	claimWriteSvc *service.ClaimWriteService
+	logger        *slog.Logger
+}
+
+// claimRequest — даты в формате YYYY-MM-DD.
+type claimRequest struct {
+	VIN         string `json:"vin"`
+	RetailDate  string `json:"retail_date"`
+	RoOpenDate  string `json:"ro_open_date"`
+	RoCloseDate string `json:"ro_close_date"`
+}
+
+func (req claimRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("vin", req.VIN)
+	errs.date("retail_date", req.RetailDate)
+	errs.date("ro_open_date", req.RoOpenDate)
+	errs.date("ro_close_date", req.RoCloseDate)
+	return errs
+}
+
+// input вызывается после validate: даты уже проверены.
+func (req claimRequest) input() service.ClaimInput {
+	retail, _ := time.Parse(time.DateOnly, req.RetailDate)
+	open, _ := time.Parse(time.DateOnly, req.RoOpenDate)
+	closed, _ := time.Parse(time.DateOnly, req.RoCloseDate)
+	return service.ClaimInput{VIN: req.VIN, RetailDate: retail, RoOpenDate: open, RoCloseDate: closed}
+}
+
+func NewClaimWriteHandler(claimWriteSvc *service.ClaimWriteService, logger *slog.Logger) *ClaimWriteHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ClaimWriteHandler{claimWriteSvc: claimWriteSvc, logger: logger}
+}
+
+func (h *ClaimWriteHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req claimRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	claim, err := h.claimWriteSvc.Create(r.Context(), req.input())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, claim)
+}
+
+func (h *ClaimWriteHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		h.writeError(w, r, service.ErrClaimNotFound)
+		return
+	}
+
+	var req claimRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	claim, err := h.claimWriteSvc.Update(r.Context(), id, req.input())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, claim)
+}
+
+func (h *ClaimWriteHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		h.writeError(w, r, service.ErrClaimNotFound)
+		return
+	}
+
+	if err := h.claimWriteSvc.Delete(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ClaimWriteHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrClaimNotFound):
+		problem.Write(w, r, http.StatusNotFound, problem.CodeClaimNotFound, "claim not found")
+	case errors.Is(err, service.ErrVINRequired):
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeVINRequired, "vin is required")
+	case errors.Is(err, service.ErrInvalidClaimDates):
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeInvalidClaimDates,
+			"ro_close_date must not be before ro_open_date",
+		)
+	default:
+		problem.Internal(w, r, h.logger, "claim write failed", err)
+	}
+}
+
+// pathID читает числовой идентификатор из пути ({id} в шаблоне маршрута).
+func pathID(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	return id, err == nil && id > 0
+}
@@ -2,139 +2,197 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
-	"gorm.io/gorm"
+	"go.opentelemetry.io/otel"
 
-	"warranty_days/internal/repo"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/metrics"
+	"warranty_days/internal/service"
 )
 
-type ClaimsHandler struct {
-	claimRepo *repo.ClaimRepo
-	logger    *slog.Logger
-}
-
-type warrantyYearResponse struct {
-	VIN        string                       `json:"vin"`
-	RetailDate time.Time                    `json:"retail_date"`
-	Periods    []warrantyYearPeriodResponse `json:"periods"`
-}
-
-type warrantyYearPeriodResponse struct {
-	WarrantyPeriod warrantyPeriodResponse `json:"warranty_period"`
-	TotalDays      int                    `json:"total_days"`
-	Items          []warrantyPeriodItem   `json:"items"`
-}
+const asOfLayout = "2006-01-02"
 
-type warrantyPeriodResponse struct {
-	Start time.Time `json:"start"`
-	End   time.Time `json:"end"`
-}
+var tracer = otel.Tracer("warranty_days/internal/httpapi/handler")
 
-type warrantyPeriodItem struct {
-	Claim      warrantyPeriodClaim `json:"claim"`
-	RepairDays int                 `json:"repair_days"`
+// ClaimsView превращает результаты ClaimsService в DTO конкретной версии API.
+// Новая версия (например, /api/v2) добавляет свой ClaimsView, логика расчета не копируется.
+type ClaimsView interface {
+	Claims(result service.ClaimsResult) any
+	WarrantyYear(result service.WarrantyYearResult) any
 }
 
-type warrantyPeriodClaim struct {
-	ID          int64     `json:"id"`
-	RoOpenDate  time.Time `json:"ro_open_date"`
-	RoCloseDate time.Time `json:"ro_close_date"`
+type ClaimsHandler struct {
+	claimsSvc *service.ClaimsService
+	view      ClaimsView
+	metrics   *metrics.Metrics
+	logger    *slog.Logger
 }
 
-func NewClaimsHandler(claimRepo *repo.ClaimRepo, logger *slog.Logger) *ClaimsHandler {
+func NewClaimsHandler(
+	claimsSvc *service.ClaimsService,
+	view ClaimsView,
+	metrics *metrics.Metrics,
+	logger *slog.Logger,
+) *ClaimsHandler {
 	if logger == nil {
 		logger = slog.Default()
 	}
-	return &ClaimsHandler{claimRepo: claimRepo, logger: logger}
+	return &ClaimsHandler{claimsSvc: claimsSvc, view: view, metrics: metrics, logger: logger}
 }
 
 func (h *ClaimsHandler) Health(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	_, _ = w.Write([]byte("ok"))
 }
 
 func (h *ClaimsHandler) GetClaimsByVIN(w http.ResponseWriter, r *http.Request) {
-	vin := strings.TrimSpace(r.URL.Query().Get("vin"))
-	if vin == "" {
-		h.logger.WarnContext(r.Context(), "vin query param is missing", "path", r.URL.Path)
-		http.Error(w, "vin query param is required, example: /claims?vin=XXX", http.StatusBadRequest)
-		return
-	}
+	vin := r.URL.Query().Get("vin")
+	userID := currentUserID(r)
 
-	claims, err := h.claimRepo.ListByVINCaseInsensitive(r.Context(), vin)
+	// версия и заявки читаются в одном снимке: иначе ETag мог бы описать другую версию заявок
+	var (
+		etag   string
+		result *service.ClaimsResult
+	)
+	err := h.claimsSvc.ReadSnapshot(r.Context(), vin, func(ctx context.Context) error {
+		stamp, err := h.claimsSvc.ClaimsStamp(ctx, userID, vin)
+		if err != nil {
+			return err
+		}
+		// метки времени в ответе показываются в часовом поясе пользователя — он тоже часть версии
+		etag = strongETag(
+			"claims",
+			strings.TrimSpace(vin),
+			strconv.FormatInt(stamp.Stamp.Count, 10),
+			stamp.Stamp.MaxUpdatedAt.UTC().Format(time.RFC3339Nano),
+			stamp.Location.String(),
+		)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			return nil
+		}
+
+		claims, err := h.claimsSvc.ClaimsByVIN(ctx, userID, vin)
+		if err != nil {
+			return err
+		}
+		result = &claims
+		return nil
+	})
 	if err != nil {
-		h.logger.ErrorContext(r.Context(), "failed to fetch claims by vin", "vin", vin, "error", err)
-		http.Error(w, "db error: "+err.Error(), http.StatusInternalServerError)
+		writeClaimsError(w, r, h.logger, vin, err)
+		return
+	}
+	if result == nil {
+		notModified(w, r, etag)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	_ = enc.Encode(claims)
+	setCacheHeaders(w, etag)
+	writeIndentedJSON(w, h.view.Claims(*result))
 }
 
 func (h *ClaimsHandler) GetWarrantyYearClaims(w http.ResponseWriter, r *http.Request) {
-	vin := strings.TrimSpace(r.URL.Query().Get("vin"))
-	if vin == "" {
-		h.logger.WarnContext(r.Context(), "vin query param is missing", "path", r.URL.Path)
-		http.Error(w, "vin query param is required, example: /claims/warranty-year?vin=XXX", http.StatusBadRequest)
-		return
-	}
+	vin := r.URL.Query().Get("vin")
 
-	repoResp, err := h.claimRepo.ListWarrantyYearRepairsByVIN(
-		r.Context(),
-		vin,
-		time.Now(),
-	)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			h.logger.InfoContext(r.Context(), "claims not found for vin", "vin", vin)
-			http.Error(w, "claims not found for vin", http.StatusNotFound)
+	var asOf *time.Time
+	if raw := strings.TrimSpace(r.URL.Query().Get("as_of")); raw != "" {
+		parsed, err := time.Parse(asOfLayout, raw)
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidAsOf, "as_of must be a date in YYYY-MM-DD format")
 			return
 		}
-		h.logger.ErrorContext(r.Context(), "failed to fetch warranty-year claims", "vin", vin, "error", err)
-		http.Error(w, "db error: "+err.Error(), http.StatusInternalServerError)
-		return
+		asOf = &parsed
 	}
 
-	periods := make([]warrantyYearPeriodResponse, 0, len(repoResp.Periods))
-	for _, period := range repoResp.Periods {
-		items := make([]warrantyPeriodItem, 0, len(period.Items))
-		for _, item := range period.Items {
-			items = append(items, warrantyPeriodItem{
-				Claim: warrantyPeriodClaim{
-					ID:          item.Claim.ID,
-					RoOpenDate:  item.Claim.RoOpenDate,
-					RoCloseDate: item.Claim.RoCloseDate,
-				},
-				RepairDays: item.RepairDays,
-			})
+	userID := currentUserID(r)
+
+	// версию проверяем до расчета: при совпадении If-None-Match периоды не считаем. Версия и расчет
+	// читаются в одном снимке
+	var (
+		etag   string
+		result *service.WarrantyYearResult
+	)
+	err := h.claimsSvc.ReadSnapshot(r.Context(), vin, func(ctx context.Context) error {
+		stamp, err := h.claimsSvc.WarrantyYearStamp(ctx, userID, vin, asOf)
+		if err != nil {
+			return err
+		}
+		etag = warrantyYearETag(vin, stamp)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			return nil
 		}
 
-		periods = append(periods, warrantyYearPeriodResponse{
-			WarrantyPeriod: warrantyPeriodResponse{
-				Start: period.WarrantyStart,
-				End:   period.WarrantyEnd,
-			},
-			TotalDays: period.TotalDays,
-			Items:     items,
-		})
+		// считаем на ту же дату, что вошла в ETag
+		report, err := h.claimsSvc.WarrantyYear(ctx, userID, vin, &stamp.AsOf)
+		if err != nil {
+			return err
+		}
+		result = &report
+		return nil
+	})
+	if err != nil {
+		writeClaimsError(w, r, h.logger, vin, err)
+		return
+	}
+	if result == nil {
+		notModified(w, r, etag)
+		return
 	}
+	h.metrics.WarrantyYearCalculated()
+
+	resp := h.view.WarrantyYear(*result)
+
+	_, span := tracer.Start(r.Context(), "encode warranty-year response")
+	defer span.End()
 
-	resp := warrantyYearResponse{
-		VIN:        repoResp.VIN,
-		RetailDate: repoResp.RetailDate,
-		Periods:    periods,
+	setCacheHeaders(w, etag)
+	writeIndentedJSON(w, resp)
+}
+
+// warrantyYearETag — версия расчета по гарантийным годам; она же id события в потоке /claims/stream.
+func warrantyYearETag(vin string, stamp service.WarrantyYearStampResult) string {
+	return strongETag(
+		"warranty-year",
+		strings.TrimSpace(vin),
+		strconv.FormatInt(stamp.Stamp.Count, 10),
+		stamp.Stamp.MaxUpdatedAt.UTC().Format(time.RFC3339Nano),
+		stamp.AsOf.Format(asOfLayout),
+	)
+}
+
+func writeClaimsError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, vin string, err error) {
+	switch {
+	case errors.Is(err, service.ErrVINRequired):
+		logger.WarnContext(r.Context(), "vin query param is missing", "path", r.URL.Path)
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeVINRequired,
+			"vin query param is required, example: "+r.URL.Path+"?vin=XXX",
+		)
+	case errors.Is(err, service.ErrClaimsNotFound):
+		logger.InfoContext(r.Context(), "claims not found for vin", "vin", vin)
+		problem.Write(w, r, http.StatusNotFound, problem.CodeClaimsNotFound, "claims not found for vin")
+	default:
+		problem.Internal(w, r, logger, "claims request failed", err, "vin", vin)
 	}
+}
+
+func currentUserID(r *http.Request) int64 {
+	user, _ := middleware.UserFromContext(r.Context())
+	return user.UserID
+}
 
+func writeIndentedJSON(w http.ResponseWriter, payload any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
-	_ = enc.Encode(resp)
+	_ = enc.Encode(payload)
 }
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetClaimsByVINConditional(t *testing.T) {
+	svc, mock := newMockClaimsService(t)
+	h := NewClaimsHandler(svc, ClaimsViewV1{}, nil, nil)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/claims?vin="+testVIN, nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.GetClaimsByVIN(w, r)
+		return w
+	}
+
+	mock.ExpectBegin()
+	expectStamp(mock, 1, testUpdatedAt)
+	mock.ExpectQuery(listClaimsSQL).WithArgs(testVIN).WillReturnRows(claimRows(testUpdatedAt))
+	mock.ExpectCommit()
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Cache-Control") != privateCacheControl {
+		t.Fatalf("first GET = %d, headers %v", first.Code, first.Header())
+	}
+
+	// версия совпала — заявки не читаются, тела нет
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		mock.ExpectBegin()
+		expectStamp(mock, 1, testUpdatedAt)
+		mock.ExpectCommit()
+		resp := get(ifNoneMatch)
+		if resp.Code != http.StatusNotModified || resp.Body.Len() != 0 || resp.Header().Get("ETag") != etag {
+			t.Fatalf("If-None-Match %s: %d, ETag %q, body %q; want 304 with ETag %s",
+				ifNoneMatch, resp.Code, resp.Header().Get("ETag"), resp.Body, etag)
+		}
+	}
+
+	// заявку изменили — старый ETag больше не подходит
+	changedAt := testUpdatedAt.Add(time.Minute)
+	mock.ExpectBegin()
+	expectStamp(mock, 1, changedAt)
+	mock.ExpectQuery(listClaimsSQL).WithArgs(testVIN).WillReturnRows(claimRows(changedAt))
+	mock.ExpectCommit()
+	changed := get(etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Fatalf("GET after change = %d, ETag %q; want 200 with a new ETag", changed.Code, changed.Header().Get("ETag"))
+	}
+}
+
+func TestGetWarrantyYearClaimsConditional(t *testing.T) {
+	svc, mock := newMockClaimsService(t)
+	h := NewClaimsHandler(svc, ClaimsViewV1{}, nil, nil)
+
+	get := func(asOf, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/claims/warranty-year?vin="+testVIN+"&as_of="+asOf, nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.GetWarrantyYearClaims(w, r)
+		return w
+	}
+	expectReport := func() {
+		mock.ExpectQuery(retailDateSQL).WithArgs(testVIN, 1).WillReturnRows(claimRows(testUpdatedAt))
+		mock.ExpectQuery(yearClaimsSQL).WillReturnRows(claimRows(testUpdatedAt))
+	}
+
+	mock.ExpectBegin()
+	expectStamp(mock, 1, testUpdatedAt)
+	expectReport()
+	mock.ExpectCommit()
+	first := get("2025-12-31", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first GET = %d, ETag %q", first.Code, etag)
+	}
+
+	// та же версия заявок и та же дата расчета — периоды не считаются
+	mock.ExpectBegin()
+	expectStamp(mock, 1, testUpdatedAt)
+	mock.ExpectCommit()
+	if resp := get("2025-12-31", etag); resp.Code != http.StatusNotModified {
+		t.Fatalf("same as_of with If-None-Match = %d, want 304", resp.Code)
+	}
+
+	// дата расчета входит в версию: другой as_of с тем же ETag считается заново
+	mock.ExpectBegin()
+	expectStamp(mock, 1, testUpdatedAt)
+	expectReport()
+	mock.ExpectCommit()
+	if resp := get("2026-01-01", etag); resp.Code != http.StatusOK || resp.Header().Get("ETag") == etag {
+		t.Fatalf("other as_of = %d, ETag %q; want 200 with a new ETag", resp.Code, resp.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"time"
+
+	"warranty_days/internal/service"
+)
+
+// APIV1Prefix — префикс маршрутов первой версии API.
+const APIV1Prefix = "/api/v1"
+
+// ClaimsViewV1 — формат ответов /api/v1/claims*. Поля можно только добавлять (так появился as_of):
+// переименовать, удалить или поменять тип нельзя — на них завязаны текущие клиенты.
+type ClaimsViewV1 struct{}
+
+type warrantyYearResponse struct {
+	VIN        string                       `json:"vin"`
+	RetailDate time.Time                    `json:"retail_date"`
+	AsOf       string                       `json:"as_of"`
+	Periods    []warrantyYearPeriodResponse `json:"periods"`
+}
+
+type warrantyYearPeriodResponse struct {
+	WarrantyPeriod warrantyPeriodResponse `json:"warranty_period"`
+	TotalDays      int                    `json:"total_days"`
+	Items          []warrantyPeriodItem   `json:"items"`
+}
+
+type warrantyPeriodResponse struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type warrantyPeriodItem struct {
+	Claim      warrantyPeriodClaim `json:"claim"`
+	RepairDays int                 `json:"repair_days"`
+}
+
+type warrantyPeriodClaim struct {
+	ID          int64     `json:"id"`
+	RoOpenDate  time.Time `json:"ro_open_date"`
+	RoCloseDate time.Time `json:"ro_close_date"`
+}
+
+// Claims в v1 отдает модели как есть.
+func (ClaimsViewV1) Claims(result service.ClaimsResult) any {
+	return result.Claims
+}
+
+func (ClaimsViewV1) WarrantyYear(result service.WarrantyYearResult) any {
+	report := result.Report
+
+	periods := make([]warrantyYearPeriodResponse, 0, len(report.Periods))
+	for _, period := range report.Periods {
+		items := make([]warrantyPeriodItem, 0, len(period.Items))
+		for _, item := range period.Items {
+			items = append(items, warrantyPeriodItem{
+				Claim: warrantyPeriodClaim{
+					ID:          item.Claim.ID,
+					RoOpenDate:  item.Claim.RoOpenDate,
+					RoCloseDate: item.Claim.RoCloseDate,
+				},
+				RepairDays: item.RepairDays,
+			})
+		}
+
+		periods = append(periods, warrantyYearPeriodResponse{
+			WarrantyPeriod: warrantyPeriodResponse{
+				Start: period.WarrantyStart,
+				End:   period.WarrantyEnd,
+			},
+			TotalDays: period.TotalDays,
+			Items:     items,
+		})
+	}
+
+	return warrantyYearResponse{
+		VIN:        report.VIN,
+		RetailDate: report.RetailDate,
+		AsOf:       result.AsOf.Format(asOfLayout),
+		Periods:    periods,
+	}
+}
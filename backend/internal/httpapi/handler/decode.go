@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"warranty_days/internal/httpapi/problem"
+)
+
+// maxJSONBodyBytes — предел тела запроса. Самые большие тела сейчас — регистрация и приглашение, это сотни байт.
+const maxJSONBodyBytes = 64 << 10
+
+// validator — DTO запроса, который сам проверяет поля после декодирования.
+type validator interface {
+	validate() []problem.FieldError
+}
+
+// decodeJSON читает тело запроса в dst: только application/json, не больше maxJSONBodyBytes, ровно один
+// JSON-объект без неизвестных полей. Если dst — validator, проверяет поля. При ошибке сам пишет ответ
+// и возвращает false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		problem.Write(
+			w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia,
+			"request body must be application/json",
+		)
+		return false
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		writeDecodeError(w, r, err)
+		return false
+	}
+	if err := dec.Decode(&json.RawMessage{}); !errors.Is(err, io.EOF) {
+		if isBodyTooLarge(err) {
+			writeDecodeError(w, r, err)
+			return false
+		}
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeInvalidJSON,
+			"request body must contain a single json value",
+		)
+		return false
+	}
+
+	if v, ok := dst.(validator); ok {
+		if errs := v.validate(); len(errs) > 0 {
+			problem.Validation(w, r, errs)
+			return false
+		}
+	}
+	return true
+}
+
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case isBodyTooLarge(err):
+		problem.Write(
+			w, r, http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge,
+			"request body must not exceed "+strconv.Itoa(maxJSONBodyBytes)+" bytes",
+		)
+	case errors.Is(err, io.EOF):
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is empty")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		problem.Validation(w, r, []problem.FieldError{{
+			Field:   typeErr.Field,
+			Code:    problem.FieldInvalidType,
+			Message: "must be " + jsonTypeName(typeErr.Type),
+		}})
+	case errors.As(err, &typeErr):
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body must be a json object")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// у encoding/json нет отдельного типа для этой ошибки, имя поля есть только в тексте
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		problem.Validation(w, r, []problem.FieldError{{
+			Field:   field,
+			Code:    problem.FieldUnknown,
+			Message: "unknown field",
+		}})
+	default:
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "invalid json body")
+	}
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// fieldErrors собирает ошибки полей в validate.
+type fieldErrors []problem.FieldError
+
+func (e *fieldErrors) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		*e = append(*e, problem.FieldError{Field: field, Code: problem.FieldRequired, Message: "is required"})
+	}
+}
+
+// date — обязательная дата в формате YYYY-MM-DD.
+func (e *fieldErrors) date(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		e.required(field, value)
+		return
+	}
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		*e = append(*e, problem.FieldError{
+			Field:   field,
+			Code:    problem.FieldInvalidFormat,
+			Message: "must be a date in YYYY-MM-DD format",
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"warranty_days/internal/httpapi/problem"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantField   problem.FieldError
+	}{
+		{
+			name:        "valid body",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"email":"a@example.com","password":"x"}`,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "form body",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "email=a&password=x",
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    problem.CodeUnsupportedMedia,
+		},
+		{
+			name:       "missing content type",
+			body:       `{"email":"a@example.com","password":"x"}`,
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantCode:   problem.CodeUnsupportedMedia,
+		},
+		{
+			name:        "body too large",
+			contentType: "application/json",
+			body:        `{"email":"` + strings.Repeat("a", maxJSONBodyBytes) + `"}`,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantCode:    problem.CodeBodyTooLarge,
+		},
+		{
+			// второе значение за пределом тела — все равно 413, а не invalid_json
+			name:        "trailing value over the limit",
+			contentType: "application/json",
+			body:        `{"email":"a@example.com","password":"x"}` + strings.Repeat(" ", maxJSONBodyBytes),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantCode:    problem.CodeBodyTooLarge,
+		},
+		{
+			name:        "unknown field",
+			contentType: "application/json",
+			body:        `{"email":"a@example.com","password":"x","remember":true}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    problem.CodeValidationFailed,
+			wantField:   problem.FieldError{Field: "remember", Code: problem.FieldUnknown},
+		},
+		{
+			name:        "trailing value",
+			contentType: "application/json",
+			body:        `{"email":"a@example.com","password":"x"} {}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    problem.CodeInvalidJSON,
+		},
+		{
+			name:        "trailing garbage",
+			contentType: "application/json",
+			body:        `{"email":"a@example.com","password":"x"}x`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    problem.CodeInvalidJSON,
+		},
+		{
+			name:        "empty body",
+			contentType: "application/json",
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    problem.CodeInvalidJSON,
+		},
+		{
+			name:        "wrong field type",
+			contentType: "application/json",
+			body:        `{"email":1,"password":"x"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    problem.CodeValidationFailed,
+			wantField:   problem.FieldError{Field: "email", Code: problem.FieldInvalidType},
+		},
+		{
+			name:        "required field",
+			contentType: "application/json",
+			body:        `{"email":" ","password":"x"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    problem.CodeValidationFailed,
+			wantField:   problem.FieldError{Field: "email", Code: problem.FieldRequired},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			var req loginRequest
+			if ok := decodeJSON(w, r, &req); ok != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("decodeJSON() = %v, response %d %s", ok, w.Code, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if w.Body.Len() != 0 || req.Email != "a@example.com" {
+					t.Fatalf("decoded %+v, response %q", req, w.Body)
+				}
+				return
+			}
+
+			var p problem.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("problem body: %v\n%s", err, w.Body)
+			}
+			if w.Code != tt.wantStatus || p.Code != tt.wantCode {
+				t.Fatalf("response %d %q, want %d %q", w.Code, p.Code, tt.wantStatus, tt.wantCode)
+			}
+			if tt.wantField.Field != "" {
+				if len(p.Errors) != 1 || p.Errors[0].Field != tt.wantField.Field || p.Errors[0].Code != tt.wantField.Code {
+					t.Fatalf("field errors = %+v, want %+v", p.Errors, tt.wantField)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// privateCacheControl: ответы зависят от пользователя — хранить их может только браузер,
+// и перед каждым использованием сверяться с сервером через If-None-Match.
+const privateCacheControl = "private, no-cache"
+
+// strongETag — сильный ETag из всего, от чего зависит тело ответа.
+func strongETag(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func setCacheHeaders(w http.ResponseWriter, etag string) {
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", privateCacheControl)
+	h.Add("Vary", "Authorization")
+}
+
+// notModified отвечает 304, если у клиента уже есть версия etag. Тогда ответ записан и хендлер выходит.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+
+	setCacheHeaders(w, etag)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches — слабое сравнение для If-None-Match (RFC 9110, 13.1.2): префикс W/ не учитывается.
+func etagMatches(ifNoneMatch, etag string) bool {
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+	if ifNoneMatch == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
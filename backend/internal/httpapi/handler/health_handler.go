@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"warranty_days/internal/buildinfo"
+	"warranty_days/internal/health"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+	logger  *slog.Logger
+}
+
+type liveResponse struct {
+	Status health.Status  `json:"status"`
+	Build  buildinfo.Info `json:"build"`
+}
+
+type readyResponse struct {
+	Status health.Status                 `json:"status"`
+	Checks map[string]health.CheckResult `json:"checks"`
+	Build  buildinfo.Info                `json:"build"`
+}
+
+func NewHealthHandler(checker *health.Checker, logger *slog.Logger) *HealthHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &HealthHandler{checker: checker, logger: logger}
+}
+
+// Live отвечает, что процесс жив. Зависимости не проверяет:
+// перезапуск инстанса не поможет, если недоступна БД.
+func (h *HealthHandler) Live(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, liveResponse{
+		Status: health.StatusOK,
+		Build:  buildinfo.Get(),
+	})
+}
+
+// Ready проверяет зависимости. 503 означает, что трафик на инстанс слать не нужно.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status == health.StatusFail {
+		status = http.StatusServiceUnavailable
+		h.logger.WarnContext(r.Context(), "readiness check failed", "checks", report.Checks)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, readyResponse{
+		Status: report.Status,
+		Checks: report.Checks,
+		Build:  buildinfo.Get(),
+	})
+}
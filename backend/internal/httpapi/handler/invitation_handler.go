@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/models"
+	"warranty_days/internal/service"
+)
+
+type InvitationHandler struct {
+	registrationSvc *service.RegistrationService
+	logger          *slog.Logger
+}
+
+type createInvitationRequest struct {
+	Email      string `json:"email"`
+	Role       string `json:"role"`
+	DealerCode string `json:"dealer_code"`
+}
+
+type invitationResponse struct {
+	ID         int64      `json:"id"`
+	Code       string     `json:"code,omitempty"`
+	Email      *string    `json:"email"`
+	Role       string     `json:"role"`
+	DealerCode *string    `json:"dealer_code"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func NewInvitationHandler(registrationSvc *service.RegistrationService, logger *slog.Logger) *InvitationHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &InvitationHandler{registrationSvc: registrationSvc, logger: logger}
+}
+
+func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req createInvitationRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	created, err := h.registrationSvc.CreateInvitation(
+		r.Context(),
+		user.UserID,
+		req.Email,
+		req.Role,
+		req.DealerCode,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidEmail):
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidEmail, "invalid email")
+			return
+		case errors.Is(err, service.ErrInvalidRole):
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRole, "invalid role")
+			return
+		default:
+			problem.Internal(w, r, h.logger, "failed to create invitation", err)
+			return
+		}
+	}
+
+	resp := toInvitationResponse(created.Invitation)
+	resp.Code = created.Code
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *InvitationHandler) List(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.registrationSvc.ListInvitations(r.Context())
+	if err != nil {
+		problem.Internal(w, r, h.logger, "failed to list invitations", err)
+		return
+	}
+
+	resp := make([]invitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		resp = append(resp, toInvitationResponse(invitation))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func toInvitationResponse(invitation models.Invitation) invitationResponse {
+	return invitationResponse{
+		ID:         invitation.ID,
+		Email:      invitation.Email,
+		Role:       invitation.Role,
+		DealerCode: invitation.DealerCode,
+		ExpiresAt:  invitation.ExpiresAt,
+		UsedAt:     invitation.UsedAt,
+		CreatedAt:  invitation.CreatedAt,
+	}
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/models"
+	"warranty_days/internal/service"
+)
+
+// jobRunsLimit — сколько последних запусков отдает история.
+const jobRunsLimit = 100
+
+type JobHandler struct {
+	jobSvc *service.JobService
+	logger *slog.Logger
+}
+
+type jobResponse struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Schedule    string          `json:"schedule"`
+	Paused      bool            `json:"paused"`
+	NextRunAt   *time.Time      `json:"next_run_at"`
+	LastRun     *jobRunResponse `json:"last_run"`
+}
+
+type jobRunResponse struct {
+	ID           int64            `json:"id"`
+	JobName      string           `json:"job_name"`
+	Source       string           `json:"source"`
+	Status       string           `json:"status"`
+	ScheduledFor *time.Time       `json:"scheduled_for"`
+	TriggeredBy  *int64           `json:"triggered_by"`
+	StartedAt    *time.Time       `json:"started_at"`
+	FinishedAt   *time.Time       `json:"finished_at"`
+	Result       *json.RawMessage `json:"result"`
+	Error        *string          `json:"error"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+func NewJobHandler(jobSvc *service.JobService, logger *slog.Logger) *JobHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &JobHandler{jobSvc: jobSvc, logger: logger}
+}
+
+func (h *JobHandler) List(w http.ResponseWriter, r *http.Request) {
+	infos, err := h.jobSvc.List(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp := make([]jobResponse, 0, len(infos))
+	for _, info := range infos {
+		resp = append(resp, toJobResponse(info))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Runs — история запусков задачи, новые первыми; ?status= фильтрует по статусу.
+func (h *JobHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.jobSvc.Runs(r.Context(), r.PathValue("name"), r.URL.Query().Get("status"), jobRunsLimit)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp := make([]jobRunResponse, 0, len(runs))
+	for _, run := range runs {
+		resp = append(resp, toJobRunResponse(run))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Trigger ставит ручной запуск в очередь и сразу отвечает 202: ход запуска виден в истории.
+func (h *JobHandler) Trigger(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	run, err := h.jobSvc.Trigger(r.Context(), r.PathValue("name"), user.UserID)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, toJobRunResponse(*run))
+}
+
+func (h *JobHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+func (h *JobHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+func (h *JobHandler) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	info, err := h.jobSvc.SetPaused(r.Context(), r.PathValue("name"), paused, user.UserID)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toJobResponse(*info))
+}
+
+func (h *JobHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
+		problem.Write(w, r, http.StatusNotFound, problem.CodeJobNotFound, "job not found")
+	case errors.Is(err, service.ErrJobRunInProgress):
+		problem.Write(w, r, http.StatusConflict, problem.CodeJobRunInProgress, "job already has a queued or running run")
+	case errors.Is(err, service.ErrInvalidJobRunStatus):
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeInvalidJobRunStatus,
+			"status must be queued, running, succeeded or failed",
+		)
+	default:
+		problem.Internal(w, r, h.logger, "job request failed", err)
+	}
+}
+
+func toJobResponse(info service.JobInfo) jobResponse {
+	resp := jobResponse{
+		Name:        info.Job.Name,
+		Description: info.Job.Description,
+		Schedule:    info.Job.Schedule,
+		Paused:      info.Paused,
+		NextRunAt:   info.NextRunAt,
+	}
+	if info.LastRun != nil {
+		lastRun := toJobRunResponse(*info.LastRun)
+		resp.LastRun = &lastRun
+	}
+	return resp
+}
+
+func toJobRunResponse(run models.JobRun) jobRunResponse {
+	resp := jobRunResponse{
+		ID:           run.ID,
+		JobName:      run.JobName,
+		Source:       run.Source,
+		Status:       run.Status,
+		ScheduledFor: run.ScheduledFor,
+		TriggeredBy:  run.TriggeredBy,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   run.FinishedAt,
+		Error:        run.Error,
+		CreatedAt:    run.CreatedAt,
+	}
+	if len(run.Result) > 0 {
+		resp.Result = &run.Result
+	}
+	return resp
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/models"
+	"warranty_days/internal/service"
+)
+
+type MFAHandler struct {
+	mfaSvc  *service.MFAService
+	authSvc *service.AuthService
+	cookies SessionCookies
+	logger  *slog.Logger
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (req mfaCodeRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("code", req.Code)
+	return errs
+}
+
+type mfaVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+func (req mfaVerifyRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("mfa_token", req.MFAToken)
+	errs.required("code", req.Code)
+	return errs
+}
+
+type mfaEnrollResponse struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURI    string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type mfaPolicyRequest struct {
+	Role     string `json:"role"`
+	Required *bool  `json:"required"`
+}
+
+func (req mfaPolicyRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("role", req.Role)
+	if req.Required == nil {
+		errs = append(errs, problem.FieldError{Field: "required", Code: problem.FieldRequired, Message: "is required"})
+	}
+	return errs
+}
+
+func NewMFAHandler(
+	mfaSvc *service.MFAService,
+	authSvc *service.AuthService,
+	cookies SessionCookies,
+	logger *slog.Logger,
+) *MFAHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &MFAHandler{mfaSvc: mfaSvc, authSvc: authSvc, cookies: cookies, logger: logger}
+}
+
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	enrollment, err := h.mfaSvc.Enroll(r.Context(), user.UserID)
+	if err != nil {
+		h.writeMFAError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mfaEnrollResponse{
+		Secret:        enrollment.Secret,
+		OTPAuthURI:    enrollment.OTPAuthURI,
+		RecoveryCodes: enrollment.RecoveryCodes,
+	})
+}
+
+// ConfirmEnrollment включает 2FA. Если настройка шла по челлендж-токену логина,
+// в ответе сразу выдаем полные токены.
+func (h *MFAHandler) ConfirmEnrollment(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	if !h.cookies.allowed(w, r) {
+		return
+	}
+
+	var req mfaCodeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	enrolled, err := h.mfaSvc.ConfirmEnrollment(r.Context(), user.UserID, req.Code)
+	if err != nil {
+		h.writeMFAError(w, r, err)
+		return
+	}
+
+	if user.TokenType != auth.TokenTypeMFAEnroll {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	tokens, err := h.authSvc.IssueTokens(enrolled)
+	if err != nil {
+		h.writeMFAError(w, r, err)
+		return
+	}
+	writeTokens(w, r, h.cookies, tokens)
+}
+
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req mfaCodeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.mfaSvc.Disable(r.Context(), user.UserID, req.Code); err != nil {
+		h.writeMFAError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify — второй шаг логина: mfa_token из /auth/login и код из приложения или код восстановления.
+func (h *MFAHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	if !h.cookies.allowed(w, r) {
+		return
+	}
+
+	var req mfaVerifyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.mfaSvc.VerifyLogin(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		h.writeMFAError(w, r, err)
+		return
+	}
+
+	writeTokens(w, r, h.cookies, tokens)
+}
+
+func (h *MFAHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.mfaSvc.ListPolicies(r.Context())
+	if err != nil {
+		h.writeMFAError(w, r, err)
+		return
+	}
+	if policies == nil {
+		policies = []models.MFARolePolicy{}
+	}
+
+	writeJSON(w, http.StatusOK, policies)
+}
+
+func (h *MFAHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req mfaPolicyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	policy, err := h.mfaSvc.SetPolicy(r.Context(), user.UserID, req.Role, *req.Required)
+	if err != nil {
+		h.writeMFAError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (h *MFAHandler) writeMFAError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFAToken):
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidMFAToken, "invalid or expired mfa token")
+	case errors.Is(err, service.ErrInvalidMFACode):
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidMFACode, "invalid two-factor authentication code")
+	case errors.Is(err, service.ErrMFAAttemptsLimit):
+		problem.Write(
+			w, r, http.StatusTooManyRequests, problem.CodeMFAAttemptsLimit,
+			"too many two-factor authentication attempts, try again later",
+		)
+	case errors.Is(err, service.ErrInvalidCredentials):
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidCredentials, "invalid credentials")
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		problem.Write(
+			w, r, http.StatusConflict, problem.CodeMFAAlreadyEnabled,
+			"two-factor authentication is already enabled",
+		)
+	case errors.Is(err, service.ErrMFANotEnrolled):
+		problem.Write(w, r, http.StatusConflict, problem.CodeMFANotEnrolled, "two-factor authentication is not enrolled")
+	case errors.Is(err, service.ErrMFARequiredByRole):
+		problem.Write(
+			w, r, http.StatusForbidden, problem.CodeMFARequiredByRole,
+			"two-factor authentication is required for your role",
+		)
+	case errors.Is(err, service.ErrEmailNotVerified):
+		problem.Write(
+			w, r, http.StatusForbidden, problem.CodeEmailNotVerified,
+			"email verification is pending, check your inbox",
+		)
+	case errors.Is(err, service.ErrUserInactive):
+		problem.Write(w, r, http.StatusForbidden, problem.CodeUserInactive, "user is inactive")
+	case errors.Is(err, service.ErrInvalidRole):
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRole, "invalid role")
+	default:
+		problem.Internal(w, r, h.logger, "mfa request failed", err)
+	}
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"warranty_days/internal/buildinfo"
+	"warranty_days/internal/events"
+	"warranty_days/internal/health"
+	"warranty_days/internal/httpapi/openapi"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/models"
+)
+
+// schemaDTOs связывает схемы из components.schemas с типами, которые реально кодируются/декодируются.
+var schemaDTOs = map[string]any{
+	"Problem":                   problem.Problem{},
+	"FieldError":                problem.FieldError{},
+	"RegisterRequest":           registerRequest{},
+	"VerifyEmailRequest":        verifyEmailRequest{},
+	"ResendVerificationRequest": resendVerificationRequest{},
+	"LoginRequest":              loginRequest{},
+	"RefreshRequest":            refreshRequest{},
+	"AuthTokens":                authTokensResponse{},
+	"MFAChallenge":              mfaChallengeResponse{},
+	"User":                      userResponse{},
+	"Claim":                     models.Claim{},
+	"WarrantyYear":              warrantyYearResponse{},
+	"WarrantyYearPeriod":        warrantyYearPeriodResponse{},
+	"WarrantyPeriod":            warrantyPeriodResponse{},
+	"WarrantyPeriodItem":        warrantyPeriodItem{},
+	"WarrantyPeriodClaim":       warrantyPeriodClaim{},
+	"BuildInfo":                 buildinfo.Info{},
+	"CheckResult":               health.CheckResult{},
+	"LiveResponse":              liveResponse{},
+	"ReadyResponse":             readyResponse{},
+	"CreateInvitationRequest":   createInvitationRequest{},
+	"Invitation":                invitationResponse{},
+	"MFACodeRequest":            mfaCodeRequest{},
+	"MFAVerifyRequest":          mfaVerifyRequest{},
+	"MFAEnrollment":             mfaEnrollResponse{},
+	"MFAPolicyRequest":          mfaPolicyRequest{},
+	"MFARolePolicy":             models.MFARolePolicy{},
+	"Profile":                   profileResponse{},
+	"Preferences":               preferencesResponse{},
+	"UpdateProfileRequest":      updateProfileRequest{},
+	"UpdatePreferencesRequest":  updatePreferencesRequest{},
+	"NotificationSetting":       notificationSettingResponse{},
+	"UpdateNotificationRequest": updateNotificationRequest{},
+	"ClaimRequest":              claimRequest{},
+	"WebhookRequest":            webhookRequest{},
+	"Webhook":                   webhookResponse{},
+	"WebhookDelivery":           webhookDeliveryResponse{},
+	"Job":                       jobResponse{},
+	"JobRun":                    jobRunResponse{},
+	"WebhookEvent":              events.Event{},
+	"ClaimEventData":            events.ClaimData{},
+	"ThresholdEventData":        events.ThresholdData{},
+}
+
+type specProperty struct {
+	Type json.RawMessage `json:"type"`
+	Ref  string          `json:"$ref"`
+}
+
+func (p specProperty) types() []string {
+	if p.Ref != "" {
+		return []string{"object"}
+	}
+
+	var single string
+	if err := json.Unmarshal(p.Type, &single); err == nil {
+		return []string{single}
+	}
+	var many []string
+	_ = json.Unmarshal(p.Type, &many)
+	return many
+}
+
+func TestOpenAPISchemasMatchDTOs(t *testing.T) {
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]specProperty `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(openapi.Spec(), &spec); err != nil {
+		t.Fatalf("openapi.json is not valid json: %v", err)
+	}
+
+	for name := range spec.Components.Schemas {
+		if _, ok := schemaDTOs[name]; !ok {
+			t.Errorf("schema %s has no DTO mapping in schemaDTOs", name)
+		}
+	}
+
+	for name, dto := range schemaDTOs {
+		schema, ok := spec.Components.Schemas[name]
+		if !ok {
+			t.Errorf("DTO %T is missing from openapi.json as schema %s", dto, name)
+			continue
+		}
+
+		fields := jsonFields(reflect.TypeOf(dto))
+		for field, fieldType := range fields {
+			prop, ok := schema.Properties[field]
+			if !ok {
+				t.Errorf("schema %s: field %q of %T is not documented", name, field, dto)
+				continue
+			}
+
+			want, nullable := jsonSchemaType(fieldType)
+			got := prop.types()
+			if !slices.Contains(got, want) {
+				t.Errorf("schema %s: field %q has type %v, DTO encodes %s", name, field, got, want)
+			}
+			if slices.Contains(got, "null") && !nullable {
+				t.Errorf("schema %s: field %q is nullable in spec, but DTO field is not a pointer", name, field)
+			}
+		}
+		for prop := range schema.Properties {
+			if _, ok := fields[prop]; !ok {
+				t.Errorf("schema %s: property %q does not exist in %T", name, prop, dto)
+			}
+		}
+	}
+}
+
+// Обязательные поля схемы запроса должны совпадать с тем, что отклоняет validate у пустого DTO.
+func TestOpenAPIRequiredMatchesValidation(t *testing.T) {
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Required []string `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(openapi.Spec(), &spec); err != nil {
+		t.Fatalf("openapi.json is not valid json: %v", err)
+	}
+
+	for name, dto := range schemaDTOs {
+		if !strings.HasSuffix(name, "Request") {
+			continue
+		}
+
+		var validated []string
+		if v, ok := dto.(validator); ok {
+			for _, fieldErr := range v.validate() {
+				validated = append(validated, fieldErr.Field)
+			}
+		}
+
+		required := spec.Components.Schemas[name].Required
+		slices.Sort(required)
+		slices.Sort(validated)
+		if !slices.Equal(required, validated) {
+			t.Errorf("schema %s: required %v, validate rejects empty %v", name, required, validated)
+		}
+	}
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func jsonSchemaType(t reflect.Type) (string, bool) {
+	nullable := false
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	if t == reflect.TypeFor[time.Time]() {
+		return "string", nullable
+	}
+	// json.RawMessage — уже готовый JSON, в схеме это вложенный объект
+	if t == reflect.TypeFor[json.RawMessage]() {
+		return "object", nullable
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", nullable
+	case reflect.Bool:
+		return "boolean", nullable
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", nullable
+	case reflect.Float32, reflect.Float64:
+		return "number", nullable
+	case reflect.Slice, reflect.Array:
+		return "array", nullable
+	default:
+		return "object", nullable
+	}
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/models"
+	"warranty_days/internal/service"
+)
+
+type ProfileHandler struct {
+	profileSvc *service.ProfileService
+	logger     *slog.Logger
+}
+
+type profileResponse struct {
+	ID              int64               `json:"id"`
+	Email           string              `json:"email"`
+	Role            string              `json:"role"`
+	DealerCode      *string             `json:"dealer_code"`
+	IsActive        bool                `json:"is_active"`
+	MFAEnabled      bool                `json:"mfa_enabled"`
+	EmailVerifiedAt *time.Time          `json:"email_verified_at"`
+	CreatedAt       time.Time           `json:"created_at"`
+	Preferences     preferencesResponse `json:"preferences"`
+}
+
+type preferencesResponse struct {
+	Timezone    string `json:"timezone"`
+	Language    string `json:"language"`
+	DefaultAsOf string `json:"default_as_of"`
+}
+
+type updateProfileRequest struct {
+	Preferences *updatePreferencesRequest `json:"preferences"`
+}
+
+type updatePreferencesRequest struct {
+	Timezone    *string `json:"timezone"`
+	Language    *string `json:"language"`
+	DefaultAsOf *string `json:"default_as_of"`
+}
+
+type notificationSettingResponse struct {
+	Type     string `json:"type"`
+	Enabled  bool   `json:"enabled"`
+	Required bool   `json:"required"`
+}
+
+type updateNotificationRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+func (req updateNotificationRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	if req.Enabled == nil {
+		errs = append(errs, problem.FieldError{Field: "enabled", Code: problem.FieldRequired, Message: "is required"})
+	}
+	return errs
+}
+
+func NewProfileHandler(profileSvc *service.ProfileService, logger *slog.Logger) *ProfileHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ProfileHandler{profileSvc: profileSvc, logger: logger}
+}
+
+func (h *ProfileHandler) Me(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	profile, err := h.profileSvc.GetProfile(r.Context(), user.UserID)
+	if err != nil {
+		h.writeProfileError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toProfileResponse(profile))
+}
+
+func (h *ProfileHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req updateProfileRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	var patch service.PreferencesPatch
+	if req.Preferences != nil {
+		patch = service.PreferencesPatch{
+			Timezone:    req.Preferences.Timezone,
+			Language:    req.Preferences.Language,
+			DefaultAsOf: req.Preferences.DefaultAsOf,
+		}
+	}
+
+	profile, err := h.profileSvc.UpdatePreferences(r.Context(), user.UserID, patch)
+	if err != nil {
+		h.writeProfileError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toProfileResponse(profile))
+}
+
+func (h *ProfileHandler) Notifications(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	settings, err := h.profileSvc.NotificationSettings(r.Context(), user.UserID, user.Role)
+	if err != nil {
+		h.writeProfileError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toNotificationSettingsResponse(settings))
+}
+
+func (h *ProfileHandler) UpdateNotification(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req updateNotificationRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	settings, err := h.profileSvc.SetNotification(
+		r.Context(), user.UserID, user.Role, r.PathValue("type"), *req.Enabled,
+	)
+	if err != nil {
+		h.writeProfileError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toNotificationSettingsResponse(settings))
+}
+
+func (h *ProfileHandler) writeProfileError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials):
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+	case errors.Is(err, service.ErrInvalidTimezone):
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeInvalidTimezone,
+			"invalid timezone, expected IANA name like Europe/Moscow",
+		)
+	case errors.Is(err, service.ErrInvalidLanguage):
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidLanguage, "invalid language, expected one of: ru, en")
+	case errors.Is(err, service.ErrInvalidDefaultAsOf):
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeInvalidAsOfMode,
+			"invalid default_as_of, expected one of: today, last_repair",
+		)
+	case errors.Is(err, service.ErrNotificationTypeNotFound):
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotificationTypeNotFound, "notification type not found")
+	case errors.Is(err, service.ErrNotificationNotAllowed):
+		problem.Write(
+			w, r, http.StatusForbidden, problem.CodeNotificationNotAllowed,
+			"this notification is not available for your role",
+		)
+	case errors.Is(err, service.ErrNotificationRequired):
+		problem.Write(
+			w, r, http.StatusConflict, problem.CodeNotificationRequired,
+			"this notification is required and cannot be disabled",
+		)
+	default:
+		problem.Internal(w, r, h.logger, "profile request failed", err)
+	}
+}
+
+func toProfileResponse(profile *service.Profile) profileResponse {
+	loc := service.PreferencesLocation(profile.Preferences)
+
+	var verifiedAt *time.Time
+	if profile.User.EmailVerifiedAt != nil {
+		t := profile.User.EmailVerifiedAt.In(loc)
+		verifiedAt = &t
+	}
+
+	return profileResponse{
+		ID:              profile.User.ID,
+		Email:           profile.User.Email,
+		Role:            profile.User.Role,
+		DealerCode:      profile.User.DealerCode,
+		IsActive:        profile.User.IsActive,
+		MFAEnabled:      profile.User.MFAEnabled(),
+		EmailVerifiedAt: verifiedAt,
+		CreatedAt:       profile.User.CreatedAt.In(loc),
+		Preferences:     toPreferencesResponse(profile.Preferences),
+	}
+}
+
+func toPreferencesResponse(prefs models.UserPreferences) preferencesResponse {
+	return preferencesResponse{
+		Timezone:    prefs.Timezone,
+		Language:    prefs.Language,
+		DefaultAsOf: prefs.DefaultAsOf,
+	}
+}
+
+func toNotificationSettingsResponse(settings []service.NotificationSetting) []notificationSettingResponse {
+	resp := make([]notificationSettingResponse, 0, len(settings))
+	for _, setting := range settings {
+		resp = append(resp, notificationSettingResponse{
+			Type:     setting.Type,
+			Enabled:  setting.Enabled,
+			Required: setting.Required,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/service"
+)
+
+const (
+	// TokenDeliveryHeader: "cookie" — браузерный режим, refresh-токен в HttpOnly cookie, а не в теле.
+	TokenDeliveryHeader = "X-Token-Delivery"
+	TokenDeliveryCookie = "cookie"
+
+	// CSRFHeader — сюда клиент копирует csrf_token из ответа логина/refresh (double-submit cookie).
+	CSRFHeader = "X-CSRF-Token"
+
+	RefreshCookieName = "wd_refresh"
+	CSRFCookieName    = "wd_csrf"
+)
+
+// refresh-cookie уходит только на /api/v1/auth/refresh, CSRF-cookie — на все /api/v1/auth (refresh и logout).
+// Устаревшие пути без префикса их не получают, поэтому там браузерный режим отклоняется (см. allowed).
+const (
+	refreshCookiePath = APIV1Prefix + "/auth/refresh"
+	csrfCookiePath    = APIV1Prefix + "/auth"
+)
+
+// SessionCookies — настройки браузерного режима выдачи токенов.
+type SessionCookies struct {
+	Enabled  bool
+	Secure   bool
+	SameSite http.SameSite
+	Domain   string
+	// TTL — срок жизни cookie, равен сроку refresh-токена.
+	TTL time.Duration
+}
+
+// cookieMode — клиент просит браузерный режим заголовком X-Token-Delivery: cookie.
+func cookieMode(r *http.Request) bool {
+	return r.Header.Get(TokenDeliveryHeader) == TokenDeliveryCookie
+}
+
+// allowed пишет 400, если клиент просит браузерный режим, а он выключен: молча отдать refresh-токен
+// в теле клиенту, который ждет cookie, хуже явной ошибки. На устаревших путях без /api/v1 режим тоже
+// не работает: cookie привязаны к версионированным путям, и /auth/refresh их бы не получил.
+// Вызывается до обращения к сервисам.
+func (c SessionCookies) allowed(w http.ResponseWriter, r *http.Request) bool {
+	if !cookieMode(r) {
+		return true
+	}
+	if !c.Enabled {
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeCookieModeDisabled,
+			"cookie token delivery is disabled on this server",
+		)
+		return false
+	}
+	if !strings.HasPrefix(r.URL.Path, APIV1Prefix+"/") {
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeCookieModeRequiresV1,
+			"cookie token delivery is only available under "+APIV1Prefix,
+		)
+		return false
+	}
+	return true
+}
+
+// writeTokens отдает пару токенов: в JSON-режиме обе в теле, в браузерном — access-токен и CSRF-токен
+// в теле, refresh-токен в HttpOnly cookie.
+func writeTokens(w http.ResponseWriter, r *http.Request, cookies SessionCookies, tokens *service.TokenPair) {
+	resp := authTokensResponse{
+		AccessToken: tokens.AccessToken,
+		TokenType:   "Bearer",
+	}
+
+	if cookies.Enabled && cookieMode(r) {
+		csrfToken, err := auth.NewOpaqueToken()
+		if err != nil {
+			problem.Internal(w, r, nil, "failed to generate csrf token", err)
+			return
+		}
+		cookies.set(w, tokens.RefreshToken, csrfToken)
+		resp.CSRFToken = csrfToken
+	} else {
+		resp.RefreshToken = tokens.RefreshToken
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (c SessionCookies) set(w http.ResponseWriter, refreshToken, csrfToken string) {
+	maxAge := int(c.TTL.Seconds())
+	http.SetCookie(w, c.cookie(RefreshCookieName, refreshToken, refreshCookiePath, maxAge, true))
+	http.SetCookie(w, c.cookie(CSRFCookieName, csrfToken, csrfCookiePath, maxAge, false))
+}
+
+func (c SessionCookies) clear(w http.ResponseWriter) {
+	http.SetCookie(w, c.cookie(RefreshCookieName, "", refreshCookiePath, -1, true))
+	http.SetCookie(w, c.cookie(CSRFCookieName, "", csrfCookiePath, -1, false))
+}
+
+// CSRF-cookie не HttpOnly: фронтенд на том же сайте читает ее из document.cookie. Фронтенд на другом
+// сайте cookie не видит и берет тот же токен из тела ответа.
+func (c SessionCookies) cookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.Domain,
+		MaxAge:   maxAge,
+		Secure:   c.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.SameSite,
+	}
+}
+
+// checkCSRF сверяет заголовок X-CSRF-Token с CSRF-cookie. Подделанный запрос с другого сайта
+// cookie отправит, но прочитать ее и повторить в заголовке не сможет.
+func checkCSRF(w http.ResponseWriter, r *http.Request) bool {
+	cookie, err := r.Cookie(CSRFCookieName)
+	header := r.Header.Get(CSRFHeader)
+	if err != nil || cookie.Value == "" || header == "" ||
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+		problem.Write(w, r, http.StatusForbidden, problem.CodeCSRFTokenMismatch, "missing or invalid csrf token")
+		return false
+	}
+	return true
+}
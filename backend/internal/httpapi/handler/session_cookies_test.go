@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"warranty_days/internal/service"
+)
+
+func TestWriteTokensCookieMode(t *testing.T) {
+	cookies := SessionCookies{Enabled: true, Secure: true, SameSite: http.SameSiteStrictMode, TTL: time.Hour}
+	tokens := &service.TokenPair{AccessToken: "access", RefreshToken: "refresh"}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+	r.Header.Set(TokenDeliveryHeader, TokenDeliveryCookie)
+	w := httptest.NewRecorder()
+	writeTokens(w, r, cookies, tokens)
+
+	var body authTokensResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	// refresh-токен в теле не отдается: JavaScript его видеть не должен
+	if body.RefreshToken != "" || body.AccessToken != "access" || body.CSRFToken == "" {
+		t.Fatalf("body = %+v, want access and csrf tokens only", body)
+	}
+
+	got := map[string]*http.Cookie{}
+	for _, c := range w.Result().Cookies() {
+		got[c.Name] = c
+	}
+	refresh, csrf := got[RefreshCookieName], got[CSRFCookieName]
+	if refresh == nil || refresh.Value != "refresh" || !refresh.HttpOnly || !refresh.Secure ||
+		refresh.Path != "/api/v1/auth/refresh" || refresh.SameSite != http.SameSiteStrictMode || refresh.MaxAge != 3600 {
+		t.Fatalf("refresh cookie = %+v", refresh)
+	}
+	// CSRF-cookie читает фронтенд, значение то же, что в теле
+	if csrf == nil || csrf.Value != body.CSRFToken || csrf.HttpOnly || csrf.Path != "/api/v1/auth" {
+		t.Fatalf("csrf cookie = %+v, body csrf %q", csrf, body.CSRFToken)
+	}
+}
+
+func TestCheckCSRF(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   bool
+	}{
+		{name: "matching", cookie: "token", header: "token", want: true},
+		{name: "mismatch", cookie: "token", header: "other"},
+		{name: "no header", cookie: "token"},
+		{name: "no cookie", header: "token"},
+		{name: "both empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set(CSRFHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			if got := checkCSRF(w, r); got != tt.want {
+				t.Fatalf("checkCSRF() = %v, want %v", got, tt.want)
+			}
+			if !tt.want && w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"warranty_days/internal/repo"
+	"warranty_days/internal/service"
+)
+
+const (
+	claimsStampSQL = `SELECT COUNT\(\*\) AS count, MAX\(updated_at\) AS max_updated_at FROM "claims"`
+	listClaimsSQL  = `SELECT \* FROM "claims" WHERE LOWER\(vin\) = LOWER\(\$1\)`
+	retailDateSQL  = `SELECT "retail_date" FROM "claims"`
+	yearClaimsSQL  = `SELECT \* FROM "claims" WHERE LOWER\(vin\) = LOWER\(\$1\) AND \(ro_open_date`
+	testVIN        = "VIN1"
+)
+
+var testUpdatedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newMockClaimsService — ClaimsService поверх sqlmock, без настроек пользователя (userID 0 — значения по умолчанию).
+// Запросы сверяются по порядку; в конце теста все ожидания должны быть выполнены.
+func newMockClaimsService(t *testing.T) (*service.ClaimsService, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		_ = sqlDB.Close()
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service.NewClaimsService(repo.NewClaimRepo(db), repo.NewTxManager(db), nil, nil), mock
+}
+
+// expectStamp ждет проверку версии заявок VIN: count заявок, последняя изменена в updatedAt.
+func expectStamp(mock sqlmock.Sqlmock, count int, updatedAt time.Time) {
+	expectVINStamp(mock, testVIN, count, updatedAt)
+}
+
+func expectVINStamp(mock sqlmock.Sqlmock, vin string, count int, updatedAt time.Time) {
+	mock.ExpectQuery(claimsStampSQL).
+		WithArgs(vin).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "max_updated_at"}).AddRow(count, updatedAt))
+}
+
+// claimRows — одна заявка VIN, купленного 2025-01-10, с ремонтом 2025-06-01..2025-06-05.
+func claimRows(updatedAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "vin", "retail_date", "ro_open_date", "ro_close_date", "updated_at"}).
+		AddRow(1, testVIN, time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC), updatedAt)
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/models"
+	"warranty_days/internal/service"
+)
+
+// webhookDeliveriesLimit — сколько последних доставок отдает журнал.
+const webhookDeliveriesLimit = 100
+
+type WebhookHandler struct {
+	webhookSvc *service.WebhookService
+	logger     *slog.Logger
+}
+
+type webhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+func (req webhookRequest) validate() []problem.FieldError {
+	var errs fieldErrors
+	errs.required("url", req.URL)
+	if len(req.EventTypes) == 0 {
+		errs = append(errs, problem.FieldError{Field: "event_types", Code: problem.FieldRequired, Message: "is required"})
+	}
+	return errs
+}
+
+func (req webhookRequest) input() service.WebhookInput {
+	return service.WebhookInput{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		IsActive:    req.IsActive,
+	}
+}
+
+type webhookResponse struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	EventTypes  []string  `json:"event_types"`
+	IsActive    bool      `json:"is_active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedBy   *int64    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type webhookDeliveryResponse struct {
+	ID             int64      `json:"id"`
+	SubscriptionID int64      `json:"subscription_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code"`
+	LastError      *string    `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func NewWebhookHandler(webhookSvc *service.WebhookService, logger *slog.Logger) *WebhookHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &WebhookHandler{webhookSvc: webhookSvc, logger: logger}
+}
+
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req webhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	created, err := h.webhookSvc.Create(r.Context(), user.UserID, req.input())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp := toWebhookResponse(created.Subscription)
+	resp.Secret = created.Secret
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhookSvc.List(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp := make([]webhookResponse, 0, len(subs))
+	for _, sub := range subs {
+		resp = append(resp, toWebhookResponse(sub))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		h.writeError(w, r, service.ErrWebhookNotFound)
+		return
+	}
+
+	sub, err := h.webhookSvc.Get(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toWebhookResponse(*sub))
+}
+
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		h.writeError(w, r, service.ErrWebhookNotFound)
+		return
+	}
+
+	var req webhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	sub, err := h.webhookSvc.Update(r.Context(), id, req.input())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toWebhookResponse(*sub))
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		h.writeError(w, r, service.ErrWebhookNotFound)
+		return
+	}
+
+	if err := h.webhookSvc.Delete(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries — журнал доставок подписки, новые первыми; ?status= фильтрует по статусу.
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		h.writeError(w, r, service.ErrWebhookNotFound)
+		return
+	}
+
+	deliveries, err := h.webhookSvc.Deliveries(r.Context(), id, r.URL.Query().Get("status"), webhookDeliveriesLimit)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, toWebhookDeliveryResponse(delivery))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Redeliver возвращает доставку в очередь — обычно из dead после того, как получатель починили.
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, okID := pathID(r, "id")
+	deliveryID, okDelivery := pathID(r, "delivery_id")
+	if !okID || !okDelivery {
+		h.writeError(w, r, service.ErrWebhookDeliveryNotFound)
+		return
+	}
+
+	delivery, err := h.webhookSvc.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, toWebhookDeliveryResponse(*delivery))
+}
+
+func (h *WebhookHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		problem.Write(w, r, http.StatusNotFound, problem.CodeWebhookNotFound, "webhook subscription not found")
+	case errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		problem.Write(w, r, http.StatusNotFound, problem.CodeWebhookDeliveryNotFound, "webhook delivery not found")
+	case errors.Is(err, service.ErrInvalidWebhookURL):
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeInvalidWebhookURL,
+			"url must be an absolute http or https url",
+		)
+	case errors.Is(err, service.ErrInvalidEventType):
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidEventType, "unknown event type")
+	case errors.Is(err, service.ErrInvalidDeliveryStatus):
+		problem.Write(
+			w, r, http.StatusBadRequest, problem.CodeInvalidDeliveryStatus,
+			"status must be pending, delivered or dead",
+		)
+	default:
+		problem.Internal(w, r, h.logger, "webhook request failed", err)
+	}
+}
+
+func toWebhookResponse(sub models.WebhookSubscription) webhookResponse {
+	return webhookResponse{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		Description: sub.Description,
+		EventTypes:  sub.EventTypes,
+		IsActive:    sub.IsActive,
+		CreatedBy:   sub.CreatedBy,
+		CreatedAt:   sub.CreatedAt,
+		UpdatedAt:   sub.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery models.WebhookDelivery) webhookDeliveryResponse {
+	return webhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
@@ -2,17 +2,33 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"warranty_days/internal/auth"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/logging"
+)
+
+var (
+	ErrAuthNotConfigured = errors.New("auth is not configured")
+	ErrMissingToken      = errors.New("missing or invalid authorization header")
+	ErrInvalidToken      = errors.New("invalid access token")
 )
 
 type userContextKey struct{}
 
 type UserContext struct {
-	UserID int64
-	Email  string
+	UserID    int64
+	Email     string
+	Role      string
+	TokenType string
+	// ExpiresAt — когда истекает токен; долгие ответы (поток SSE) не переживают его.
+	ExpiresAt time.Time
 }
 
 type AccessTokenValidator interface {
@@ -20,30 +36,93 @@ type AccessTokenValidator interface {
 }
 
 func Auth(jwtSvc AccessTokenValidator, next http.Handler) http.Handler {
+	return AuthTokenTypes(jwtSvc, []string{auth.TokenTypeAccess}, next)
+}
+
+// AuthTokenTypes — как Auth, но принимает любой из перечисленных типов токена.
+// Нужен для шагов логина, которые выполняются до выдачи access-токена (настройка 2FA).
+func AuthTokenTypes(jwtSvc AccessTokenValidator, tokenTypes []string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if jwtSvc == nil {
-			http.Error(w, "auth is not configured", http.StatusInternalServerError)
+		user, err := Authenticate(jwtSvc, r.Header.Get("Authorization"), tokenTypes)
+		switch {
+		case errors.Is(err, ErrAuthNotConfigured):
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "auth is not configured")
+			return
+		case errors.Is(err, ErrMissingToken):
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "missing or invalid Authorization header")
+			return
+		case err != nil:
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid access token")
 			return
 		}
 
-		token, ok := extractBearerToken(r.Header.Get("Authorization"))
+		logging.SetUserID(r.Context(), user.UserID)
+		next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), user)))
+	})
+}
+
+// Authenticate проверяет значение заголовка Authorization ("Bearer <token>") и тип токена.
+// Общая проверка для HTTP и gRPC (метаданные authorization).
+func Authenticate(jwtSvc AccessTokenValidator, authorization string, tokenTypes []string) (UserContext, error) {
+	if jwtSvc == nil {
+		return UserContext{}, ErrAuthNotConfigured
+	}
+
+	token, ok := extractBearerToken(authorization)
+	if !ok {
+		return UserContext{}, ErrMissingToken
+	}
+
+	claims, err := jwtSvc.ParseAndValidate(token, "")
+	if err != nil || !slices.Contains(tokenTypes, claims.TokenType) {
+		return UserContext{}, ErrInvalidToken
+	}
+
+	user := UserContext{
+		UserID:    claims.UserID,
+		Email:     claims.Email,
+		Role:      claims.Role,
+		TokenType: claims.TokenType,
+	}
+	if claims.ExpiresAt != nil {
+		user.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return user, nil
+}
+
+func ContextWithUser(ctx context.Context, user UserContext) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// RequireRole пропускает только пользователей с одной из ролей. Ставится после Auth.
+func RequireRole(roles []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
 		if !ok {
-			http.Error(w, "missing or invalid Authorization header", http.StatusUnauthorized)
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
 			return
 		}
-
-		claims, err := jwtSvc.ParseAndValidate(token, auth.TokenTypeAccess)
-		if err != nil {
-			http.Error(w, "invalid access token", http.StatusUnauthorized)
+		if !slices.Contains(roles, user.Role) {
+			problem.Write(w, r, http.StatusForbidden, problem.CodeForbidden, "forbidden")
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		ctx := context.WithValue(r.Context(), userContextKey{}, UserContext{
-			UserID: claims.UserID,
-			Email:  claims.Email,
-		})
-
-		next.ServeHTTP(w, r.WithContext(ctx))
+// StaticToken пускает только запросы с "Authorization: Bearer <token>". Для служебных маршрутов
+// вроде /metrics, которые опрашивает не пользователь, а сборщик с заранее выданным токеном.
+func StaticToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := extractBearerToken(r.Header.Get("Authorization"))
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
 
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBrowser(t *testing.T) {
+	policy := BrowserPolicy{
+		CORS: CORSPolicy{
+			// в конфиге origin может быть записан в любом регистре
+			AllowedOrigins:   []string{"https://App.Example.com"},
+			AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			ExposedHeaders:   []string{"ETag"},
+			AllowCredentials: true,
+			MaxAge:           10 * time.Minute,
+		},
+		Security: SecurityPolicy{
+			HSTSMaxAge:            365 * 24 * time.Hour,
+			HSTSIncludeSubdomains: true,
+			FrameOptions:          "DENY",
+			ContentSecurityPolicy: "default-src 'none'",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		// wantNext — запрос дошел до маршрута, а не получил ответ от middleware
+		wantNext   bool
+		wantStatus int
+		want       map[string]string
+	}{
+		{
+			name:   "preflight from allowed origin",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  http.MethodPost,
+				"Access-Control-Request-Headers": "authorization",
+			},
+			wantStatus: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+				"Access-Control-Max-Age":           "600",
+				"Access-Control-Expose-Headers":    "",
+			},
+		},
+		{
+			// origin сравнивается без учета регистра
+			name:   "preflight from allowed origin in upper case",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://APP.example.com",
+				"Access-Control-Request-Method": http.MethodGet,
+			},
+			wantStatus: http.StatusNoContent,
+			want:       map[string]string{"Access-Control-Allow-Origin": "https://APP.example.com"},
+		},
+		{
+			name:   "preflight from unknown origin",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": http.MethodGet,
+			},
+			wantStatus: http.StatusForbidden,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+				"Content-Type":                 "application/problem+json",
+			},
+		},
+		{
+			name:       "actual request from allowed origin",
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://app.example.com"},
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": "ETag",
+				"Access-Control-Allow-Methods":  "",
+			},
+		},
+		{
+			// чужой origin получает ответ без CORS-заголовков: прочитать его браузер не даст
+			name:       "actual request from unknown origin",
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://evil.example.com"},
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+			want:       map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:       "plain options is not preflight",
+			method:     http.MethodOptions,
+			headers:    map[string]string{"Origin": "https://app.example.com"},
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+		},
+		{name: "same-origin request", method: http.MethodGet, wantNext: true, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reached bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				reached = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(tt.method, "/api/v1/claims", nil)
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			Browser(policy, next).ServeHTTP(w, r)
+
+			if reached != tt.wantNext || w.Code != tt.wantStatus {
+				t.Fatalf("reached handler = %v, status %d; want %v, %d", reached, w.Code, tt.wantNext, tt.wantStatus)
+			}
+			for key, want := range tt.want {
+				if got := w.Header().Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+			// ответ зависит от Origin — кэши должны это учитывать
+			if vary := strings.Join(w.Header().Values("Vary"), ", "); !strings.Contains(vary, "Origin") {
+				t.Errorf("Vary = %q, want Origin", vary)
+			}
+
+			// заголовки безопасности есть у любого ответа, в том числе у отказа middleware
+			security := map[string]string{
+				"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+				"X-Frame-Options":           "DENY",
+				"X-Content-Type-Options":    "nosniff",
+				"Referrer-Policy":           "no-referrer",
+				"Content-Security-Policy":   "default-src 'none'",
+			}
+			for key, want := range security {
+				if got := w.Header().Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/claims", nil)
+	r.Header.Set("Origin", "https://any.example.com")
+
+	tests := []struct {
+		name        string
+		credentials bool
+		want        string
+	}{
+		{name: "without credentials", want: "*"},
+		// с credentials браузер не примет "*", поэтому отражаем конкретный origin
+		{name: "with credentials", credentials: true, want: "https://any.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			CORS(CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: tt.credentials}, next).ServeHTTP(w, r)
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersWithoutHSTS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	w := httptest.NewRecorder()
+	SecurityHeaders(SecurityPolicy{}, next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for _, key := range []string{"Strict-Transport-Security", "X-Frame-Options", "Content-Security-Policy"} {
+		if got := w.Header().Get(key); got != "" {
+			t.Errorf("%s = %q, want no header when not configured", key, got)
+		}
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+}
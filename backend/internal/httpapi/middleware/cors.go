@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"warranty_days/internal/httpapi/problem"
+)
+
+// CORSPolicy — какие сторонние origin могут вызывать API из браузера. Без AllowedOrigins CORS выключен:
+// браузер с другого origin не прочитает ни один ответ.
+type CORSPolicy struct {
+	// AllowedOrigins — точные origin вида https://app.example.com или "*" (только без AllowCredentials).
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge — сколько браузер кэширует ответ на preflight.
+	MaxAge time.Duration
+}
+
+// allows ждет AllowedOrigins в нижнем регистре — их приводит CORS при создании middleware.
+func (p CORSPolicy) allows(origin string) bool {
+	return slices.Contains(p.AllowedOrigins, "*") || slices.Contains(p.AllowedOrigins, strings.ToLower(origin))
+}
+
+// CORS отвечает на preflight-запросы (OPTIONS с Access-Control-Request-Method) до маршрутизации
+// и добавляет заголовки Access-Control-* к ответам для разрешенных origin. Запросы с чужих origin
+// обрабатываются как обычно, просто без этих заголовков — прочитать ответ браузер не даст.
+func CORS(policy CORSPolicy, next http.Handler) http.Handler {
+	if len(policy.AllowedOrigins) == 0 {
+		return next
+	}
+	// origin сравнивается без учета регистра; свой срез, чтобы не менять конфиг вызывающего
+	origins := make([]string, len(policy.AllowedOrigins))
+	for i, o := range policy.AllowedOrigins {
+		origins[i] = strings.ToLower(o)
+	}
+	policy.AllowedOrigins = origins
+
+	methods := strings.Join(policy.AllowedMethods, ", ")
+	headers := strings.Join(policy.AllowedHeaders, ", ")
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.FormatInt(int64(policy.MaxAge.Seconds()), 10)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !policy.allows(origin) {
+			if preflight {
+				problem.Write(w, r, http.StatusForbidden, problem.CodeOriginNotAllowed, "origin is not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if slices.Contains(policy.AllowedOrigins, "*") && !policy.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposed != "" {
+				h.Set("Access-Control-Expose-Headers", exposed)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.Set("Access-Control-Allow-Methods", methods)
+		if headers != "" {
+			h.Set("Access-Control-Allow-Headers", headers)
+		}
+		if policy.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
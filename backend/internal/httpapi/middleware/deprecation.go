@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Deprecation описывает вывод из эксплуатации старых путей API (без префикса версии).
+// Нулевые DeprecatedAt и Sunset — дата не объявлена, соответствующий заголовок не отдается.
+type Deprecation struct {
+	Enabled      bool
+	DeprecatedAt time.Time
+	Sunset       time.Time
+}
+
+// Deprecated добавляет заголовки Deprecation (RFC 9745), Sunset (RFC 8594) и ссылку на путь-преемник.
+func Deprecated(policy Deprecation, successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetDeprecationHeaders(w.Header(), policy, successor)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func SetDeprecationHeaders(h http.Header, policy Deprecation, successor string) {
+	if !policy.DeprecatedAt.IsZero() {
+		h.Set("Deprecation", "@"+strconv.FormatInt(policy.DeprecatedAt.Unix(), 10))
+	}
+	if !policy.Sunset.IsZero() {
+		h.Set("Sunset", policy.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if successor != "" {
+		h.Set("Link", "<"+successor+`>; rel="successor-version"`)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSetDeprecationHeaders(t *testing.T) {
+	deprecatedAt := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy Deprecation
+		want   map[string]string
+	}{
+		{
+			name:   "announced",
+			policy: Deprecation{Enabled: true, DeprecatedAt: deprecatedAt, Sunset: sunset},
+			want: map[string]string{
+				"Deprecation": "@1792368000",
+				"Sunset":      "Mon, 19 Apr 2027 00:00:00 GMT",
+				"Link":        `</api/v1/claims>; rel="successor-version"`,
+			},
+		},
+		{
+			// дата не объявлена — клиенту не сообщаем выдуманную, остается только ссылка на v1
+			name:   "dates not set",
+			policy: Deprecation{Enabled: true},
+			want: map[string]string{
+				"Deprecation": "",
+				"Sunset":      "",
+				"Link":        `</api/v1/claims>; rel="successor-version"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			SetDeprecationHeaders(h, tt.policy, "/api/v1/claims")
+			for key, want := range tt.want {
+				if got := h.Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
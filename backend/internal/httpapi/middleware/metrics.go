@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"warranty_days/internal/metrics"
+)
+
+// Metrics считает запросы и латентность по шаблону маршрута ServeMux (r.Pattern), а не по сырому пути.
+func Metrics(m *metrics.Metrics, next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startedAt := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		m.RequestStarted()
+		next.ServeHTTP(rec, r)
+
+		// ServeMux проставляет Pattern в тот же *http.Request при выборе обработчика
+		m.RequestFinished(r.Method, r.Pattern, rec.status, time.Since(startedAt))
+	})
+}
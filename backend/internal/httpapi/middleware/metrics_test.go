@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"warranty_days/internal/metrics"
+)
+
+func TestMetricsLabelsByRoutePattern(t *testing.T) {
+	m := metrics.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/webhooks/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := Metrics(m, mux)
+
+	for _, path := range []string{"/api/v1/webhooks/1", "/api/v1/webhooks/2", "/no/such/route"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	// id из пути в метки не попадает, неизвестные пути собираются в одну метку
+	for _, want := range []string{
+		`warranty_days_http_requests_total{method="GET",route="GET /api/v1/webhooks/{id}",status="404"} 2`,
+		`warranty_days_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`warranty_days_http_requests_in_flight 0`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+	if strings.Contains(body, "/api/v1/webhooks/1") {
+		t.Errorf("raw path leaked into metrics labels")
+	}
+}
+
+func TestStaticToken(t *testing.T) {
+	handler := StaticToken("secret", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{name: "valid token", authorization: "Bearer secret", want: http.StatusOK},
+		{name: "wrong token", authorization: "Bearer other", want: http.StatusUnauthorized},
+		{name: "token prefix", authorization: "Bearer secre", want: http.StatusUnauthorized},
+		{name: "no header", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Fatalf("WWW-Authenticate = %q, want Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
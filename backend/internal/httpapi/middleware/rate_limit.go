@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/ratelimit"
+)
+
+// RateLimits — лимиты запросов по группам маршрутов. Без Limiter ограничение выключено.
+type RateLimits struct {
+	Limiter ratelimit.Limiter
+	// Fallback — считает лимиты, пока Limiter возвращает ошибку (например, недоступна БД).
+	// Без Fallback такие запросы пропускаются без ограничения.
+	Fallback ratelimit.Limiter
+	// Groups — лимит для каждой группы маршрутов; группа без лимита не ограничивается.
+	Groups map[string]ratelimit.Limit
+	// TrustedProxies — адреса прокси, которым доверяем X-Forwarded-For при определении IP клиента.
+	TrustedProxies []netip.Prefix
+	Logger         *slog.Logger
+}
+
+// RateLimit ограничивает запросы группы маршрутов: аутентифицированных — по пользователю, остальных — по IP.
+// Корзина общая для всех маршрутов группы. Ставится после Auth, чтобы пользователь уже был в контексте.
+func RateLimit(policy RateLimits, group string, next http.Handler) http.Handler {
+	limit := policy.Groups[group]
+	if policy.Limiter == nil || !limit.Enabled() {
+		return next
+	}
+
+	logger := policy.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := group + ":" + rateLimitSubject(r, policy.TrustedProxies)
+
+		res, err := policy.Limiter.Allow(r.Context(), key, limit)
+		if err != nil && policy.Fallback != nil {
+			logger.WarnContext(r.Context(), "rate limiter failed, using fallback", "group", group, "error", err)
+			res, err = policy.Fallback.Allow(r.Context(), key, limit)
+		}
+		if err != nil {
+			// считать лимит негде — пропускаем запрос, а не роняем API
+			logger.WarnContext(r.Context(), "rate limiter failed, request allowed", "group", group, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		setRateLimitHeaders(w.Header(), res)
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(res.RetryAfter), 10))
+			problem.Write(w, r, http.StatusTooManyRequests, problem.CodeRateLimited, "too many requests")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setRateLimitHeaders — заголовки RateLimit-* по draft-ietf-httpapi-ratelimit-headers.
+func setRateLimitHeaders(h http.Header, res ratelimit.Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit.Requests))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(res.ResetAfter), 10))
+	h.Set("RateLimit-Policy", strconv.Itoa(res.Limit.Requests)+";w="+strconv.FormatInt(ceilSeconds(res.Limit.Per), 10))
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+func rateLimitSubject(r *http.Request, trustedProxies []netip.Prefix) string {
+	if user, ok := UserFromContext(r.Context()); ok {
+		return "user:" + strconv.FormatInt(user.UserID, 10)
+	}
+	return "ip:" + ClientIP(r, trustedProxies)
+}
+
+// ClientIP — адрес клиента. X-Forwarded-For читается только если запрос пришел от доверенного прокси,
+// и справа налево до первого адреса не из trustedProxies: левые элементы клиент может подделать.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	ip := peer.Addr().Unmap()
+	if !isTrustedProxy(ip, trustedProxies) {
+		return ip.String()
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = hop.Unmap()
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+func isTrustedProxy(ip netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"warranty_days/internal/ratelimit"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		trusted    []netip.Prefix
+		want       string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5000", trusted: proxies, want: "203.0.113.7"},
+		{
+			name:       "untrusted peer header ignored",
+			remoteAddr: "203.0.113.7:5000",
+			forwarded:  []string{"198.51.100.1"},
+			trusted:    proxies,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "no trusted proxies configured",
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "client behind trusted proxy",
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  []string{"198.51.100.1"},
+			trusted:    proxies,
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  []string{"198.51.100.1, 10.0.0.5, 10.0.0.3"},
+			trusted:    proxies,
+			want:       "198.51.100.1",
+		},
+		// клиент сам дописал адрес в начало заголовка — берем первый недоверенный справа
+		{
+			name:       "spoofed leftmost hop",
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  []string{"1.1.1.1, 198.51.100.1"},
+			trusted:    proxies,
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "several header lines",
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  []string{"198.51.100.1", "10.0.0.3"},
+			trusted:    proxies,
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "garbage hop stops the walk",
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  []string{"198.51.100.1, not-an-ip, 10.0.0.3"},
+			trusted:    proxies,
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "only trusted hops",
+			remoteAddr: "10.0.0.2:5000",
+			forwarded:  []string{"10.0.0.4"},
+			trusted:    proxies,
+			want:       "10.0.0.4",
+		},
+		{name: "trusted proxy without header", remoteAddr: "10.0.0.2:5000", trusted: proxies, want: "10.0.0.2"},
+		{
+			name:       "ipv6 proxy",
+			remoteAddr: "[fd00::1]:5000",
+			forwarded:  []string{"2001:db8::7"},
+			trusted:    proxies,
+			want:       "2001:db8::7",
+		},
+		{
+			name:       "ipv4-mapped peer",
+			remoteAddr: "[::ffff:10.0.0.2]:5000",
+			forwarded:  []string{"198.51.100.1"},
+			trusted:    proxies,
+			want:       "198.51.100.1",
+		},
+		{name: "unparsable remote addr", remoteAddr: "pipe", trusted: proxies, want: "pipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/claims", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := ClientIP(r, tt.trusted); got != tt.want {
+				t.Fatalf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitFallsBackToMemoryWhenDBFails(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.MatchExpectationsInOrder(false)
+	for range 2 {
+		mock.ExpectQuery(`INSERT INTO rate_limit_buckets`).WillReturnError(errors.New("connection refused"))
+	}
+	// фоновая чистка старых корзин тоже не проходит — на ответ это не влияет
+	mock.ExpectExec(`DELETE FROM rate_limit_buckets`).WillReturnError(errors.New("connection refused"))
+
+	discard := slog.New(slog.DiscardHandler)
+	handler := RateLimit(RateLimits{
+		Limiter:  ratelimit.NewPostgresLimiter(db, time.Second, discard),
+		Fallback: ratelimit.NewMemoryLimiter(),
+		Groups:   map[string]ratelimit.Limit{"auth": {Requests: 1, Per: time.Minute}},
+		Logger:   discard,
+	}, "auth", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// лимит продолжает действовать по корзинам в памяти, а не выключается вместе с БД
+	for _, want := range []int{http.StatusNoContent, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil))
+		if w.Code != want || w.Header().Get("RateLimit-Limit") != "1" {
+			t.Fatalf("status = %d, RateLimit-Limit %q; want %d from the fallback limiter",
+				w.Code, w.Header().Get("RateLimit-Limit"), want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"warranty_days/internal/httpapi/problem"
+)
+
+// Recovery перехватывает панику в хендлере и отвечает 500 problem+json вместо обрыва соединения.
+// http.ErrAbortHandler пробрасывается дальше: это штатный способ прервать ответ.
+func Recovery(logger *slog.Logger, next http.Handler) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			err := fmt.Errorf("panic: %v", recovered)
+			if rec.wroteHeader {
+				// ответ уже начат — остается только записать в лог
+				logger.ErrorContext(r.Context(), "panic recovered", "error", err, "stack", string(debug.Stack()))
+				return
+			}
+			problem.Internal(rec, r, logger, "panic recovered", err, "stack", string(debug.Stack()))
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
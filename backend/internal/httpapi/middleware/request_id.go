@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"warranty_days/internal/logging"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestID берет X-Request-ID клиента или прокси (или генерирует новый), возвращает его в ответе
+// и кладет в контекст для логов. Ставится самым внешним middleware.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := ResolveRequestID(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, requestID)
+
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// ResolveRequestID возвращает входящий ID, если он безопасен для логов и заголовков, иначе новый.
+func ResolveRequestID(incoming string) string {
+	if isValidRequestID(incoming) {
+		return incoming
+	}
+	return newRequestID()
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"warranty_days/internal/logging"
+)
+
+func TestRequestID(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	tests := []struct {
+		name     string
+		incoming string
+		// wantSame — входящий ID возвращается как есть; иначе ждем сгенерированный
+		wantSame bool
+	}{
+		{name: "incoming id echoed", incoming: "req-123.abc:1_x", wantSame: true},
+		{name: "max length id echoed", incoming: strings.Repeat("a", maxRequestIDLength), wantSame: true},
+		{name: "missing id generated"},
+		{name: "header injection replaced", incoming: "bad id\r\nSet-Cookie: x=1"},
+		{name: "non-ascii replaced", incoming: "запрос-1"},
+		{name: "too long id replaced", incoming: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inContext string
+			next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				inContext = logging.RequestIDFromContext(r.Context())
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/claims", nil)
+			if tt.incoming != "" {
+				r.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			RequestID(next).ServeHTTP(w, r)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got != inContext {
+				t.Fatalf("response id %q, context id %q; want the same", got, inContext)
+			}
+			if tt.wantSame && got != tt.incoming {
+				t.Fatalf("response id %q, want incoming %q", got, tt.incoming)
+			}
+			if !tt.wantSame && !generated.MatchString(got) {
+				t.Fatalf("response id %q, want generated 32 hex chars", got)
+			}
+		})
+	}
+}
+
+func TestRequestIDGeneratesUniqueIDs(t *testing.T) {
+	seen := map[string]bool{}
+	for range 100 {
+		id := ResolveRequestID("")
+		if seen[id] {
+			t.Fatalf("duplicate generated id %q", id)
+		}
+		seen[id] = true
+	}
+}
@@ -9,14 +9,39 @@ import (
 
 type statusRecorder struct {
 	http.ResponseWriter
-	status int
+	status      int
+	wroteHeader bool
 }
 
 func (w *statusRecorder) WriteHeader(status int) {
-	w.status = status
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
 	w.ResponseWriter.WriteHeader(status)
 }
 
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap дает http.ResponseController добраться до исходного writer (Flush, дедлайны).
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// FlushError — для http.ResponseController: ошибка сброса доходит до хендлера.
+func (w *statusRecorder) FlushError() error {
+	w.wroteHeader = true
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Flush нужен writer-ам, которые сами приводят обернутый writer к http.Flusher (Gin).
+func (w *statusRecorder) Flush() {
+	_ = w.FlushError()
+}
+
 func RequestLogging(logger *slog.Logger, next http.Handler) http.Handler {
 	if logger == nil {
 		logger = slog.Default()
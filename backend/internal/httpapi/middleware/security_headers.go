@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SecurityPolicy — заголовки безопасности для браузера, одинаковые для всех ответов.
+type SecurityPolicy struct {
+	// HSTSMaxAge — 0 выключает Strict-Transport-Security (например, если TLS не на этом сервисе).
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// FrameOptions — DENY или SAMEORIGIN.
+	FrameOptions string
+	// ContentSecurityPolicy — для ответов API; HTML-страницы (Swagger UI) ставят свою политику поверх.
+	ContentSecurityPolicy string
+}
+
+// BrowserPolicy — все, что нужно фронтенду на другом origin: CORS и заголовки безопасности.
+type BrowserPolicy struct {
+	CORS     CORSPolicy
+	Security SecurityPolicy
+}
+
+// Browser оборачивает роутер целиком: preflight-запросы не доходят до маршрутов, а заголовки
+// безопасности есть и у 404/405.
+func Browser(policy BrowserPolicy, next http.Handler) http.Handler {
+	return SecurityHeaders(policy.Security, CORS(policy.CORS, next))
+}
+
+// SecurityHeaders выставляет заголовки до вызова хендлера: хендлер может заменить их своими.
+func SecurityHeaders(policy SecurityPolicy, next http.Handler) http.Handler {
+	var hsts string
+	if policy.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(policy.HSTSMaxAge.Seconds()), 10)
+		if policy.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", "no-referrer")
+		if hsts != "" {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		if policy.FrameOptions != "" {
+			h.Set("X-Frame-Options", policy.FrameOptions)
+		}
+		if policy.ContentSecurityPolicy != "" {
+			h.Set("Content-Security-Policy", policy.ContentSecurityPolicy)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
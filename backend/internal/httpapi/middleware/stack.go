@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"warranty_days/internal/metrics"
+)
+
+// Stack — общий набор middleware для обоих роутеров, снаружи внутрь: request ID, трейсинг, метрики,
+// лог запроса, recovery. Роутер должен проставить r.Pattern (ServeMux делает это сам), иначе маршрут
+// в метриках и трейсах будет "unmatched".
+func Stack(m *metrics.Metrics, logger *slog.Logger, next http.Handler) http.Handler {
+	return RequestID(Tracing(Metrics(m, RequestLogging(logger, Recovery(logger, next)))))
+}
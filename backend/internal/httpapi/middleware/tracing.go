@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"warranty_days/internal/tracing"
+)
+
+// Tracing открывает серверный спан на запрос; должен стоять снаружи остальных middleware,
+// чтобы логи и метрики видели контекст спана, а ServeMux проставил Pattern в тот же запрос.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.StartServerSpan(r)
+		r = r.WithContext(ctx)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		tracing.EndServerSpan(span, r.Method, routePath(r.Pattern), rec.status)
+	})
+}
+
+// routePath — шаблон ServeMux без метода: "GET /claims" -> "/claims". Метод уже есть в имени спана,
+// а http.route по семантике OpenTelemetry — только путь.
+func routePath(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
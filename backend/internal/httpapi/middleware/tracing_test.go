@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	var handlerSpan trace.SpanContext
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/7", nil)
+	r.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	Tracing(mux).ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	// спан продолжает трейс вызывающего и виден обработчику через контекст
+	if span.SpanContext().TraceID().String() != traceID || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("span trace %s parent %s, want trace %s", span.SpanContext().TraceID(), span.Parent().SpanID(), traceID)
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Fatalf("handler saw span %s, want %s", handlerSpan.SpanID(), span.SpanContext().SpanID())
+	}
+	// имя по шаблону маршрута, без id из пути
+	if span.Name() != "GET /api/v1/webhooks/{id}" {
+		t.Fatalf("span name = %q", span.Name())
+	}
+	for _, attr := range span.Attributes() {
+		if attr.Key == semconv.HTTPRouteKey && attr.Value.AsString() != "/api/v1/webhooks/{id}" {
+			t.Fatalf("http.route = %q, want the path template", attr.Value.AsString())
+		}
+	}
+	if span.Status().Code != codes.Error {
+		t.Fatalf("span status = %v, want error for 500", span.Status())
+	}
+}
@@ -0,0 +1,45 @@
+// Package openapi serves the OpenAPI 3.1 specification and Swagger UI
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/swaggest/swgui/v5emb"
+)
+
+const (
+	SpecPath = "/openapi.json"
+	DocsPath = "/docs/"
+)
+
+// docsCSP — Swagger UI встраивает inline-скрипт и стили и рисует иконки через data:, поэтому политика
+// мягче, чем у ответов API. Внешних источников нет: статика встроена в бинарник.
+const docsCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"
+
+// Спецификация поддерживается вручную; тесты в router и handler сверяют ее с маршрутами и DTO.
+//
+//go:embed openapi.json
+var spec []byte
+
+func Spec() []byte {
+	return spec
+}
+
+func SpecHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(spec)
+	})
+}
+
+// UIHandler отдает Swagger UI со встроенной статикой (без CDN) по префиксу DocsPath.
+func UIHandler() http.Handler {
+	ui := v5emb.New("WARRANTY_DAYS API", SpecPath, DocsPath)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", docsCSP)
+		ui.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,74 @@
+package problem
+
+// Стабильные коды ошибок: на них опирается фронтенд, менять и переиспользовать нельзя.
+const (
+	CodeInternal         = "internal_error"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeInvalidJSON      = "invalid_json"
+	CodeValidationFailed = "validation_failed"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeBodyTooLarge     = "body_too_large"
+	CodeUnauthorized     = "unauthorized"
+	CodeInvalidToken     = "invalid_token"
+	CodeForbidden        = "forbidden"
+	CodeRateLimited      = "rate_limited"
+	CodeOriginNotAllowed = "origin_not_allowed"
+
+	CodeVINRequired       = "vin_required"
+	CodeInvalidAsOf       = "invalid_as_of"
+	CodeClaimsNotFound    = "claims_not_found"
+	CodeClaimNotFound     = "claim_not_found"
+	CodeInvalidClaimDates = "invalid_claim_dates"
+
+	CodeInvalidEmail             = "invalid_email"
+	CodeInvalidRole              = "invalid_role"
+	CodeWeakPassword             = "weak_password"
+	CodeEmailAlreadyExists       = "email_already_exists"
+	CodeInviteRequired           = "invite_required"
+	CodeInvalidInvitation        = "invalid_invitation"
+	CodeEmailDomainNotAllowed    = "email_domain_not_allowed"
+	CodeInvalidVerificationToken = "invalid_verification_token"
+	CodeInvalidCredentials       = "invalid_credentials"
+	CodeInvalidRefreshToken      = "invalid_refresh_token"
+	CodeEmailNotVerified         = "email_not_verified"
+	CodeUserInactive             = "user_inactive"
+	CodeCookieModeDisabled       = "cookie_mode_disabled"
+	CodeCookieModeRequiresV1     = "cookie_mode_requires_v1"
+	CodeCSRFTokenMismatch        = "csrf_token_mismatch"
+
+	CodeMFAEnrollRequired = "mfa_enroll_required"
+	CodeInvalidMFAToken   = "invalid_mfa_token"
+	CodeInvalidMFACode    = "invalid_mfa_code"
+	CodeMFAAlreadyEnabled = "mfa_already_enabled"
+	CodeMFANotEnrolled    = "mfa_not_enrolled"
+	CodeMFARequiredByRole = "mfa_required_by_role"
+	CodeMFAAttemptsLimit  = "mfa_attempts_exceeded"
+
+	CodeInvalidTimezone = "invalid_timezone"
+	CodeInvalidLanguage = "invalid_language"
+	CodeInvalidAsOfMode = "invalid_default_as_of"
+
+	CodeNotificationTypeNotFound = "notification_type_not_found"
+	CodeNotificationRequired     = "notification_required"
+	CodeNotificationNotAllowed   = "notification_not_allowed"
+
+	CodeWebhookNotFound         = "webhook_not_found"
+	CodeWebhookDeliveryNotFound = "webhook_delivery_not_found"
+	CodeInvalidWebhookURL       = "invalid_webhook_url"
+	CodeInvalidEventType        = "invalid_event_type"
+	CodeInvalidDeliveryStatus   = "invalid_delivery_status"
+
+	CodeJobNotFound         = "job_not_found"
+	CodeJobRunInProgress    = "job_run_in_progress"
+	CodeInvalidJobRunStatus = "invalid_job_run_status"
+)
+
+// Коды ошибок отдельных полей в Problem.Errors.
+const (
+	FieldRequired    = "required"
+	FieldInvalidType = "invalid_type"
+	FieldUnknown     = "unknown_field"
+	// FieldInvalidFormat — тип верный, но значение не разбирается (например, дата не в формате YYYY-MM-DD).
+	FieldInvalidFormat = "invalid_format"
+)
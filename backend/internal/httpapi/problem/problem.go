@@ -0,0 +1,76 @@
+// Package problem writes RFC 7807 application/problem+json error responses
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"warranty_days/internal/logging"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem — единый формат ошибки API. Type всегда about:blank, поэтому Title — стандартный текст статуса;
+// различать ошибки клиенту нужно по Code, а Detail — текст для человека, он может меняться.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors — ошибки по полям тела запроса (code validation_failed).
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError — ошибка одного поля. Field — путь в JSON через точку, например "preferences.timezone".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func New(r *http.Request, status int, code, detail string) Problem {
+	p := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.RequestID = logging.RequestIDFromContext(r.Context())
+	}
+	return p
+}
+
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	WriteProblem(w, New(r, status, code, detail))
+}
+
+// Validation отвечает 400 validation_failed со списком ошибок по полям.
+func Validation(w http.ResponseWriter, r *http.Request, errs []FieldError) {
+	p := New(r, http.StatusBadRequest, CodeValidationFailed, "request body has invalid fields")
+	p.Errors = errs
+	WriteProblem(w, p)
+}
+
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// Internal пишет ошибку в лог целиком, а клиенту отдает общий 500 — найти запись в логах можно по request_id.
+func Internal(w http.ResponseWriter, r *http.Request, logger *slog.Logger, msg string, err error, args ...any) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.ErrorContext(r.Context(), msg, append(args, "path", r.URL.Path, "error", err)...)
+
+	Write(w, r, http.StatusInternalServerError, CodeInternal, "internal error")
+}
@@ -0,0 +1,75 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"warranty_days/internal/logging"
+)
+
+func TestWrite(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/claims?vin=", nil)
+	r = r.WithContext(logging.WithRequestID(r.Context(), "req-1"))
+	w := httptest.NewRecorder()
+
+	Write(w, r, http.StatusBadRequest, CodeVINRequired, "vin query param is required")
+
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("response %d %q, want 400 %q", w.Code, w.Header().Get("Content-Type"), ContentType)
+	}
+	var got Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	// instance — путь без query: параметры могут содержать то, чему не место в логах клиента
+	want := Problem{
+		Type:      "about:blank",
+		Title:     "Bad Request",
+		Status:    http.StatusBadRequest,
+		Code:      CodeVINRequired,
+		Detail:    "vin query param is required",
+		Instance:  "/api/v1/claims",
+		RequestID: "req-1",
+	}
+	if got.Type != want.Type || got.Title != want.Title || got.Status != want.Status || got.Code != want.Code ||
+		got.Detail != want.Detail || got.Instance != want.Instance || got.RequestID != want.RequestID {
+		t.Fatalf("problem = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+	w := httptest.NewRecorder()
+
+	Validation(w, r, []FieldError{{Field: "email", Code: FieldRequired, Message: "is required"}})
+
+	var got Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || got.Code != CodeValidationFailed || len(got.Errors) != 1 ||
+		got.Errors[0].Field != "email" || got.Errors[0].Code != FieldRequired {
+		t.Fatalf("response %d, problem %+v", w.Code, got)
+	}
+}
+
+func TestInternalHidesError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/claims", nil)
+	w := httptest.NewRecorder()
+
+	err := errors.New("pq: password authentication failed")
+	Internal(w, r, slog.New(slog.DiscardHandler), "claims request failed", err)
+
+	var got Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	// текст ошибки уходит только в лог
+	if w.Code != http.StatusInternalServerError || got.Code != CodeInternal || got.Detail != "internal error" {
+		t.Fatalf("response %d, problem %+v", w.Code, got)
+	}
+}
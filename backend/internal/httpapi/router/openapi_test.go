@@ -0,0 +1,142 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"warranty_days/internal/httpapi/handler"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/openapi"
+	"warranty_days/internal/httpapi/routes"
+	ginrouter "warranty_days/internal/httpapi_gin/router"
+	"warranty_days/internal/metrics"
+)
+
+// служебные маршруты, которые не описываются в спецификации
+var undocumentedPatterns = map[string]bool{
+	"/":              true,
+	openapi.DocsPath: true,
+}
+
+type recordingRegistrar struct {
+	patterns []string
+}
+
+func (r *recordingRegistrar) Handle(pattern string, _ http.Handler) {
+	r.patterns = append(r.patterns, pattern)
+}
+
+func (r *recordingRegistrar) HandleFunc(pattern string, _ func(http.ResponseWriter, *http.Request)) {
+	r.patterns = append(r.patterns, pattern)
+}
+
+// specOperations возвращает "METHOD /path" для всех операций openapi.json.
+func specOperations(t *testing.T) map[string]bool {
+	t.Helper()
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec(), &spec); err != nil {
+		t.Fatalf("openapi.json is not valid json: %v", err)
+	}
+
+	ops := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range item {
+			// параметры пути, общие для всех операций, — не операция
+			if method == "parameters" {
+				continue
+			}
+			ops[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	return ops
+}
+
+// testTable — таблица маршрутов без устаревших алиасов: в спецификации описан только /api/v1.
+func testTable() []routes.Route {
+	return routes.Table(
+		routes.Handlers{
+			Claims:      &handler.ClaimsHandler{},
+			ClaimStream: &handler.ClaimStreamHandler{},
+			Auth:        &handler.AuthHandler{},
+			Invitation:  &handler.InvitationHandler{},
+			MFA:         &handler.MFAHandler{},
+			Profile:     &handler.ProfileHandler{},
+			ClaimWrite:  &handler.ClaimWriteHandler{},
+			Webhooks:    &handler.WebhookHandler{},
+			Jobs:        &handler.JobHandler{},
+			Health:      &handler.HealthHandler{},
+			Metrics:     metrics.New(),
+		},
+		nil,
+		middleware.Deprecation{},
+		middleware.RateLimits{},
+	)
+}
+
+func TestOpenAPIMatchesMuxRoutes(t *testing.T) {
+	rec := &recordingRegistrar{}
+	registerRoutes(rec, testTable())
+
+	specPaths := make(map[string]bool)
+	for op := range specOperations(t) {
+		_, path, _ := strings.Cut(op, " ")
+		specPaths[path] = true
+	}
+
+	registered := make(map[string]bool)
+	for _, pattern := range rec.patterns {
+		if undocumentedPatterns[pattern] {
+			continue
+		}
+		registered[pattern] = true
+		if !specPaths[pattern] {
+			t.Errorf("route %s is registered in router.NewMux but missing from openapi.json", pattern)
+		}
+	}
+	for path := range specPaths {
+		if !registered[path] {
+			t.Errorf("openapi.json documents %s, but router.NewMux does not register it", path)
+		}
+	}
+}
+
+func TestOpenAPIMatchesGinRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := ginrouter.NewEngine(testTable())
+
+	ops := specOperations(t)
+	registered := make(map[string]bool)
+	for _, route := range engine.Routes() {
+		if strings.HasPrefix(route.Path, openapi.DocsPath) {
+			continue
+		}
+		op := route.Method + " " + muxPath(route.Path)
+		registered[op] = true
+		if !ops[op] {
+			t.Errorf("route %s is registered in ginrouter.NewEngine but missing from openapi.json", op)
+		}
+	}
+	for op := range ops {
+		if !registered[op] {
+			t.Errorf("openapi.json documents %s, but ginrouter.NewEngine does not register it", op)
+		}
+	}
+}
+
+// muxPath переводит параметры пути Gin (:id) обратно в синтаксис спецификации ({id}).
+func muxPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -4,43 +4,69 @@ package router
 import (
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
-	"warranty_days/internal/httpapi/handler"
 	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/httpapi/routes"
 )
 
 func NewMux(
-	claimsHandler *handler.ClaimsHandler,
-	authHandler *handler.AuthHandler,
+	handlers routes.Handlers,
 	jwtSvc middleware.AccessTokenValidator,
+	legacyRoutes middleware.Deprecation,
+	rateLimits middleware.RateLimits,
+	browser middleware.BrowserPolicy,
 	logger *slog.Logger,
 ) http.Handler {
 	mux := http.NewServeMux()
+	registerRoutes(mux, routes.Table(handlers, jwtSvc, legacyRoutes, rateLimits))
 
-	// public routes
-	mux.HandleFunc("/health", claimsHandler.Health)
-	mux.Handle("/auth/register", method(http.MethodPost, http.HandlerFunc(authHandler.Register)))
-	mux.Handle("/auth/login", method(http.MethodPost, http.HandlerFunc(authHandler.Login)))
-	mux.Handle("/auth/refresh", method(http.MethodPost, http.HandlerFunc(authHandler.Refresh)))
-
-	// protected routes
-	mux.Handle(
-		"/claims",
-		middleware.Auth(jwtSvc, method(http.MethodGet, http.HandlerFunc(claimsHandler.GetClaimsByVIN))),
-	)
-	mux.Handle(
-		"/claims/warranty-year",
-		middleware.Auth(jwtSvc, method(http.MethodGet, http.HandlerFunc(claimsHandler.GetWarrantyYearClaims))),
-	)
-
-	return middleware.RequestLogging(logger, mux)
+	return middleware.Stack(handlers.Metrics, logger, middleware.Browser(browser, mux))
 }
 
-func method(allowed string, next http.Handler) http.Handler {
+// routeRegistrar — то, что нужно от *http.ServeMux при регистрации; тест подставляет свой и собирает маршруты.
+type routeRegistrar interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+func registerRoutes(mux routeRegistrar, table []routes.Route) {
+	// все, что не совпало с маршрутами ниже, — 404 в формате problem+json вместо текста ServeMux
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// шаблон "/" ловит любые пути — в метриках и трейсах это "unmatched", как в Gin
+		r.Pattern = ""
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "route not found")
+	})
+
+	// ServeMux матчит по пути, метод проверяем сами, чтобы 405 был в формате problem+json
+	var paths []string
+	byPath := make(map[string]map[string]http.Handler)
+	for _, route := range table {
+		if byPath[route.Path] == nil {
+			byPath[route.Path] = make(map[string]http.Handler)
+			paths = append(paths, route.Path)
+		}
+		byPath[route.Path][route.Method] = route.Handler
+	}
+	for _, path := range paths {
+		mux.Handle(path, methods(byPath[path]))
+	}
+}
+
+func methods(handlers map[string]http.Handler) http.Handler {
+	allowed := make([]string, 0, len(handlers))
+	for m := range handlers {
+		allowed = append(allowed, m)
+	}
+	slices.Sort(allowed)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != allowed {
-			w.Header().Set("Allow", allowed)
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		next, ok := handlers[r.Method]
+		if !ok {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "method not allowed")
 			return
 		}
 		next.ServeHTTP(w, r)
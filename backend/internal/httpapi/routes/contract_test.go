@@ -0,0 +1,836 @@
+package routes_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/events"
+	"warranty_days/internal/health"
+	"warranty_days/internal/httpapi/handler"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/httpapi/router"
+	"warranty_days/internal/httpapi/routes"
+	ginrouter "warranty_days/internal/httpapi_gin/router"
+	"warranty_days/internal/metrics"
+	"warranty_days/internal/models"
+	"warranty_days/internal/ratelimit"
+	"warranty_days/internal/scheduler"
+	"warranty_days/internal/service"
+)
+
+// заголовки, которые должны совпадать у обоих серверов
+var contractHeaders = []string{
+	"Content-Type",
+	"Cache-Control",
+	"Allow",
+	"WWW-Authenticate",
+	"Deprecation",
+	"Sunset",
+	"Link",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"RateLimit-Policy",
+	"Retry-After",
+	"Vary",
+	"Access-Control-Allow-Origin",
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Expose-Headers",
+	"Access-Control-Max-Age",
+	"Strict-Transport-Security",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"Content-Security-Policy",
+	"Referrer-Policy",
+	"Set-Cookie",
+	middleware.RequestIDHeader,
+}
+
+type scenario struct {
+	name   string
+	method string
+	path   string
+	token  string
+	body   string
+	// contentType — Content-Type тела; по умолчанию application/json
+	contentType string
+	headers     map[string]string
+	// status — ожидаемый код, чтобы оба сервера не совпали в одинаково неверном ответе
+	status int
+	// code — если задан, ответ должен быть application/problem+json с этим code
+	code string
+}
+
+type response struct {
+	status  int
+	headers http.Header
+	body    []byte
+}
+
+// Сервисы в хендлерах не заданы: сценарии не доходят до БД. Где хендлер все же лезет в сервис,
+// он паникует — это проверяет recovery. newLimits вызывается для каждого сервера: корзины у них раздельные.
+func contractServers(
+	t *testing.T,
+	jwtSvc *auth.JWTService,
+	newLimits func() middleware.RateLimits,
+	cookies handler.SessionCookies,
+) map[string]http.Handler {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	logger := slog.New(slog.DiscardHandler)
+	// планировщик без задач и без БД: ошибки проверки имени задачи и фильтра видны без сервиса
+	jobScheduler, err := scheduler.New(scheduler.Config{}, nil, nil, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := routes.Handlers{
+		Claims: handler.NewClaimsHandler(nil, handler.ClaimsViewV1{}, nil, logger),
+		ClaimStream: handler.NewClaimStreamHandler(
+			nil,
+			handler.ClaimsViewV1{},
+			events.NewBus(),
+			handler.StreamConfig{Heartbeat: time.Second, MaxDuration: time.Minute, WriteTimeout: time.Second},
+			logger,
+		),
+		Auth:       handler.NewAuthHandler(nil, nil, nil, cookies, logger),
+		Invitation: handler.NewInvitationHandler(nil, logger),
+		MFA:        handler.NewMFAHandler(nil, nil, cookies, logger),
+		Profile:    handler.NewProfileHandler(nil, logger),
+		ClaimWrite: handler.NewClaimWriteHandler(nil, logger),
+		Webhooks:   handler.NewWebhookHandler(nil, logger),
+		Jobs:       handler.NewJobHandler(service.NewJobService(nil, jobScheduler), logger),
+		Health:     handler.NewHealthHandler(health.NewChecker(time.Second, logger), logger),
+		// тело /metrics у серверов разное, поэтому сценарии проверяют только отказ без токена
+		Metrics:      metrics.New(),
+		MetricsToken: "contract-metrics-token",
+	}
+	legacy := middleware.Deprecation{
+		Enabled:      true,
+		DeprecatedAt: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Sunset:       time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC),
+	}
+	browser := middleware.BrowserPolicy{
+		CORS: middleware.CORSPolicy{
+			AllowedOrigins:   []string{"https://app.example.com"},
+			AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			ExposedHeaders:   []string{"ETag", middleware.RequestIDHeader},
+			AllowCredentials: true,
+			MaxAge:           10 * time.Minute,
+		},
+		Security: middleware.SecurityPolicy{
+			HSTSMaxAge:            365 * 24 * time.Hour,
+			FrameOptions:          "DENY",
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		},
+	}
+
+	return map[string]http.Handler{
+		"net/http": router.NewMux(handlers, jwtSvc, legacy, newLimits(), browser, logger),
+		"gin":      ginrouter.NewHandler(handlers, jwtSvc, legacy, newLimits(), browser, logger),
+	}
+}
+
+func TestContractNetHTTPAndGin(t *testing.T) {
+	jwtSvc := auth.NewJWTService("contract-test-secret-contract-test-secret", "warranty_days", time.Hour, time.Hour)
+	userToken, err := jwtSvc.GenerateAccessToken(1, "user@example.com", models.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enrollToken, err := jwtSvc.GenerateMFAChallengeToken(1, "user@example.com", auth.TokenTypeMFAEnroll, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminToken, err := jwtSvc.GenerateAccessToken(2, "admin@example.com", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []scenario{
+		{name: "health", method: http.MethodGet, path: "/health", status: http.StatusOK},
+		{name: "live", method: http.MethodGet, path: "/health/live", status: http.StatusOK},
+		{name: "ready without checks", method: http.MethodGet, path: "/health/ready", status: http.StatusOK},
+		{
+			name:   "health wrong method",
+			method: http.MethodPost,
+			path:   "/health",
+			status: http.StatusMethodNotAllowed,
+			code:   problem.CodeMethodNotAllowed,
+		},
+		{name: "openapi spec", method: http.MethodGet, path: "/openapi.json", status: http.StatusOK},
+		{
+			name:   "metrics without token",
+			method: http.MethodGet,
+			path:   "/metrics",
+			status: http.StatusUnauthorized,
+			code:   problem.CodeUnauthorized,
+		},
+		{
+			name:   "metrics with access token",
+			method: http.MethodGet,
+			path:   "/metrics",
+			token:  userToken,
+			status: http.StatusUnauthorized,
+			code:   problem.CodeUnauthorized,
+		},
+		{
+			name:   "unknown route",
+			method: http.MethodGet,
+			path:   "/nope",
+			status: http.StatusNotFound,
+			code:   problem.CodeNotFound,
+		},
+		{
+			name:   "trailing slash",
+			method: http.MethodGet,
+			path:   "/api/v1/claims/",
+			status: http.StatusNotFound,
+			code:   problem.CodeNotFound,
+		},
+		{
+			name:   "no token",
+			method: http.MethodGet,
+			path:   "/api/v1/claims?vin=X",
+			status: http.StatusUnauthorized,
+			code:   problem.CodeUnauthorized,
+		},
+		{
+			name:   "invalid token",
+			method: http.MethodGet,
+			path:   "/api/v1/claims?vin=X",
+			token:  "not-a-jwt",
+			status: http.StatusUnauthorized,
+			code:   problem.CodeInvalidToken,
+		},
+		{
+			name:   "enroll token on access route",
+			method: http.MethodGet,
+			path:   "/api/v1/auth/me",
+			token:  enrollToken,
+			status: http.StatusUnauthorized,
+			code:   problem.CodeInvalidToken,
+		},
+		{
+			name:   "wrong method before auth",
+			method: http.MethodPost,
+			path:   "/api/v1/claims",
+			status: http.StatusMethodNotAllowed,
+			code:   problem.CodeMethodNotAllowed,
+		},
+		{
+			name:   "wrong method on multi-method route",
+			method: http.MethodDelete,
+			path:   "/api/v1/admin/invitations",
+			token:  userToken,
+			status: http.StatusMethodNotAllowed,
+			code:   problem.CodeMethodNotAllowed,
+		},
+		{
+			name:   "vin required",
+			method: http.MethodGet,
+			path:   "/api/v1/claims?vin=",
+			token:  userToken,
+			status: http.StatusBadRequest,
+			code:   problem.CodeVINRequired,
+		},
+		{
+			name:   "invalid as_of",
+			method: http.MethodGet,
+			path:   "/api/v1/claims/warranty-year?vin=X&as_of=01.02.2026",
+			token:  userToken,
+			status: http.StatusBadRequest,
+			code:   problem.CodeInvalidAsOf,
+		},
+		{
+			name:   "stream without token",
+			method: http.MethodGet,
+			path:   "/api/v1/claims/stream?vin=X",
+			status: http.StatusUnauthorized,
+			code:   problem.CodeUnauthorized,
+		},
+		{
+			name:   "stream vin required",
+			method: http.MethodGet,
+			path:   "/api/v1/claims/stream?vin=",
+			token:  userToken,
+			status: http.StatusBadRequest,
+			code:   problem.CodeVINRequired,
+		},
+		{
+			name:   "admin route for user",
+			method: http.MethodGet,
+			path:   "/api/v1/admin/invitations",
+			token:  userToken,
+			status: http.StatusForbidden,
+			code:   problem.CodeForbidden,
+		},
+		{
+			name:   "invalid json",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   "{",
+			status: http.StatusBadRequest,
+			code:   problem.CodeInvalidJSON,
+		},
+		{
+			name:        "body is not json",
+			method:      http.MethodPost,
+			path:        "/api/v1/auth/login",
+			body:        "email=a&password=b",
+			contentType: "application/x-www-form-urlencoded",
+			status:      http.StatusUnsupportedMediaType,
+			code:        problem.CodeUnsupportedMedia,
+		},
+		{
+			name:   "unknown field",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   `{"email":"a@example.com","password":"x","remember":true}`,
+			status: http.StatusBadRequest,
+			code:   problem.CodeValidationFailed,
+		},
+		{
+			name:   "trailing json value",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   `{"email":"a@example.com","password":"x"}{}`,
+			status: http.StatusBadRequest,
+			code:   problem.CodeInvalidJSON,
+		},
+		{
+			name:   "missing required fields",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   `{"email":" "}`,
+			status: http.StatusBadRequest,
+			code:   problem.CodeValidationFailed,
+		},
+		{
+			name:   "wrong field type",
+			method: http.MethodPut,
+			path:   "/api/v1/admin/mfa-policies",
+			token:  adminToken,
+			body:   `{"role":"admin","required":"yes"}`,
+			status: http.StatusBadRequest,
+			code:   problem.CodeValidationFailed,
+		},
+		{
+			name:   "body too large",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   `{"email":"` + strings.Repeat("a", 70<<10) + `"}`,
+			status: http.StatusRequestEntityTooLarge,
+			code:   problem.CodeBodyTooLarge,
+		},
+		{
+			name:   "panic recovered",
+			method: http.MethodGet,
+			path:   "/api/v1/claims?vin=X",
+			token:  userToken,
+			status: http.StatusInternalServerError,
+			code:   problem.CodeInternal,
+		},
+		{
+			name:   "enroll token on enroll route",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/mfa/enroll",
+			token:  enrollToken,
+			status: http.StatusInternalServerError,
+			code:   problem.CodeInternal,
+		},
+		{
+			name:   "path id is not a number",
+			method: http.MethodGet,
+			path:   "/api/v1/admin/webhooks/abc",
+			token:  adminToken,
+			status: http.StatusNotFound,
+			code:   problem.CodeWebhookNotFound,
+		},
+		{
+			name:   "wrong method on path with id",
+			method: http.MethodPost,
+			path:   "/api/v1/admin/webhooks/1",
+			token:  adminToken,
+			status: http.StatusMethodNotAllowed,
+			code:   problem.CodeMethodNotAllowed,
+		},
+		{
+			name:   "admin route with id for user",
+			method: http.MethodDelete,
+			path:   "/api/v1/admin/claims/1",
+			token:  userToken,
+			status: http.StatusForbidden,
+			code:   problem.CodeForbidden,
+		},
+		{
+			name:   "invalid claim dates",
+			method: http.MethodPost,
+			path:   "/api/v1/admin/claims",
+			token:  adminToken,
+			body:   `{"vin":"X","retail_date":"2026-01-01","ro_open_date":"01.02.2026"}`,
+			status: http.StatusBadRequest,
+			code:   problem.CodeValidationFailed,
+		},
+		{
+			name:   "webhook without event types",
+			method: http.MethodPut,
+			path:   "/api/v1/admin/webhooks/1",
+			token:  adminToken,
+			body:   `{"url":"https://example.com/hook","event_types":[]}`,
+			status: http.StatusBadRequest,
+			code:   problem.CodeValidationFailed,
+		},
+		{
+			name:   "jobs for user",
+			method: http.MethodGet,
+			path:   "/api/v1/admin/jobs",
+			token:  userToken,
+			status: http.StatusForbidden,
+			code:   problem.CodeForbidden,
+		},
+		{
+			name:   "trigger unknown job",
+			method: http.MethodPost,
+			path:   "/api/v1/admin/jobs/unknown/trigger",
+			token:  adminToken,
+			status: http.StatusNotFound,
+			code:   problem.CodeJobNotFound,
+		},
+		{
+			name:   "job runs with invalid status",
+			method: http.MethodGet,
+			path:   "/api/v1/admin/jobs/unknown/runs?status=done",
+			token:  adminToken,
+			status: http.StatusBadRequest,
+			code:   problem.CodeInvalidJobRunStatus,
+		},
+		{
+			name:   "unknown notification type",
+			method: http.MethodPut,
+			path:   "/api/v1/auth/me/notifications/unknown",
+			token:  userToken,
+			body:   `{"enabled":false}`,
+			status: http.StatusNotFound,
+			code:   problem.CodeNotificationTypeNotFound,
+		},
+		{
+			name:   "disable required notification",
+			method: http.MethodPut,
+			path:   "/api/v1/auth/me/notifications/email_verification",
+			token:  userToken,
+			body:   `{"enabled":false}`,
+			status: http.StatusConflict,
+			code:   problem.CodeNotificationRequired,
+		},
+		{
+			name:   "threshold notification for user role",
+			method: http.MethodPut,
+			path:   "/api/v1/auth/me/notifications/warranty.threshold_exceeded",
+			token:  userToken,
+			body:   `{"enabled":true}`,
+			status: http.StatusForbidden,
+			code:   problem.CodeNotificationNotAllowed,
+		},
+		{
+			name:   "notification without enabled",
+			method: http.MethodPut,
+			path:   "/api/v1/auth/me/notifications/warranty.threshold_exceeded",
+			token:  userToken,
+			body:   `{}`,
+			status: http.StatusBadRequest,
+			code:   problem.CodeValidationFailed,
+		},
+		{name: "docs html", method: http.MethodGet, path: "/docs/", status: http.StatusOK},
+		{
+			name:   "cors preflight",
+			method: http.MethodOptions,
+			path:   "/api/v1/claims",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  http.MethodGet,
+				"Access-Control-Request-Headers": "authorization",
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "cors preflight from unknown origin",
+			method: http.MethodOptions,
+			path:   "/api/v1/claims",
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": http.MethodGet,
+			},
+			status: http.StatusForbidden,
+			code:   problem.CodeOriginNotAllowed,
+		},
+		{
+			name:    "plain options is not preflight",
+			method:  http.MethodOptions,
+			path:    "/api/v1/claims",
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			status:  http.StatusMethodNotAllowed,
+			code:    problem.CodeMethodNotAllowed,
+		},
+		{
+			name:    "cors actual request",
+			method:  http.MethodGet,
+			path:    "/api/v1/claims?vin=",
+			token:   userToken,
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			status:  http.StatusBadRequest,
+			code:    problem.CodeVINRequired,
+		},
+		{
+			name:    "cors actual request from unknown origin",
+			method:  http.MethodGet,
+			path:    "/health/live",
+			headers: map[string]string{"Origin": "https://evil.example.com"},
+			status:  http.StatusOK,
+		},
+		{
+			name:   "legacy alias",
+			method: http.MethodGet,
+			path:   "/claims?vin=X",
+			status: http.StatusUnauthorized,
+			code:   problem.CodeUnauthorized,
+		},
+		{
+			name:   "legacy alias with token",
+			method: http.MethodGet,
+			path:   "/claims/warranty-year?vin=",
+			token:  userToken,
+			status: http.StatusBadRequest,
+			code:   problem.CodeVINRequired,
+		},
+	}
+
+	runScenarios(t, contractServers(t, jwtSvc, noLimits, handler.SessionCookies{}), scenarios)
+}
+
+func noLimits() middleware.RateLimits { return middleware.RateLimits{} }
+
+// Браузерный режим: refresh-токен только из cookie и только вместе с совпадающим CSRF-токеном.
+func TestContractSessionCookies(t *testing.T) {
+	jwtSvc := auth.NewJWTService("contract-test-secret-contract-test-secret", "warranty_days", time.Hour, time.Hour)
+	cookies := handler.SessionCookies{
+		Enabled:  true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		TTL:      time.Hour,
+	}
+
+	scenarios := []scenario{
+		{
+			name:   "cookie refresh without csrf header",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/refresh",
+			headers: map[string]string{
+				handler.TokenDeliveryHeader: handler.TokenDeliveryCookie,
+				"Cookie":                    "wd_refresh=token; wd_csrf=csrf",
+			},
+			status: http.StatusForbidden,
+			code:   problem.CodeCSRFTokenMismatch,
+		},
+		{
+			name:   "cookie refresh with csrf mismatch",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/refresh",
+			headers: map[string]string{
+				handler.TokenDeliveryHeader: handler.TokenDeliveryCookie,
+				handler.CSRFHeader:          "other",
+				"Cookie":                    "wd_refresh=token; wd_csrf=csrf",
+			},
+			status: http.StatusForbidden,
+			code:   problem.CodeCSRFTokenMismatch,
+		},
+		{
+			name:   "cookie refresh without refresh cookie",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/refresh",
+			headers: map[string]string{
+				handler.TokenDeliveryHeader: handler.TokenDeliveryCookie,
+				handler.CSRFHeader:          "csrf",
+				"Cookie":                    "wd_csrf=csrf",
+			},
+			status: http.StatusUnauthorized,
+			code:   problem.CodeInvalidRefreshToken,
+		},
+		{
+			// cookie с Path=/api/v1/auth/refresh сюда не придут, поэтому режим отклоняется явно
+			name:   "cookie refresh on legacy path",
+			method: http.MethodPost,
+			path:   "/auth/refresh",
+			headers: map[string]string{
+				handler.TokenDeliveryHeader: handler.TokenDeliveryCookie,
+				handler.CSRFHeader:          "csrf",
+			},
+			status: http.StatusBadRequest,
+			code:   problem.CodeCookieModeRequiresV1,
+		},
+		{
+			name:    "cookie login on legacy path",
+			method:  http.MethodPost,
+			path:    "/auth/login",
+			body:    `{"email":"a@example.com","password":"x"}`,
+			headers: map[string]string{handler.TokenDeliveryHeader: handler.TokenDeliveryCookie},
+			status:  http.StatusBadRequest,
+			code:    problem.CodeCookieModeRequiresV1,
+		},
+		{
+			name:   "json refresh still needs body",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/refresh",
+			body:   `{}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "logout clears cookies",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/logout",
+			headers: map[string]string{
+				handler.CSRFHeader: "csrf",
+				"Cookie":           "wd_csrf=csrf",
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name:    "logout with csrf mismatch",
+			method:  http.MethodPost,
+			path:    "/api/v1/auth/logout",
+			headers: map[string]string{"Cookie": "wd_csrf=csrf"},
+			status:  http.StatusForbidden,
+			code:    problem.CodeCSRFTokenMismatch,
+		},
+		{
+			name:   "logout without cookies",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/logout",
+			status: http.StatusNoContent,
+		},
+	}
+	runScenarios(t, contractServers(t, jwtSvc, noLimits, cookies), scenarios)
+
+	disabled := []scenario{
+		{
+			name:    "cookie login when disabled",
+			method:  http.MethodPost,
+			path:    "/api/v1/auth/login",
+			body:    `{"email":"a@example.com","password":"x"}`,
+			headers: map[string]string{handler.TokenDeliveryHeader: handler.TokenDeliveryCookie},
+			status:  http.StatusBadRequest,
+			code:    problem.CodeCookieModeDisabled,
+		},
+	}
+	runScenarios(t, contractServers(t, jwtSvc, noLimits, handler.SessionCookies{}), disabled)
+}
+
+// Сценарии идут по порядку и тратят общие корзины: второй логин с того же IP уже упирается в лимит,
+// а пользователь считается отдельно от IP.
+func TestContractRateLimit(t *testing.T) {
+	jwtSvc := auth.NewJWTService("contract-test-secret-contract-test-secret", "warranty_days", time.Hour, time.Hour)
+	userToken, err := jwtSvc.GenerateAccessToken(1, "user@example.com", models.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limits := func() middleware.RateLimits {
+		return middleware.RateLimits{
+			Limiter: ratelimit.NewMemoryLimiter(),
+			Groups: map[string]ratelimit.Limit{
+				routes.RateGroupAuth:   {Requests: 1, Per: time.Hour},
+				routes.RateGroupClaims: {Requests: 2, Per: time.Hour},
+			},
+			Logger: slog.New(slog.DiscardHandler),
+		}
+	}
+
+	scenarios := []scenario{
+		{
+			name:   "login within limit",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   "{",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "login over limit",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   "{",
+			status: http.StatusTooManyRequests,
+			code:   problem.CodeRateLimited,
+		},
+		{
+			name:   "legacy alias shares bucket",
+			method: http.MethodPost,
+			path:   "/auth/login",
+			body:   "{",
+			status: http.StatusTooManyRequests,
+			code:   problem.CodeRateLimited,
+		},
+		{
+			name:   "claims within limit",
+			method: http.MethodGet,
+			path:   "/api/v1/claims?vin=",
+			token:  userToken,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "warranty-year shares claims bucket",
+			method: http.MethodGet,
+			path:   "/api/v1/claims/warranty-year?vin=",
+			token:  userToken,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "claims over limit",
+			method: http.MethodGet,
+			path:   "/api/v1/claims?vin=",
+			token:  userToken,
+			status: http.StatusTooManyRequests,
+			code:   problem.CodeRateLimited,
+		},
+		{
+			name:   "no token is not limited",
+			method: http.MethodGet,
+			path:   "/api/v1/claims?vin=",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "infra is not limited",
+			method: http.MethodGet,
+			path:   "/health/live",
+			status: http.StatusOK,
+		},
+	}
+
+	runScenarios(t, contractServers(t, jwtSvc, limits, handler.SessionCookies{}), scenarios)
+}
+
+// failingLimiter — хранилище лимитов, которое всегда недоступно.
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("limiter store unavailable")
+}
+
+// Хранилище недоступно: лимит считает Fallback, запросы сверх лимита по-прежнему получают 429.
+func TestContractRateLimitFallback(t *testing.T) {
+	jwtSvc := auth.NewJWTService("contract-test-secret-contract-test-secret", "warranty_days", time.Hour, time.Hour)
+
+	limits := func() middleware.RateLimits {
+		return middleware.RateLimits{
+			Limiter:  failingLimiter{},
+			Fallback: ratelimit.NewMemoryLimiter(),
+			Groups: map[string]ratelimit.Limit{
+				routes.RateGroupAuth: {Requests: 1, Per: time.Hour},
+			},
+			Logger: slog.New(slog.DiscardHandler),
+		}
+	}
+
+	scenarios := []scenario{
+		{
+			name:   "login within fallback limit",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   "{",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "login over fallback limit",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/login",
+			body:   "{",
+			status: http.StatusTooManyRequests,
+			code:   problem.CodeRateLimited,
+		},
+	}
+
+	runScenarios(t, contractServers(t, jwtSvc, limits, handler.SessionCookies{}), scenarios)
+}
+
+func runScenarios(t *testing.T, servers map[string]http.Handler, scenarios []scenario) {
+	t.Helper()
+
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			responses := make(map[string]response, len(servers))
+			for name, srv := range servers {
+				resp := serve(srv, sc)
+				if resp.status != sc.status {
+					t.Errorf("%s: %s %s: status %d, want %d\n%s", name, sc.method, sc.path, resp.status, sc.status, resp.body)
+				}
+				if sc.code != "" {
+					checkProblemCode(t, name, resp, sc.code)
+				}
+				responses[name] = resp
+			}
+
+			std, gin := responses["net/http"], responses["gin"]
+			if std.status != gin.status {
+				t.Errorf("status: net/http %d, gin %d", std.status, gin.status)
+			}
+			for _, key := range contractHeaders {
+				s, g := strings.Join(std.headers.Values(key), ", "), strings.Join(gin.headers.Values(key), ", ")
+				if s != g {
+					t.Errorf("header %s: net/http %q, gin %q", key, s, g)
+				}
+			}
+			if !bytes.Equal(std.body, gin.body) {
+				t.Errorf("body:\nnet/http: %s\ngin:      %s", std.body, gin.body)
+			}
+		})
+	}
+}
+
+func checkProblemCode(t *testing.T, server string, resp response, want string) {
+	t.Helper()
+	if ct := resp.headers.Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("%s: Content-Type %q, want %q", server, ct, problem.ContentType)
+	}
+	var body problem.Problem
+	if err := json.Unmarshal(resp.body, &body); err != nil {
+		t.Errorf("%s: problem body: %v\n%s", server, err, resp.body)
+		return
+	}
+	if body.Code != want || body.Status != resp.status {
+		t.Errorf("%s: problem code %q status %d, want %q status %d", server, body.Code, body.Status, want, resp.status)
+	}
+}
+
+func serve(srv http.Handler, sc scenario) response {
+	req := httptest.NewRequest(sc.method, sc.path, strings.NewReader(sc.body))
+	req.Header.Set(middleware.RequestIDHeader, "contract-"+strings.ReplaceAll(sc.name, " ", "-"))
+	if sc.contentType != "" {
+		req.Header.Set("Content-Type", sc.contentType)
+	} else if sc.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if sc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+sc.token)
+	}
+	for key, value := range sc.headers {
+		req.Header.Set(key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	return response{status: rec.Code, headers: rec.Header(), body: rec.Body.Bytes()}
+}
@@ -0,0 +1,355 @@
+// Package routes — единая таблица маршрутов HTTP API. По ней собираются и net/http mux, и Gin engine,
+// поэтому метод, путь, проверка токена и роли описываются в одном месте.
+package routes
+
+import (
+	"net/http"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/httpapi/handler"
+	"warranty_days/internal/httpapi/middleware"
+	"warranty_days/internal/httpapi/openapi"
+	"warranty_days/internal/metrics"
+	"warranty_days/internal/models"
+)
+
+// Access — какой токен нужен для вызова маршрута.
+type Access int
+
+const (
+	Public Access = iota
+	// AccessToken — обычный access-токен.
+	AccessToken
+	// AccessOrMFAEnroll — access-токен или челлендж-токен логина (настройка обязательной 2FA).
+	AccessOrMFAEnroll
+)
+
+// Группы лимитов запросов. Маршруты группы делят одну корзину на пользователя (или IP).
+const (
+	// RateGroupAuth — логин, регистрация и прочие шаги входа; без токена считаются по IP.
+	RateGroupAuth = "auth"
+	// RateGroupClaims — расчеты по VIN, самые дорогие для БД.
+	RateGroupClaims = "claims"
+	// RateGroupAPI — остальные маршруты с access-токеном.
+	RateGroupAPI = "api"
+)
+
+type Route struct {
+	Method  string
+	Path    string
+	Handler http.Handler
+	Access  Access
+	// Roles — если задано, пускаем только пользователей с одной из ролей.
+	Roles []string
+	// Subtree — маршрут обслуживает и все вложенные пути (статика Swagger UI). Path заканчивается на "/".
+	Subtree bool
+	// RateGroup — группа лимита запросов. Если пусто, для API выбирается по Access, служебные не ограничиваются.
+	RateGroup string
+}
+
+type Handlers struct {
+	Claims      *handler.ClaimsHandler
+	ClaimStream *handler.ClaimStreamHandler
+	Auth        *handler.AuthHandler
+	Invitation  *handler.InvitationHandler
+	MFA         *handler.MFAHandler
+	Profile     *handler.ProfileHandler
+	ClaimWrite  *handler.ClaimWriteHandler
+	Webhooks    *handler.WebhookHandler
+	Jobs        *handler.JobHandler
+	Health      *handler.HealthHandler
+	// Metrics — если nil, /metrics не регистрируется.
+	Metrics *metrics.Metrics
+	// MetricsToken — если задан, /metrics требует "Authorization: Bearer <MetricsToken>".
+	MetricsToken string
+}
+
+// Table возвращает все маршруты с полными путями: служебные в корне, API под /api/v1 и,
+// если включено, устаревшие алиасы API без префикса. Handler в результате уже обернут проверкой доступа
+// и лимитом запросов.
+func Table(
+	h Handlers,
+	jwtSvc middleware.AccessTokenValidator,
+	legacy middleware.Deprecation,
+	limits middleware.RateLimits,
+) []Route {
+	table := make([]Route, 0)
+	for _, route := range Infra(h) {
+		table = append(table, secure(route, jwtSvc, limits))
+	}
+
+	api := API(h)
+	for i := range api {
+		if api[i].RateGroup == "" {
+			api[i].RateGroup = rateGroup(api[i].Access)
+		}
+	}
+	for _, route := range api {
+		v1 := route
+		v1.Path = handler.APIV1Prefix + route.Path
+		table = append(table, secure(v1, jwtSvc, limits))
+	}
+	if legacy.Enabled {
+		for _, route := range api {
+			legacyRoute := secure(route, jwtSvc, limits)
+			legacyRoute.Handler = middleware.Deprecated(legacy, handler.APIV1Prefix+route.Path, legacyRoute.Handler)
+			table = append(table, legacyRoute)
+		}
+	}
+
+	return table
+}
+
+// Infra — служебные маршруты: health, метрики, документация.
+func Infra(h Handlers) []Route {
+	routes := []Route{
+		{Method: http.MethodGet, Path: "/health", Handler: http.HandlerFunc(h.Claims.Health)},
+		{Method: http.MethodGet, Path: "/health/live", Handler: http.HandlerFunc(h.Health.Live)},
+		{Method: http.MethodGet, Path: "/health/ready", Handler: http.HandlerFunc(h.Health.Ready)},
+	}
+	if h.Metrics != nil {
+		metricsHandler := h.Metrics.Handler()
+		if h.MetricsToken != "" {
+			metricsHandler = middleware.StaticToken(h.MetricsToken, metricsHandler)
+		}
+		routes = append(routes, Route{Method: http.MethodGet, Path: "/metrics", Handler: metricsHandler})
+	}
+
+	return append(routes,
+		Route{Method: http.MethodGet, Path: openapi.SpecPath, Handler: openapi.SpecHandler()},
+		Route{Method: http.MethodGet, Path: openapi.DocsPath, Handler: openapi.UIHandler(), Subtree: true},
+	)
+}
+
+// API — маршруты API относительно префикса версии.
+func API(h Handlers) []Route {
+	admin := []string{models.RoleAdmin}
+
+	return []Route{
+		{Method: http.MethodPost, Path: "/auth/register", Handler: http.HandlerFunc(h.Auth.Register)},
+		{Method: http.MethodPost, Path: "/auth/login", Handler: http.HandlerFunc(h.Auth.Login)},
+		{Method: http.MethodPost, Path: "/auth/refresh", Handler: http.HandlerFunc(h.Auth.Refresh)},
+		{Method: http.MethodPost, Path: "/auth/logout", Handler: http.HandlerFunc(h.Auth.Logout)},
+		{Method: http.MethodPost, Path: "/auth/verify-email", Handler: http.HandlerFunc(h.Auth.VerifyEmail)},
+		{Method: http.MethodPost, Path: "/auth/verify-email/resend", Handler: http.HandlerFunc(h.Auth.ResendVerification)},
+		{Method: http.MethodPost, Path: "/auth/mfa/verify", Handler: http.HandlerFunc(h.MFA.Verify)},
+
+		// настройка 2FA доступна и с access-токеном, и с челлендж-токеном логина (обязательная 2FA)
+		{
+			Method:  http.MethodPost,
+			Path:    "/auth/mfa/enroll",
+			Handler: http.HandlerFunc(h.MFA.Enroll),
+			Access:  AccessOrMFAEnroll,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/auth/mfa/enroll/confirm",
+			Handler: http.HandlerFunc(h.MFA.ConfirmEnrollment),
+			Access:  AccessOrMFAEnroll,
+		},
+
+		// protected routes
+		{
+			Method:    http.MethodGet,
+			Path:      "/claims",
+			Handler:   http.HandlerFunc(h.Claims.GetClaimsByVIN),
+			Access:    AccessToken,
+			RateGroup: RateGroupClaims,
+		},
+		{
+			Method:    http.MethodGet,
+			Path:      "/claims/warranty-year",
+			Handler:   http.HandlerFunc(h.Claims.GetWarrantyYearClaims),
+			Access:    AccessToken,
+			RateGroup: RateGroupClaims,
+		},
+		// поток держит соединение долго, но в лимите считается один раз — при подключении
+		{
+			Method:    http.MethodGet,
+			Path:      "/claims/stream",
+			Handler:   http.HandlerFunc(h.ClaimStream.Stream),
+			Access:    AccessToken,
+			RateGroup: RateGroupClaims,
+		},
+		{Method: http.MethodGet, Path: "/auth/me", Handler: http.HandlerFunc(h.Profile.Me), Access: AccessToken},
+		{Method: http.MethodPatch, Path: "/auth/me", Handler: http.HandlerFunc(h.Profile.UpdateMe), Access: AccessToken},
+		{
+			Method:  http.MethodGet,
+			Path:    "/auth/me/notifications",
+			Handler: http.HandlerFunc(h.Profile.Notifications),
+			Access:  AccessToken,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/auth/me/notifications/{type}",
+			Handler: http.HandlerFunc(h.Profile.UpdateNotification),
+			Access:  AccessToken,
+		},
+		{Method: http.MethodPost, Path: "/auth/mfa/disable", Handler: http.HandlerFunc(h.MFA.Disable), Access: AccessToken},
+
+		// admin routes
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/invitations",
+			Handler: http.HandlerFunc(h.Invitation.List),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/invitations",
+			Handler: http.HandlerFunc(h.Invitation.Create),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/mfa-policies",
+			Handler: http.HandlerFunc(h.MFA.ListPolicies),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/admin/mfa-policies",
+			Handler: http.HandlerFunc(h.MFA.UpdatePolicy),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/claims",
+			Handler: http.HandlerFunc(h.ClaimWrite.Create),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/admin/claims/{id}",
+			Handler: http.HandlerFunc(h.ClaimWrite.Update),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/admin/claims/{id}",
+			Handler: http.HandlerFunc(h.ClaimWrite.Delete),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/webhooks",
+			Handler: http.HandlerFunc(h.Webhooks.List),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/webhooks",
+			Handler: http.HandlerFunc(h.Webhooks.Create),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/webhooks/{id}",
+			Handler: http.HandlerFunc(h.Webhooks.Get),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/admin/webhooks/{id}",
+			Handler: http.HandlerFunc(h.Webhooks.Update),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/admin/webhooks/{id}",
+			Handler: http.HandlerFunc(h.Webhooks.Delete),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/webhooks/{id}/deliveries",
+			Handler: http.HandlerFunc(h.Webhooks.Deliveries),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/webhooks/{id}/deliveries/{delivery_id}/retry",
+			Handler: http.HandlerFunc(h.Webhooks.Redeliver),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/jobs",
+			Handler: http.HandlerFunc(h.Jobs.List),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/admin/jobs/{name}/runs",
+			Handler: http.HandlerFunc(h.Jobs.Runs),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/jobs/{name}/trigger",
+			Handler: http.HandlerFunc(h.Jobs.Trigger),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/jobs/{name}/pause",
+			Handler: http.HandlerFunc(h.Jobs.Pause),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/admin/jobs/{name}/resume",
+			Handler: http.HandlerFunc(h.Jobs.Resume),
+			Access:  AccessToken,
+			Roles:   admin,
+		},
+	}
+}
+
+// rateGroup — группа лимита по умолчанию: шаги входа считаются вместе, остальное с токеном — в общей группе.
+func rateGroup(access Access) string {
+	if access == AccessToken {
+		return RateGroupAPI
+	}
+	return RateGroupAuth
+}
+
+// secure оборачивает хендлер маршрута проверкой токена, лимитом запросов и проверкой ролей.
+// Лимит идет после проверки токена: корзина выбирается по пользователю из контекста.
+func secure(route Route, jwtSvc middleware.AccessTokenValidator, limits middleware.RateLimits) Route {
+	next := route.Handler
+	if len(route.Roles) > 0 {
+		next = middleware.RequireRole(route.Roles, next)
+	}
+	if route.RateGroup != "" {
+		next = middleware.RateLimit(limits, route.RateGroup, next)
+	}
+
+	switch route.Access {
+	case AccessToken:
+		next = middleware.Auth(jwtSvc, next)
+	case AccessOrMFAEnroll:
+		next = middleware.AuthTokenTypes(jwtSvc, []string{auth.TokenTypeAccess, auth.TokenTypeMFAEnroll}, next)
+	}
+
+	route.Handler = next
+	return route
+}
@@ -1,28 +0,0 @@
-package middleware
-
-import (
-	"log/slog"
-	"time"
-
-	"github.com/gin-gonic/gin"
-)
-
-func RequestLogging(logger *slog.Logger) gin.HandlerFunc {
-	if logger == nil {
-		logger = slog.Default()
-	}
-
-	return func(c *gin.Context) {
-		startedAt := time.Now()
-		c.Next()
-
-		logger.InfoContext(c.Request.Context(), "http request",
-			"method", c.Request.Method,
-			"path", c.Request.URL.Path,
-			"query", c.Request.URL.RawQuery,
-			"status", c.Writer.Status(),
-			"duration_ms", time.Since(startedAt).Milliseconds(),
-			"remote_addr", c.Request.RemoteAddr,
-		)
-	}
-}
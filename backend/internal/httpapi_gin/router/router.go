@@ -3,36 +3,79 @@ package router
 import (
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
-	"warranty_days/internal/httpapi/handler"
 	"warranty_days/internal/httpapi/middleware"
-	ginmiddleware "warranty_days/internal/httpapi_gin/middleware"
+	"warranty_days/internal/httpapi/problem"
+	"warranty_days/internal/httpapi/routes"
 )
 
-func NewEngine(
-	claimsHandler *handler.ClaimsHandler,
-	authHandler *handler.AuthHandler,
+// NewHandler собирает сервер на Gin по той же таблице маршрутов и с тем же набором middleware,
+// что и router.NewMux: ответы обоих серверов совпадают.
+func NewHandler(
+	handlers routes.Handlers,
 	jwtSvc middleware.AccessTokenValidator,
+	legacyRoutes middleware.Deprecation,
+	rateLimits middleware.RateLimits,
+	browser middleware.BrowserPolicy,
 	logger *slog.Logger,
-) *gin.Engine {
+) http.Handler {
+	engine := NewEngine(routes.Table(handlers, jwtSvc, legacyRoutes, rateLimits))
+
+	return middleware.Stack(handlers.Metrics, logger, middleware.Browser(browser, engine))
+}
+
+// NewEngine регистрирует маршруты таблицы в Gin. Middleware сюда не входят — их добавляет NewHandler.
+func NewEngine(table []routes.Route) *gin.Engine {
 	engine := gin.New()
-	engine.Use(gin.Recovery())
-	engine.Use(ginmiddleware.RequestLogging(logger))
-
-	// public routes
-	engine.GET("/health", gin.WrapF(claimsHandler.Health))
-	engine.POST("/auth/register", gin.WrapF(authHandler.Register))
-	engine.POST("/auth/login", gin.WrapF(authHandler.Login))
-	engine.POST("/auth/refresh", gin.WrapF(authHandler.Refresh))
-
-	// protected routes
-	engine.GET("/claims", gin.WrapH(middleware.Auth(jwtSvc, http.HandlerFunc(claimsHandler.GetClaimsByVIN))))
-	engine.GET(
-		"/claims/warranty-year",
-		gin.WrapH(middleware.Auth(jwtSvc, http.HandlerFunc(claimsHandler.GetWarrantyYearClaims))),
-	)
+	engine.HandleMethodNotAllowed = true
+	// как ServeMux: /claims/ и /claims — разные пути, без редиректа
+	engine.RedirectTrailingSlash = false
+	engine.NoRoute(func(c *gin.Context) {
+		problem.Write(c.Writer, c.Request, http.StatusNotFound, problem.CodeNotFound, "route not found")
+	})
+	engine.NoMethod(func(c *gin.Context) {
+		// Gin перечисляет методы в порядке регистрации, ServeMux-версия — по алфавиту
+		allowed := strings.Split(c.Writer.Header().Get("Allow"), ", ")
+		slices.Sort(allowed)
+		c.Header("Allow", strings.Join(allowed, ", "))
+		problem.Write(c.Writer, c.Request, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "method not allowed")
+	})
+
+	for _, route := range table {
+		path := ginPath(route.Path)
+		if route.Subtree {
+			path += "*any"
+		}
+		engine.Handle(route.Method, path, serve(route))
+	}
 
 	return engine
 }
+
+// serve вызывает хендлер маршрута и проставляет r.Pattern в стиле ServeMux — по нему middleware.Stack
+// подписывает метрики и спаны.
+func serve(route routes.Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Pattern = route.Path
+		// хендлеры читают параметры пути через r.PathValue, как под ServeMux
+		for _, param := range c.Params {
+			c.Request.SetPathValue(param.Key, param.Value)
+		}
+		route.Handler.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// ginPath переводит параметры пути из синтаксиса ServeMux ({id}) в синтаксис Gin (:id).
+func ginPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + strings.Trim(segment, "{}")
+		}
+	}
+	return strings.Join(segments, "/")
+}
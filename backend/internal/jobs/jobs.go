@@ -0,0 +1,156 @@
+// Package jobs — периодические задачи сервиса для scheduler.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+	"warranty_days/internal/scheduler"
+	"warranty_days/internal/service"
+)
+
+const (
+	WarrantyStatusRecalc = "warranty_status_recalc"
+	DataQualityScan      = "data_quality_scan"
+	SessionCleanup       = "session_cleanup"
+)
+
+// dataQualitySample — сколько id заявок сохранять в результате по каждой проверке.
+const dataQualitySample = 20
+
+type warrantyStatusResult struct {
+	AsOf        string `json:"as_of"`
+	Changed     int64  `json:"changed"`
+	Removed     int64  `json:"removed"`
+	OK          int64  `json:"ok"`
+	Approaching int64  `json:"approaching"`
+	Exceeded    int64  `json:"exceeded"`
+}
+
+// WarrantyStatusRecalcJob пересчитывает статус гарантийного года всех VIN в vin_warranty_status. Статус меняется
+// и без изменения заявок: гарантийный год сменяется по дате продажи.
+func WarrantyStatusRecalcJob(
+	schedule string,
+	statusRepo *repo.WarrantyStatusRepo,
+	txManager *repo.TxManager,
+	thresholds service.WarrantyThresholds,
+) scheduler.Job {
+	return scheduler.Job{
+		Name:        WarrantyStatusRecalc,
+		Description: "Recalculate the current warranty year status of every VIN",
+		Schedule:    schedule,
+		Run: func(ctx context.Context) (any, error) {
+			now := time.Now()
+			result := warrantyStatusResult{AsOf: now.UTC().Format(time.DateOnly)}
+
+			// в одной транзакции: читатели таблицы не видят полупересчитанного состояния
+			err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+				var err error
+				if result.Changed, err = statusRepo.Recalculate(ctx, now, thresholds.Warning, thresholds.Limit); err != nil {
+					return err
+				}
+				if result.Removed, err = statusRepo.DeleteStale(ctx, now); err != nil {
+					return err
+				}
+				counts, err := statusRepo.CountByStatus(ctx)
+				if err != nil {
+					return err
+				}
+				result.OK = counts[models.WarrantyStatusOK]
+				result.Approaching = counts[models.WarrantyStatusApproaching]
+				result.Exceeded = counts[models.WarrantyStatusExceeded]
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		},
+	}
+}
+
+type dataQualityCheckResult struct {
+	Check          string  `json:"check"`
+	Claims         int64   `json:"claims"`
+	SampleClaimIDs []int64 `json:"sample_claim_ids"`
+}
+
+type dataQualityResult struct {
+	// Findings — сумма по проверкам; одна заявка может попасть в несколько.
+	Findings int64                    `json:"findings"`
+	Checks   []dataQualityCheckResult `json:"checks"`
+}
+
+// DataQualityScanJob прогоняет проверки repo.DataQualityChecks. Найденные проблемы не ошибка запуска: они
+// сохраняются в результате и пишутся в лог предупреждением.
+func DataQualityScanJob(schedule string, qualityRepo *repo.DataQualityRepo, logger *slog.Logger) scheduler.Job {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return scheduler.Job{
+		Name:        DataQualityScan,
+		Description: "Scan claims for invalid VINs, inconsistent dates, duplicates and overlapping repairs",
+		Schedule:    schedule,
+		Run: func(ctx context.Context) (any, error) {
+			result := dataQualityResult{Checks: make([]dataQualityCheckResult, 0, len(repo.DataQualityChecks))}
+			for _, check := range repo.DataQualityChecks {
+				finding, err := qualityRepo.Run(ctx, check, dataQualitySample)
+				if err != nil {
+					return nil, err
+				}
+				result.Findings += finding.Count
+				result.Checks = append(result.Checks, dataQualityCheckResult{
+					Check:          finding.Check,
+					Claims:         finding.Count,
+					SampleClaimIDs: finding.SampleIDs,
+				})
+				if finding.Count > 0 {
+					logger.WarnContext(ctx, "data quality issue found", "check", finding.Check, "claims", finding.Count)
+				}
+			}
+			return result, nil
+		},
+	}
+}
+
+type sessionCleanupResult struct {
+	EmailVerificationsDeleted int64 `json:"email_verifications_deleted"`
+	InvitationsDeleted        int64 `json:"invitations_deleted"`
+	MFAChallengesDeleted      int64 `json:"mfa_challenges_deleted"`
+}
+
+// SessionCleanupJob удаляет истекшее состояние входа и регистрации. Refresh-токены не хранятся на сервере, поэтому
+// серверных сессий нет — чистятся токены подтверждения email, неиспользованные приглашения и челленджи 2FA,
+// истекшие больше retention назад.
+func SessionCleanupJob(
+	schedule string,
+	verificationRepo *repo.EmailVerificationRepo,
+	invitationRepo *repo.InvitationRepo,
+	mfaRepo *repo.MFARepo,
+	retention time.Duration,
+) scheduler.Job {
+	return scheduler.Job{
+		Name:        SessionCleanup,
+		Description: "Delete expired email verification tokens, unused invitations and mfa challenges",
+		Schedule:    schedule,
+		Run: func(ctx context.Context) (any, error) {
+			before := time.Now().Add(-retention)
+
+			var result sessionCleanupResult
+			var err error
+			if result.EmailVerificationsDeleted, err = verificationRepo.DeleteExpiredBefore(ctx, before); err != nil {
+				return nil, err
+			}
+			if result.InvitationsDeleted, err = invitationRepo.DeleteUnusedExpiredBefore(ctx, before); err != nil {
+				return nil, err
+			}
+			if result.MFAChallengesDeleted, err = mfaRepo.DeleteChallengesExpiredBefore(ctx, before); err != nil {
+				return nil, err
+			}
+			return result, nil
+		},
+	}
+}
@@ -30,6 +30,8 @@ func New(appEnv string, level string, out io.Writer) *slog.Logger {
 		handler = slog.NewJSONHandler(out, opts)
 	}
 
+	handler = contextHandler{Handler: traceHandler{Handler: handler}}
+
 	return slog.New(handler).With("service", "warranty_days", "env", normalizeEnv(appEnv))
 }
 
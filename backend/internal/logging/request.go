@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+)
+
+type requestContextKey struct{}
+
+// requestInfo живет в контексте всего запроса. UserID заполняется позже, в auth middleware,
+// поэтому хранится по указателю — так его видит и лог внешнего RequestLogging.
+type requestInfo struct {
+	requestID string
+	userID    atomic.Int64
+}
+
+// WithRequestID кладет ID запроса в контекст; все *Context-записи с этим контекстом получат request_id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, &requestInfo{requestID: requestID})
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	if info, ok := ctx.Value(requestContextKey{}).(*requestInfo); ok {
+		return info.requestID
+	}
+	return ""
+}
+
+// SetUserID привязывает аутентифицированного пользователя к запросу. Без WithRequestID ничего не делает.
+func SetUserID(ctx context.Context, userID int64) {
+	if info, ok := ctx.Value(requestContextKey{}).(*requestInfo); ok {
+		info.userID.Store(userID)
+	}
+}
+
+// contextHandler добавляет request_id и user_id запроса в записи, созданные через *Context-методы.
+// Атрибуты запроса всегда на верхнем уровне записи: после WithGroup обработчик помнит root — себя
+// до первой группы — и повторяет над ним With/WithGroup вызывающего уже после атрибутов запроса.
+type contextHandler struct {
+	slog.Handler
+
+	root slog.Handler
+	ops  []func(slog.Handler) slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := requestAttrs(ctx)
+	if len(attrs) == 0 {
+		return h.Handler.Handle(ctx, record)
+	}
+	if len(h.ops) == 0 {
+		record.AddAttrs(attrs...)
+		return h.Handler.Handle(ctx, record)
+	}
+
+	handler := h.root.WithAttrs(attrs)
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(h.ops) == 0 {
+		return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+	}
+	return h.with(h.Handler.WithAttrs(attrs), func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(h.Handler.WithGroup(name), func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h contextHandler) with(handler slog.Handler, op func(slog.Handler) slog.Handler) contextHandler {
+	root := h.root
+	if root == nil {
+		root = h.Handler
+	}
+	return contextHandler{
+		Handler: handler,
+		root:    root,
+		ops:     append(slices.Clip(h.ops), op),
+	}
+}
+
+func requestAttrs(ctx context.Context) []slog.Attr {
+	info, ok := ctx.Value(requestContextKey{}).(*requestInfo)
+	if !ok {
+		return nil
+	}
+	attrs := []slog.Attr{slog.String("request_id", info.requestID)}
+	if userID := info.userID.Load(); userID != 0 {
+		attrs = append(attrs, slog.Int64("user_id", userID))
+	}
+	return attrs
+}
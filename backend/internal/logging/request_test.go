@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+type otherContextKey struct{}
+
+func TestContextHandlerAddsRequestAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(contextHandler{Handler: slog.NewJSONHandler(&buf, nil)})
+
+	// RequestLogging создает requestInfo до аутентификации
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "before auth")
+
+	// Auth видит производный контекст, но пишет в тот же requestInfo
+	SetUserID(context.WithValue(ctx, otherContextKey{}, "auth"), 42)
+	logger.InfoContext(ctx, "after auth")
+
+	logger.InfoContext(context.Background(), "outside request")
+	logger.Info("without context")
+
+	lines := logLines(t, &buf)
+	if len(lines) != 4 {
+		t.Fatalf("got %d log lines, want 4", len(lines))
+	}
+	if lines[0]["request_id"] != "req-1" || lines[0]["user_id"] != nil {
+		t.Errorf("before auth = %v, want request_id only", lines[0])
+	}
+	if lines[1]["request_id"] != "req-1" || lines[1]["user_id"] != float64(42) {
+		t.Errorf("after auth = %v, want request_id and user_id 42", lines[1])
+	}
+	for _, line := range lines[2:] {
+		if _, ok := line["request_id"]; ok {
+			t.Errorf("%v: request_id outside a request", line)
+		}
+	}
+}
+
+func TestContextHandlerKeepsRequestAttrsOutsideGroups(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(contextHandler{Handler: slog.NewJSONHandler(&buf, nil)}).With("service", "api")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	SetUserID(ctx, 42)
+	logger.WithGroup("claims").With("vin", "VIN1").WithGroup("period").InfoContext(ctx, "calculated", "days", 3)
+
+	lines := logLines(t, &buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1", len(lines))
+	}
+	line := lines[0]
+	if line["request_id"] != "req-1" || line["user_id"] != float64(42) || line["service"] != "api" {
+		t.Fatalf("line = %v, want request_id, user_id and service at the top level", line)
+	}
+	claims, _ := line["claims"].(map[string]any)
+	period, _ := claims["period"].(map[string]any)
+	if claims["vin"] != "VIN1" || period["days"] != float64(3) || claims["request_id"] != nil {
+		t.Fatalf("claims group = %v, want vin and period.days only", claims)
+	}
+}
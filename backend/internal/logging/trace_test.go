@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// logLines разбирает JSON-записи из buf, по одной на строку.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	buf.Reset()
+	return lines
+}
+
+func TestTraceHandlerAddsSpanIDs(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	var buf bytes.Buffer
+	logger := slog.New(traceHandler{Handler: slog.NewJSONHandler(&buf, nil)})
+	logger.InfoContext(ctx, "inside span")
+	logger.InfoContext(context.Background(), "outside span")
+
+	lines := logLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2", len(lines))
+	}
+	spanCtx := span.SpanContext()
+	if lines[0]["trace_id"] != spanCtx.TraceID().String() || lines[0]["span_id"] != spanCtx.SpanID().String() {
+		t.Errorf("inside span = %v, want trace_id %s and span_id %s", lines[0], spanCtx.TraceID(), spanCtx.SpanID())
+	}
+	if _, ok := lines[1]["trace_id"]; ok {
+		t.Errorf("outside span = %v, want no trace_id", lines[1])
+	}
+	if _, ok := lines[1]["span_id"]; ok {
+		t.Errorf("outside span = %v, want no span_id", lines[1])
+	}
+}
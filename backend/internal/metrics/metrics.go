@@ -0,0 +1,182 @@
+// Package metrics exposes Prometheus metrics of the API
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "warranty_days"
+
+const (
+	LoginSuccess     = "success"
+	LoginFailure     = "failure"
+	LoginMFARequired = "mfa_required"
+	LoginRejected    = "rejected"
+	LoginError       = "error"
+)
+
+// UnmatchedRoute — метка для запросов, не попавших ни в один маршрут,
+// чтобы произвольные пути не раздували кардинальность.
+const UnmatchedRoute = "unmatched"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+
+	loginAttempts        *prometheus.CounterVec
+	warrantyCalculations prometheus.Counter
+}
+
+// New создает реестр метрик.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status.",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"method", "route", "status"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+		loginAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_login_attempts_total",
+			Help:      "Login attempts by result (success, failure, mfa_required, rejected, error).",
+		}, []string{"result"}),
+		warrantyCalculations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "warranty_year_calculations_total",
+			Help:      "Warranty-year repair days calculations performed.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.loginAttempts,
+		m.warrantyCalculations,
+	)
+
+	return m
+}
+
+// RegisterDBStats добавляет статистику пула sql.DB (соединения, ожидания).
+func (m *Metrics) RegisterDBStats(sqlDB *sql.DB, dbName string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, dbName))
+}
+
+// RegisterVINsOverLimit добавляет гейдж числа VIN, превысивших лимит дней ремонта в текущем гарантийном году.
+// count вызывается не чаще раза в refreshEvery, между обновлениями отдается кэш.
+func (m *Metrics) RegisterVINsOverLimit(
+	count func(ctx context.Context) (int64, error),
+	limit int,
+	refreshEvery time.Duration,
+) {
+	m.registry.MustRegister(&vinsOverLimitCollector{
+		count:        count,
+		refreshEvery: refreshEvery,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "warranty", "vins_over_limit"),
+			"VINs whose repair days in the current warranty year exceed the limit.",
+			nil,
+			prometheus.Labels{"limit": strconv.Itoa(limit)},
+		),
+	})
+}
+
+// Handler отдает метрики без проверки доступа; токен для /metrics проверяет middleware.StaticToken.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) RequestStarted() {
+	if m == nil {
+		return
+	}
+	m.httpInFlight.Inc()
+}
+
+func (m *Metrics) RequestFinished(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	if route == "" {
+		route = UnmatchedRoute
+	}
+
+	statusLabel := strconv.Itoa(status)
+	m.httpInFlight.Dec()
+	m.httpRequests.WithLabelValues(method, route, statusLabel).Inc()
+	m.httpDuration.WithLabelValues(method, route, statusLabel).Observe(duration.Seconds())
+}
+
+func (m *Metrics) LoginAttempt(result string) {
+	if m == nil {
+		return
+	}
+	m.loginAttempts.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) WarrantyYearCalculated() {
+	if m == nil {
+		return
+	}
+	m.warrantyCalculations.Inc()
+}
+
+type vinsOverLimitCollector struct {
+	count        func(ctx context.Context) (int64, error)
+	refreshEvery time.Duration
+	desc         *prometheus.Desc
+
+	mu        sync.Mutex
+	value     float64
+	updatedAt time.Time
+}
+
+func (c *vinsOverLimitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *vinsOverLimitCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.updatedAt) >= c.refreshEvery {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		value, err := c.count(ctx)
+		cancel()
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(c.desc, err)
+			return
+		}
+		c.value = float64(value)
+		c.updatedAt = time.Now()
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, c.value)
+}
@@ -0,0 +1,18 @@
+// Package models for ClaimChange data
+package models
+
+import "time"
+
+// ClaimChange — строка журнала изменений заявок. Ее пишет триггер на claims и рассылает через pg_notify.
+type ClaimChange struct {
+	ID          int64     `json:"id" gorm:"primaryKey"`
+	EventType   string    `json:"event_type" gorm:"column:event_type;not null"`
+	ClaimID     int64     `json:"claim_id" gorm:"column:claim_id;not null"`
+	VIN         string    `json:"vin" gorm:"column:vin;not null"`
+	PreviousVIN *string   `json:"previous_vin" gorm:"column:previous_vin"`
+	ChangedAt   time.Time `json:"changed_at" gorm:"column:changed_at;not null"`
+}
+
+func (ClaimChange) TableName() string {
+	return "claim_changes"
+}
@@ -0,0 +1,34 @@
+// Package models for Invitation data
+package models
+
+import "time"
+
+type Invitation struct {
+	ID         int64      `json:"id" gorm:"primaryKey"`
+	CodeHash   string     `json:"-" gorm:"column:code_hash;not null"`
+	Email      *string    `json:"email" gorm:"column:email"`
+	Role       string     `json:"role" gorm:"column:role;not null;default:user"`
+	DealerCode *string    `json:"dealer_code" gorm:"column:dealer_code"`
+	CreatedBy  *int64     `json:"created_by" gorm:"column:created_by"`
+	UsedBy     *int64     `json:"used_by" gorm:"column:used_by"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	UsedAt     *time.Time `json:"used_at" gorm:"column:used_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+type EmailVerification struct {
+	ID        int64      `json:"id" gorm:"primaryKey"`
+	UserID    int64      `json:"user_id" gorm:"column:user_id;not null"`
+	TokenHash string     `json:"-" gorm:"column:token_hash;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `json:"used_at" gorm:"column:used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (EmailVerification) TableName() string {
+	return "email_verifications"
+}
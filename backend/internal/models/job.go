@@ -0,0 +1,50 @@
+// Package models for Job data
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobRunQueued    = "queued"
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+
+	// JobSourceSchedule — запуск по расписанию, JobSourceManual — администратор запустил вручную.
+	JobSourceSchedule = "schedule"
+	JobSourceManual   = "manual"
+)
+
+// ScheduledJob — состояние задачи, общее для всех инстансов. Строки нет, пока задачу не ставили на паузу.
+type ScheduledJob struct {
+	Name      string    `json:"name" gorm:"primaryKey;column:name"`
+	Paused    bool      `json:"paused" gorm:"column:paused;not null;default:false"`
+	UpdatedBy *int64    `json:"updated_by" gorm:"column:updated_by"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (ScheduledJob) TableName() string {
+	return "scheduled_jobs"
+}
+
+// JobRun — запуск задачи. Строка остается после завершения: это история.
+type JobRun struct {
+	ID      int64  `json:"id" gorm:"primaryKey"`
+	JobName string `json:"job_name" gorm:"column:job_name;not null"`
+	Source  string `json:"source" gorm:"column:source;not null"`
+	Status  string `json:"status" gorm:"column:status;not null"`
+	// ScheduledFor — слот расписания; у ручного запуска nil.
+	ScheduledFor *time.Time      `json:"scheduled_for" gorm:"column:scheduled_for"`
+	TriggeredBy  *int64          `json:"triggered_by" gorm:"column:triggered_by"`
+	StartedAt    *time.Time      `json:"started_at" gorm:"column:started_at"`
+	FinishedAt   *time.Time      `json:"finished_at" gorm:"column:finished_at"`
+	Result       json.RawMessage `json:"result" gorm:"column:result;type:jsonb"`
+	Error        *string         `json:"error" gorm:"column:error"`
+	CreatedAt    time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (JobRun) TableName() string {
+	return "job_runs"
+}
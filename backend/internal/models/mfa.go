@@ -0,0 +1,41 @@
+// Package models for MFA data
+package models
+
+import "time"
+
+type MFARecoveryCode struct {
+	ID        int64      `json:"id" gorm:"primaryKey"`
+	UserID    int64      `json:"user_id" gorm:"column:user_id;not null"`
+	CodeHash  string     `json:"-" gorm:"column:code_hash;not null"`
+	UsedAt    *time.Time `json:"used_at" gorm:"column:used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+type MFARolePolicy struct {
+	Role      string    `json:"role" gorm:"column:role;primaryKey"`
+	Required  bool      `json:"required" gorm:"column:required;not null"`
+	UpdatedBy *int64    `json:"updated_by" gorm:"column:updated_by"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (MFARolePolicy) TableName() string {
+	return "mfa_role_policies"
+}
+
+// MFALoginChallenge — выданный после пароля mfa_token. ID совпадает с jti токена.
+type MFALoginChallenge struct {
+	ID         string     `json:"id" gorm:"column:id;primaryKey"`
+	UserID     int64      `json:"user_id" gorm:"column:user_id;not null"`
+	Attempts   int        `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	ConsumedAt *time.Time `json:"consumed_at" gorm:"column:consumed_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (MFALoginChallenge) TableName() string {
+	return "mfa_challenges"
+}
@@ -0,0 +1,90 @@
+// Package models for Notification data
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	// NotificationDead — попытки кончились или сервер отклонил письмо окончательно.
+	NotificationDead = "dead"
+)
+
+// Типы писем. От обязательных (подтверждение email) отказаться нельзя.
+const (
+	NotificationEmailVerification    = "email_verification"
+	NotificationThresholdApproaching = "warranty.threshold_approaching"
+	NotificationThresholdExceeded    = "warranty.threshold_exceeded"
+)
+
+// NotificationTypes — все типы писем в порядке документации.
+var NotificationTypes = []string{
+	NotificationEmailVerification,
+	NotificationThresholdApproaching,
+	NotificationThresholdExceeded,
+}
+
+func KnownNotificationType(notificationType string) bool {
+	return slices.Contains(NotificationTypes, notificationType)
+}
+
+// NotificationRequired — письмо нужно для входа в аккаунт, отказаться от него нельзя.
+func NotificationRequired(notificationType string) bool {
+	return notificationType == NotificationEmailVerification
+}
+
+// NotificationRoles — роли, которым доступны письма типа; nil — всем. Письма о порогах касаются любого VIN,
+// поэтому только администраторам.
+func NotificationRoles(notificationType string) []string {
+	switch notificationType {
+	case NotificationThresholdApproaching, NotificationThresholdExceeded:
+		return []string{RoleAdmin}
+	default:
+		return nil
+	}
+}
+
+// NotificationAllowed — может ли пользователь с ролью role получать письма типа.
+func NotificationAllowed(notificationType, role string) bool {
+	roles := NotificationRoles(notificationType)
+	return roles == nil || slices.Contains(roles, role)
+}
+
+// Notification — письмо в очереди отправки. Строка остается после отправки как журнал, тело стирается.
+type Notification struct {
+	ID     int64  `json:"id" gorm:"primaryKey"`
+	Type   string `json:"type" gorm:"column:type;not null"`
+	UserID *int64 `json:"user_id" gorm:"column:user_id"`
+	// EventID — доменное событие, по которому письмо; повтор публикации не дублирует письмо.
+	EventID       *string    `json:"event_id" gorm:"column:event_id"`
+	Recipient     string     `json:"recipient" gorm:"column:recipient;not null"`
+	Language      string     `json:"language" gorm:"column:language;not null"`
+	Subject       string     `json:"subject" gorm:"column:subject;not null"`
+	TextBody      string     `json:"-" gorm:"column:text_body;not null"`
+	HTMLBody      string     `json:"-" gorm:"column:html_body;not null"`
+	Status        string     `json:"status" gorm:"column:status;not null;default:pending"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"column:next_attempt_at;not null"`
+	LastError     *string    `json:"last_error" gorm:"column:last_error"`
+	SentAt        *time.Time `json:"sent_at" gorm:"column:sent_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationOptOut — пользователь отказался от писем этого типа.
+type NotificationOptOut struct {
+	UserID    int64     `json:"user_id" gorm:"column:user_id;primaryKey"`
+	Type      string    `json:"type" gorm:"column:type;primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (NotificationOptOut) TableName() string {
+	return "notification_opt_outs"
+}
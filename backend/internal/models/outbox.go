@@ -0,0 +1,28 @@
+// Package models for Outbox data
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage — доменное событие, записанное вместе с изменением заявок. Relay отдает его получателям
+// и проставляет PublishedAt, а после последней неудачной попытки — DeadAt.
+type OutboxMessage struct {
+	ID            int64           `json:"id" gorm:"primaryKey"`
+	EventID       string          `json:"event_id" gorm:"column:event_id;not null"`
+	EventType     string          `json:"event_type" gorm:"column:event_type;not null"`
+	VIN           string          `json:"vin" gorm:"column:vin;not null"`
+	Payload       json.RawMessage `json:"payload" gorm:"column:payload;type:jsonb;not null"`
+	OccurredAt    time.Time       `json:"occurred_at" gorm:"column:occurred_at;not null"`
+	Attempts      int             `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"column:next_attempt_at;not null"`
+	LastError     *string         `json:"last_error" gorm:"column:last_error"`
+	PublishedAt   *time.Time      `json:"published_at" gorm:"column:published_at"`
+	DeadAt        *time.Time      `json:"dead_at" gorm:"column:dead_at"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
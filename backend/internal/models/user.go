@@ -3,15 +3,37 @@ package models
 
 import "time"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User. У IsActive нет default в теге: GORM заменил бы им false, и неподтвержденный аккаунт
+// создался бы активным. Значение по умолчанию есть в самой таблице.
 type User struct {
-	ID           int64     `json:"id" gorm:"primaryKey"`
-	Email        string    `json:"email" gorm:"column:email;not null"`
-	PasswordHash string    `json:"-" gorm:"column:password_hash;not null"`
-	IsActive     bool      `json:"is_active" gorm:"column:is_active;not null;default:true"`
-	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+	ID              int64      `json:"id" gorm:"primaryKey"`
+	Email           string     `json:"email" gorm:"column:email;not null"`
+	PasswordHash    string     `json:"-" gorm:"column:password_hash;not null"`
+	Role            string     `json:"role" gorm:"column:role;not null;default:user"`
+	DealerCode      *string    `json:"dealer_code" gorm:"column:dealer_code"`
+	IsActive        bool       `json:"is_active" gorm:"column:is_active;not null"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" gorm:"column:email_verified_at"`
+	TOTPSecret      *string    `json:"-" gorm:"column:totp_secret"`
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at" gorm:"column:totp_enabled_at"`
+	TOTPLastStep    int64      `json:"-" gorm:"column:totp_last_step;not null;default:0"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
+
+// IsPendingVerification — аккаунт создан, но email еще не подтвержден.
+func (u User) IsPendingVerification() bool {
+	return !u.IsActive && u.EmailVerifiedAt == nil
+}
+
+func (u User) MFAEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != nil
+}
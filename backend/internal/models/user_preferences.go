@@ -0,0 +1,35 @@
+// Package models for UserPreferences data
+package models
+
+import "time"
+
+const (
+	AsOfToday      = "today"
+	AsOfLastRepair = "last_repair"
+
+	LanguageRU = "ru"
+	LanguageEN = "en"
+)
+
+type UserPreferences struct {
+	UserID      int64     `json:"-" gorm:"column:user_id;primaryKey"`
+	Timezone    string    `json:"timezone" gorm:"column:timezone;not null;default:UTC"`
+	Language    string    `json:"language" gorm:"column:language;not null;default:ru"`
+	DefaultAsOf string    `json:"default_as_of" gorm:"column:default_as_of;not null;default:today"`
+	CreatedAt   time.Time `json:"-" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (UserPreferences) TableName() string {
+	return "user_preferences"
+}
+
+// DefaultUserPreferences — настройки пользователя, который их еще не менял.
+func DefaultUserPreferences(userID int64) UserPreferences {
+	return UserPreferences{
+		UserID:      userID,
+		Timezone:    "UTC",
+		Language:    LanguageRU,
+		DefaultAsOf: AsOfToday,
+	}
+}
@@ -0,0 +1,28 @@
+// Package models for VINWarrantyStatus data
+package models
+
+import "time"
+
+const (
+	WarrantyStatusOK = "ok"
+	// WarrantyStatusApproaching — сумма дней ремонта дошла до порога предупреждения.
+	WarrantyStatusApproaching = "approaching"
+	// WarrantyStatusExceeded — сумма дней ремонта превысила законный лимит.
+	WarrantyStatusExceeded = "exceeded"
+)
+
+// VINWarrantyStatus — статус текущего гарантийного года VIN на момент последнего пересчета.
+type VINWarrantyStatus struct {
+	VIN           string    `json:"vin" gorm:"primaryKey;column:vin"`
+	RetailDate    time.Time `json:"retail_date" gorm:"column:retail_date;type:date;not null"`
+	WarrantyStart time.Time `json:"warranty_start" gorm:"column:warranty_start;type:date;not null"`
+	WarrantyEnd   time.Time `json:"warranty_end" gorm:"column:warranty_end;type:date;not null"`
+	TotalDays     int       `json:"total_days" gorm:"column:total_days;not null"`
+	Status        string    `json:"status" gorm:"column:status;not null"`
+	// UpdatedAt — когда статус или сумма дней менялись последний раз.
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (VINWarrantyStatus) TableName() string {
+	return "vin_warranty_status"
+}
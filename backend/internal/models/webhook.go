@@ -0,0 +1,82 @@
+// Package models for Webhook data
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead — попытки кончились; доставку можно перезапустить вручную.
+	DeliveryDead = "dead"
+)
+
+// WebhookSubscription. У IsActive нет default в теге, как и у User: иначе подписка, созданная
+// выключенной, сохранилась бы включенной.
+type WebhookSubscription struct {
+	ID          int64  `json:"id" gorm:"primaryKey"`
+	URL         string `json:"url" gorm:"column:url;not null"`
+	Description string `json:"description" gorm:"column:description;not null;default:''"`
+	// SecretCiphertext — секрет подписи, зашифрованный SecretBox: для HMAC нужен исходный секрет.
+	SecretCiphertext string     `json:"-" gorm:"column:secret_ciphertext;not null"`
+	EventTypes       StringList `json:"event_types" gorm:"column:event_types;type:jsonb;not null"`
+	IsActive         bool       `json:"is_active" gorm:"column:is_active;not null"`
+	CreatedBy        *int64     `json:"created_by" gorm:"column:created_by"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDelivery — одно событие для одного подписчика. Строка живет и после доставки: это журнал.
+type WebhookDelivery struct {
+	ID             int64           `json:"id" gorm:"primaryKey"`
+	SubscriptionID int64           `json:"subscription_id" gorm:"column:subscription_id;not null"`
+	EventID        string          `json:"event_id" gorm:"column:event_id;not null"`
+	EventType      string          `json:"event_type" gorm:"column:event_type;not null"`
+	VIN            string          `json:"vin" gorm:"column:vin;not null"`
+	Payload        json.RawMessage `json:"-" gorm:"column:payload;type:jsonb;not null"`
+	Status         string          `json:"status" gorm:"column:status;not null;default:pending"`
+	Attempts       int             `json:"attempts" gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" gorm:"column:next_attempt_at;not null"`
+	LastStatusCode *int            `json:"last_status_code" gorm:"column:last_status_code"`
+	LastError      *string         `json:"last_error" gorm:"column:last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at" gorm:"column:delivered_at"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// StringList хранится в JSONB-массиве.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		l = StringList{}
+	}
+	raw, err := json.Marshal([]string(l))
+	return string(raw), err
+}
+
+func (l *StringList) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(l))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(l))
+	case nil:
+		*l = nil
+		return nil
+	default:
+		return errors.New("unsupported type for StringList")
+	}
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message — письмо перед отправкой: общее для SMTP и файлов .eml.
+type Message struct {
+	// ID — Message-ID без угловых скобок; при повторе отправки тот же.
+	ID      string
+	From    mail.Address
+	To      mail.Address
+	Subject string
+	Text    string
+	HTML    string
+	Date    time.Time
+}
+
+// Bytes собирает письмо в формате RFC 5322: multipart/alternative с текстовой и HTML-версией в UTF-8.
+func (m Message) Bytes() ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	if err := writePart(parts, "text/plain; charset=utf-8", m.Text); err != nil {
+		return nil, err
+	}
+	if err := writePart(parts, "text/html; charset=utf-8", m.HTML); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	header := func(name, value string) {
+		// значения заголовков — одна строка, иначе через адрес или тему можно дописать свои заголовки
+		value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, value)
+	}
+	header("From", m.From.String())
+	header("To", m.To.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", m.Date.Format(time.RFC1123Z))
+	header("Message-ID", "<"+m.ID+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", `multipart/alternative; boundary="`+parts.Boundary()+`"`)
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func writePart(parts *multipart.Writer, contentType, content string) error {
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	// в текстовом режиме переводы строк становятся CRLF
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMessage() Message {
+	return Message{
+		ID:      "42.1700000000@warranty-days",
+		From:    mail.Address{Name: "WARRANTY_DAYS", Address: "noreply@example.com"},
+		To:      mail.Address{Name: "Иван Петров", Address: "ivan@example.com"},
+		Subject: "VIN XTA21099012345678: превышен лимит дней ремонта (31 из 30)",
+		Text:    "Строка 1\nСтрока 2 с длинным текстом, который quoted-printable перенесет: " + strings.Repeat("ж", 60),
+		HTML:    "<p>Строка <strong>1</strong></p>\n<p>Строка 2</p>",
+		Date:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+// Письмо разбирается стандартным парсером: заголовки, тема в UTF-8 и обе версии тела.
+func TestMessageBytesRoundTrip(t *testing.T) {
+	msg := testMessage()
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Fatalf("Subject = %q, %v; want %q", subject, err, msg.Subject)
+	}
+	to, err := mail.ParseAddress(parsed.Header.Get("To"))
+	if err != nil || *to != msg.To {
+		t.Fatalf("To = %v, %v; want %v", to, err, msg.To)
+	}
+	if got := parsed.Header.Get("Message-ID"); got != "<"+msg.ID+">" {
+		t.Fatalf("Message-ID = %q", got)
+	}
+	if date, err := parsed.Header.Date(); err != nil || !date.Equal(msg.Date) {
+		t.Fatalf("Date = %v, %v; want %v", date, err, msg.Date)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", mediaType, err)
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{contentType: "text/plain; charset=utf-8", body: msg.Text},
+		{contentType: "text/html; charset=utf-8", body: msg.HTML},
+	} {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatalf("NextPart() error = %v", err)
+		}
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Fatalf("part Content-Type = %q, want %q", got, want.contentType)
+		}
+		// NextPart сам снимает quoted-printable
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != want.body {
+			t.Fatalf("part body = %q, want %q", got, want.body)
+		}
+	}
+}
+
+// Перевод строки в адресе, теме или ID не должен дописывать в письмо свои заголовки.
+func TestMessageBytesHeaderInjection(t *testing.T) {
+	const injected = "\r\nBcc: attacker@example.com"
+
+	tests := []struct {
+		name   string
+		change func(m *Message)
+	}{
+		{name: "subject", change: func(m *Message) { m.Subject += injected }},
+		{name: "recipient name", change: func(m *Message) { m.To.Name += injected }},
+		{name: "sender name", change: func(m *Message) { m.From.Name += injected }},
+		{name: "message id", change: func(m *Message) { m.ID += ">" + injected }},
+		{name: "bare line feed", change: func(m *Message) { m.ID += "\nBcc: attacker@example.com" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := testMessage()
+			tt.change(&msg)
+			raw, err := msg.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v", err)
+			}
+			if bcc := parsed.Header.Get("Bcc"); bcc != "" {
+				t.Fatalf("injected Bcc header: %q", bcc)
+			}
+			if len(parsed.Header) != 7 {
+				t.Fatalf("headers = %v, want exactly 7", parsed.Header)
+			}
+		})
+	}
+}
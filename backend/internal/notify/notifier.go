@@ -0,0 +1,171 @@
+// Package notify — письма пользователям: шаблоны на русском и английском, очередь отправки в Postgres
+// с повторами и отправка через SMTP, в файлы .eml или в лог.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"warranty_days/internal/config"
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+	"warranty_days/internal/worker"
+)
+
+type Config struct {
+	From mail.Address
+	// DefaultLanguage — для адресатов без настроек и писем до входа (подтверждение email).
+	DefaultLanguage string
+	// VerifyEmailURL — ссылка для подтверждения email с {token}; пусто — в письме только код.
+	VerifyEmailURL string
+
+	// PollInterval — как часто искать письма, у которых подошло время попытки.
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	Backoff      worker.Backoff
+	// SendTimeout — на одну попытку; на это время письмо арендуется у других инстансов.
+	SendTimeout time.Duration
+	// Retention — сколько хранить журнал отправленных и брошенных писем.
+	Retention time.Duration
+}
+
+func ConfigFromApp(cfg config.Config) Config {
+	return Config{
+		From:            cfg.NotifyFrom,
+		DefaultLanguage: cfg.NotifyDefaultLanguage,
+		VerifyEmailURL:  cfg.NotifyVerifyEmailURL,
+		PollInterval:    cfg.NotifyPollInterval,
+		BatchSize:       cfg.NotifyBatchSize,
+		MaxAttempts:     cfg.NotifyMaxAttempts,
+		Backoff:         worker.Backoff{Initial: cfg.NotifyBackoffInitial, Max: cfg.NotifyBackoffMax},
+		SendTimeout:     cfg.SMTPTimeout,
+		Retention:       cfg.NotifyRetention,
+	}
+}
+
+// thresholdTypes — события, о которых пишем пользователям, и тип письма для каждого.
+var thresholdTypes = map[string]string{
+	events.ThresholdApproaching: models.NotificationThresholdApproaching,
+	events.ThresholdExceeded:    models.NotificationThresholdExceeded,
+}
+
+type verificationData struct {
+	Token string
+	Link  string
+}
+
+type thresholdData struct {
+	VIN           string
+	WarrantyStart string
+	WarrantyEnd   string
+	TotalDays     int
+	PreviousDays  int
+	Threshold     int
+	Limit         int
+	DaysLeft      int
+}
+
+// Notifier собирает письма по шаблонам и ставит их в очередь; отправляет их Worker.
+// Реализует service.VerificationSender и events.Publisher (для outbox).
+type Notifier struct {
+	cfg              Config
+	notificationRepo *repo.NotificationRepo
+	templates        *Templates
+	nowFn            func() time.Time
+}
+
+func NewNotifier(cfg Config, notificationRepo *repo.NotificationRepo, templates *Templates) *Notifier {
+	return &Notifier{
+		cfg:              cfg,
+		notificationRepo: notificationRepo,
+		templates:        templates,
+		nowFn:            time.Now,
+	}
+}
+
+// SendEmailVerification ставит в очередь письмо с токеном подтверждения. Настроек языка у пользователя
+// до подтверждения нет, письмо уходит на языке по умолчанию.
+func (n *Notifier) SendEmailVerification(ctx context.Context, email, token string) error {
+	data := verificationData{Token: token}
+	if n.cfg.VerifyEmailURL != "" {
+		data.Link = strings.ReplaceAll(n.cfg.VerifyEmailURL, "{token}", url.QueryEscape(token))
+	}
+
+	notification, err := n.build(models.NotificationEmailVerification, email, n.cfg.DefaultLanguage, data)
+	if err != nil {
+		return err
+	}
+	return n.notificationRepo.Enqueue(ctx, []models.Notification{notification})
+}
+
+// Publish пишет о событиях warranty.threshold_* адресатам с ролью из NotificationRoles, кто не отказался от писем.
+// Остальные события пропускает. Повтор публикации не дублирует письма: они уникальны по событию и адресату.
+func (n *Notifier) Publish(ctx context.Context, published ...events.Event) error {
+	var notifications []models.Notification
+	for _, event := range published {
+		notificationType, ok := thresholdTypes[event.Type]
+		if !ok {
+			continue
+		}
+
+		var payload events.ThresholdData
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return fmt.Errorf("decode %s event %s: %w", event.Type, event.ID, err)
+		}
+		data := thresholdData{
+			VIN:           event.VIN,
+			WarrantyStart: payload.WarrantyStart,
+			WarrantyEnd:   payload.WarrantyEnd,
+			TotalDays:     payload.TotalDays,
+			PreviousDays:  payload.PreviousDays,
+			Threshold:     payload.Threshold,
+			Limit:         payload.Limit,
+			DaysLeft:      max(payload.Limit-payload.TotalDays, 0),
+		}
+
+		recipients, err := n.notificationRepo.Recipients(ctx, notificationType, models.NotificationRoles(notificationType))
+		if err != nil {
+			return err
+		}
+		for _, recipient := range recipients {
+			notification, err := n.build(notificationType, recipient.Email, recipient.Language, data)
+			if err != nil {
+				return err
+			}
+			notification.UserID = &recipient.UserID
+			notification.EventID = &event.ID
+			notifications = append(notifications, notification)
+		}
+	}
+
+	return n.notificationRepo.Enqueue(ctx, notifications)
+}
+
+func (n *Notifier) build(notificationType, recipient, language string, data any) (models.Notification, error) {
+	if !slices.Contains(Languages, language) {
+		language = n.cfg.DefaultLanguage
+	}
+
+	rendered, err := n.templates.Render(notificationType, language, data)
+	if err != nil {
+		return models.Notification{}, err
+	}
+	return models.Notification{
+		Type:          notificationType,
+		Recipient:     recipient,
+		Language:      language,
+		Subject:       rendered.Subject,
+		TextBody:      rendered.Text,
+		HTMLBody:      rendered.HTML,
+		Status:        models.NotificationPending,
+		NextAttemptAt: n.nowFn(),
+	}, nil
+}
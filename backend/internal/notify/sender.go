@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sender отправляет готовое письмо. Ошибка — попытка не удалась, Worker повторит ее позже.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogSender пишет письма в лог вместо отправки — поведение по умолчанию, пока почта не настроена.
+type LogSender struct {
+	logger *slog.Logger
+}
+
+func NewLogSender(logger *slog.Logger) *LogSender {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.logger.InfoContext(ctx, "email notification",
+		"message_id", msg.ID,
+		"to", msg.To.Address,
+		"subject", msg.Subject,
+		"text", msg.Text,
+	)
+	return nil
+}
+
+// EMLSender складывает письма файлами .eml в каталог — для разработки без почтового сервера.
+// Файл открывается любым почтовым клиентом.
+type EMLSender struct {
+	dir string
+}
+
+func NewEMLSender(dir string) *EMLSender {
+	return &EMLSender{dir: dir}
+}
+
+func (s *EMLSender) Send(_ context.Context, msg Message) error {
+	raw, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	// в письмах бывают токены — каталог и файлы только для владельца
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	id, _, _ := strings.Cut(msg.ID, "@")
+	name := fmt.Sprintf("%s-%s.eml", msg.Date.UTC().Format("20060102T150405Z"), id)
+	return os.WriteFile(filepath.Join(s.dir, name), raw, 0o600)
+}
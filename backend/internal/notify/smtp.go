@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"warranty_days/internal/config"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// TLS — один из config.SMTPTLS*.
+	TLS string
+	// Timeout — на всю отправку одного письма, от подключения до QUIT.
+	Timeout time.Duration
+}
+
+// SMTPSender отправляет письма через SMTP-сервер, новое соединение на каждое письмо.
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+func SMTPConfigFromApp(cfg config.Config) SMTPConfig {
+	return SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		TLS:      cfg.SMTPTLS,
+		Timeout:  cfg.SMTPTimeout,
+	}
+}
+
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	raw, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// net/smtp не знает про контекст: при отмене рвем соединение, и текущая команда вернет ошибку
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}
+	if s.cfg.TLS == config.SMTPTLSImplicit {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.cfg.TLS == config.SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		// PlainAuth отказывается отправлять пароль без TLS, кроме localhost
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(msg.From.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To.Address); err != nil {
+		return rejectedError{err: err}
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(raw); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return rejectedError{err: err}
+	}
+	// письмо уже принято: ошибка QUIT не повод отправлять его еще раз
+	_ = client.Quit()
+	return nil
+}
+
+// rejectedError — ответ сервера на адресата или на само письмо. Ошибки входа и подключения сюда не
+// попадают: это настройка сервиса, а не письмо.
+type rejectedError struct {
+	err error
+}
+
+func (e rejectedError) Error() string {
+	return e.err.Error()
+}
+
+func (e rejectedError) Unwrap() error {
+	return e.err
+}
+
+// permanent — сервер окончательно отклонил письмо или адресата (ответ 5xx): повтор ничего не изменит.
+func permanent(err error) bool {
+	var rejected rejectedError
+	var protoErr *textproto.Error
+	return errors.As(err, &rejected) && errors.As(rejected.err, &protoErr) && protoErr.Code >= 500
+}
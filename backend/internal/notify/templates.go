@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"warranty_days/internal/models"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Languages — языки писем; у каждого типа есть шаблоны на всех.
+var Languages = []string{models.LanguageRU, models.LanguageEN}
+
+// templateNames — файл шаблона для типа письма: templates/<язык>/<имя>.txt и .html.
+// В .txt, кроме текста письма, определен шаблон "subject" — тема.
+var templateNames = map[string]string{
+	models.NotificationEmailVerification:    "email_verification",
+	models.NotificationThresholdApproaching: "threshold_approaching",
+	models.NotificationThresholdExceeded:    "threshold_exceeded",
+}
+
+type templateKey struct {
+	notificationType string
+	language         string
+}
+
+// Templates — шаблоны писем, встроенные в бинарник.
+type Templates struct {
+	text map[templateKey]*texttemplate.Template
+	html map[templateKey]*htmltemplate.Template
+}
+
+// Rendered — готовое письмо: тема, текстовая и HTML-версия.
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// LoadTemplates разбирает все шаблоны. Ошибка, если для какого-то типа и языка шаблона нет: это
+// выясняется на старте, а не при первой отправке.
+func LoadTemplates() (*Templates, error) {
+	t := &Templates{
+		text: make(map[templateKey]*texttemplate.Template),
+		html: make(map[templateKey]*htmltemplate.Template),
+	}
+
+	for _, notificationType := range models.NotificationTypes {
+		name, ok := templateNames[notificationType]
+		if !ok {
+			return nil, fmt.Errorf("no template for notification type %s", notificationType)
+		}
+		for _, language := range Languages {
+			key := templateKey{notificationType: notificationType, language: language}
+			base := "templates/" + language + "/" + name
+
+			text, err := texttemplate.New(name+".txt").Option("missingkey=error").ParseFS(templateFS, base+".txt")
+			if err != nil {
+				return nil, fmt.Errorf("parse %s.txt: %w", base, err)
+			}
+			if text.Lookup("subject") == nil {
+				return nil, fmt.Errorf("%s.txt does not define subject", base)
+			}
+			html, err := htmltemplate.New(name+".html").Option("missingkey=error").ParseFS(templateFS, base+".html")
+			if err != nil {
+				return nil, fmt.Errorf("parse %s.html: %w", base, err)
+			}
+
+			t.text[key] = text
+			t.html[key] = html
+		}
+	}
+	return t, nil
+}
+
+func (t *Templates) Render(notificationType, language string, data any) (Rendered, error) {
+	key := templateKey{notificationType: notificationType, language: language}
+	text, ok := t.text[key]
+	if !ok {
+		return Rendered{}, fmt.Errorf("no %s template for notification type %s", language, notificationType)
+	}
+
+	var subject, body, html bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Rendered{}, fmt.Errorf("render subject: %w", err)
+	}
+	if err := text.Execute(&body, data); err != nil {
+		return Rendered{}, fmt.Errorf("render text: %w", err)
+	}
+	if err := t.html[key].Execute(&html, data); err != nil {
+		return Rendered{}, fmt.Errorf("render html: %w", err)
+	}
+
+	return Rendered{
+		// тема — одна строка: переводы строк в заголовке письма недопустимы
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    body.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+
+	"warranty_days/internal/models"
+)
+
+func TestTemplatesRender(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	threshold := thresholdData{
+		VIN:           "XTA21099012345678",
+		WarrantyStart: "2025-06-01",
+		WarrantyEnd:   "2026-05-31",
+		TotalDays:     31,
+		PreviousDays:  28,
+		Threshold:     30,
+		Limit:         30,
+		DaysLeft:      0,
+	}
+
+	tests := []struct {
+		name             string
+		notificationType string
+		language         string
+		data             any
+		wantSubject      string
+		wantText         []string
+		wantHTML         []string
+	}{
+		{
+			name:             "exceeded ru",
+			notificationType: models.NotificationThresholdExceeded,
+			language:         models.LanguageRU,
+			data:             threshold,
+			wantSubject:      "VIN XTA21099012345678: превышен лимит дней ремонта (31 из 30)",
+			wantText:         []string{"Дней в ремонте: 31 (было 28)", "2025-06-01 — 2026-05-31"},
+			wantHTML:         []string{`<html lang="ru">`, "XTA21099012345678"},
+		},
+		{
+			name:             "exceeded en",
+			notificationType: models.NotificationThresholdExceeded,
+			language:         models.LanguageEN,
+			data:             threshold,
+			wantSubject:      "VIN XTA21099012345678: repair day limit exceeded (31 of 30)",
+			wantText:         []string{"Days in repair: 31 (was 28)", "Limit: 30"},
+			wantHTML:         []string{`<html lang="en">`, "Days in repair</td><td>31 (was 28)"},
+		},
+		{
+			name:             "approaching ru",
+			notificationType: models.NotificationThresholdApproaching,
+			language:         models.LanguageRU,
+			data:             threshold,
+			wantSubject:      "VIN XTA21099012345678: 31 дн. ремонта из 30",
+			wantText:         []string{"XTA21099012345678"},
+			wantHTML:         []string{`<html lang="ru">`},
+		},
+		{
+			name:             "approaching en",
+			notificationType: models.NotificationThresholdApproaching,
+			language:         models.LanguageEN,
+			data:             threshold,
+			wantSubject:      "VIN XTA21099012345678: 31 of 30 repair days",
+			wantText:         []string{"XTA21099012345678"},
+			wantHTML:         []string{`<html lang="en">`},
+		},
+		{
+			name:             "verification link ru",
+			notificationType: models.NotificationEmailVerification,
+			language:         models.LanguageRU,
+			data:             verificationData{Token: "tok123", Link: "https://app.example.com/verify?token=tok123"},
+			wantSubject:      "Подтвердите email в WARRANTY_DAYS",
+			wantText:         []string{"Перейдите по ссылке:\nhttps://app.example.com/verify?token=tok123"},
+			wantHTML:         []string{`<a href="https://app.example.com/verify?token=tok123">Подтвердить email</a>`},
+		},
+		{
+			name:             "verification code en",
+			notificationType: models.NotificationEmailVerification,
+			language:         models.LanguageEN,
+			data:             verificationData{Token: "tok123"},
+			wantSubject:      "Confirm your email for WARRANTY_DAYS",
+			wantText:         []string{"tok123"},
+			wantHTML:         []string{"tok123"},
+		},
+		// значения подставляются в HTML экранированными, в текст — как есть
+		{
+			name:             "html escaping",
+			notificationType: models.NotificationThresholdExceeded,
+			language:         models.LanguageEN,
+			data:             thresholdData{VIN: `<script>alert("x")</script>`, TotalDays: 31, Limit: 30},
+			wantSubject:      `VIN <script>alert("x")</script>: repair day limit exceeded (31 of 30)`,
+			wantText:         []string{`<script>alert("x")</script>`},
+			wantHTML:         []string{"&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := templates.Render(tt.notificationType, tt.language, tt.data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if rendered.Subject != tt.wantSubject {
+				t.Fatalf("Subject = %q, want %q", rendered.Subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(rendered.Text, want) {
+					t.Fatalf("Text does not contain %q:\n%s", want, rendered.Text)
+				}
+			}
+			for _, want := range tt.wantHTML {
+				if !strings.Contains(rendered.HTML, want) {
+					t.Fatalf("HTML does not contain %q:\n%s", want, rendered.HTML)
+				}
+			}
+			if strings.Contains(rendered.HTML, "<script>") {
+				t.Fatalf("HTML contains unescaped script:\n%s", rendered.HTML)
+			}
+		})
+	}
+}
+
+func TestTemplatesRenderErrors(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		notificationType string
+		language         string
+		data             any
+	}{
+		{
+			name:             "unknown language",
+			notificationType: models.NotificationThresholdExceeded,
+			language:         "de",
+			data:             thresholdData{},
+		},
+		{name: "unknown type", notificationType: "claim.created", language: models.LanguageEN, data: thresholdData{}},
+		// данные не того типа: поля VIN у verificationData нет
+		{
+			name:             "wrong data",
+			notificationType: models.NotificationThresholdExceeded,
+			language:         models.LanguageEN,
+			data:             verificationData{},
+		},
+		{
+			name:             "missing map key",
+			notificationType: models.NotificationEmailVerification,
+			language:         models.LanguageRU,
+			data:             map[string]string{"Token": "tok123"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := templates.Render(tt.notificationType, tt.language, tt.data); err == nil {
+				t.Fatal("Render() error = nil, want error")
+			}
+		})
+	}
+}
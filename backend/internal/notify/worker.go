@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+	"warranty_days/internal/worker"
+)
+
+// pruneInterval — как часто удалять журнал писем старше Retention.
+const pruneInterval = time.Hour
+
+// Worker отправляет письма из очереди. Инстансов может быть несколько: письмо забирает один из них.
+type Worker struct {
+	cfg              Config
+	notificationRepo *repo.NotificationRepo
+	sender           Sender
+	logger           *slog.Logger
+	poller           *worker.Poller
+}
+
+func NewWorker(cfg Config, notificationRepo *repo.NotificationRepo, sender Sender, logger *slog.Logger) *Worker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	w := &Worker{
+		cfg:              cfg,
+		notificationRepo: notificationRepo,
+		sender:           sender,
+		logger:           logger,
+	}
+	w.poller = &worker.Poller{
+		Interval:      cfg.PollInterval,
+		Poll:          w.poll,
+		Prune:         w.prune,
+		PruneInterval: pruneInterval,
+	}
+	return w
+}
+
+func (w *Worker) Start() {
+	w.poller.Start()
+}
+
+// Shutdown прерывает текущие отправки и ждет выхода из цикла. Прерванное письмо снова возьмется после
+// аренды; если сервер успел его принять, адресат получит его дважды.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	return w.poller.Shutdown(ctx)
+}
+
+func (w *Worker) poll(ctx context.Context) {
+	// пока пачка полная, в очереди, скорее всего, есть еще
+	for ctx.Err() == nil {
+		lease := w.cfg.SendTimeout + 30*time.Second
+		notifications, err := w.notificationRepo.ClaimDue(ctx, w.cfg.BatchSize, lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.WarnContext(ctx, "failed to claim notifications", "error", err)
+			}
+			return
+		}
+
+		// письма уходят по одному: почтовые серверы ограничивают число одновременных соединений
+		for _, notification := range notifications {
+			w.deliver(ctx, notification)
+		}
+		if len(notifications) < w.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+func (w *Worker) deliver(ctx context.Context, notification models.Notification) {
+	sendErr := w.sender.Send(ctx, w.message(notification))
+	if ctx.Err() != nil {
+		// остановка сервера — попытку не засчитываем
+		return
+	}
+
+	now := time.Now()
+	attempt := repo.NotificationAttempt{
+		Attempts:      notification.Attempts + 1,
+		NextAttemptAt: now,
+	}
+
+	switch {
+	case sendErr == nil:
+		attempt.Status = models.NotificationSent
+		attempt.SentAt = &now
+	case attempt.Attempts >= w.cfg.MaxAttempts || permanent(sendErr):
+		attempt.Status = models.NotificationDead
+		msg := sendErr.Error()
+		attempt.Error = &msg
+		w.logger.WarnContext(ctx, "notification dropped",
+			"notification_id", notification.ID,
+			"type", notification.Type,
+			"attempts", attempt.Attempts,
+			"error", sendErr,
+		)
+	default:
+		attempt.Status = models.NotificationPending
+		attempt.NextAttemptAt = now.Add(w.cfg.Backoff.Delay(attempt.Attempts))
+		msg := sendErr.Error()
+		attempt.Error = &msg
+	}
+
+	if err := w.notificationRepo.RecordAttempt(ctx, notification.ID, attempt); err != nil {
+		w.logger.WarnContext(ctx, "failed to record notification attempt",
+			"notification_id", notification.ID,
+			"error", err,
+		)
+	}
+}
+
+func (w *Worker) message(notification models.Notification) Message {
+	_, domain, _ := strings.Cut(w.cfg.From.Address, "@")
+	return Message{
+		// один Message-ID на все попытки: почтовый клиент склеит дубликат, если письмо ушло дважды
+		ID:      fmt.Sprintf("notification-%d@%s", notification.ID, domain),
+		From:    w.cfg.From,
+		To:      mail.Address{Address: notification.Recipient},
+		Subject: notification.Subject,
+		Text:    notification.TextBody,
+		HTML:    notification.HTMLBody,
+		Date:    time.Now(),
+	}
+}
+
+func (w *Worker) prune(ctx context.Context) {
+	deleted, err := w.notificationRepo.DeleteFinishedBefore(ctx, time.Now().Add(-w.cfg.Retention))
+	if err != nil {
+		w.logger.WarnContext(ctx, "failed to prune notifications", "error", err)
+		return
+	}
+	if deleted > 0 {
+		w.logger.InfoContext(ctx, "notifications pruned", "deleted", deleted)
+	}
+}
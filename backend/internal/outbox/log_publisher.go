@@ -0,0 +1,33 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+
+	"warranty_days/internal/events"
+)
+
+// LogPublisher пишет события в лог: поток событий виден без внешних получателей.
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LogPublisher{logger: logger}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, published ...events.Event) error {
+	for _, event := range published {
+		p.logger.InfoContext(ctx, "domain event",
+			"event_id", event.ID,
+			"event_type", event.Type,
+			"vin", event.VIN,
+			"occurred_at", event.OccurredAt,
+			"data", string(event.Data),
+		)
+	}
+	return nil
+}
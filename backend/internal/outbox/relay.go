@@ -0,0 +1,212 @@
+// Package outbox отдает получателям доменные события, записанные в таблицу outbox в одной транзакции
+// с изменением заявок: событие не теряется при падении после коммита и не уходит, если транзакция откатилась.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"warranty_days/internal/config"
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/worker"
+)
+
+// pruneInterval — как часто удалять отправленные сообщения старше Retention.
+const pruneInterval = time.Hour
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts — после стольких неудач сообщение бросается (dead_at).
+	MaxAttempts int
+	// Backoff начинается с PollInterval.
+	Backoff worker.Backoff
+	// Retention — сколько хранить отправленные и брошенные сообщения.
+	Retention time.Duration
+}
+
+func ConfigFromApp(cfg config.Config) Config {
+	return Config{
+		PollInterval: cfg.OutboxPollInterval,
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+		Backoff:      worker.Backoff{Initial: cfg.OutboxPollInterval, Max: cfg.OutboxBackoffMax},
+		Retention:    cfg.OutboxRetention,
+	}
+}
+
+// Store — очередь сообщений outbox; реализация — repo.OutboxRepo.
+type Store interface {
+	LockPending(ctx context.Context, limit int) ([]models.OutboxMessage, error)
+	MarkPublished(ctx context.Context, ids []int64, now time.Time) error
+	RecordFailure(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkDead(ctx context.Context, id int64, attempts int, now time.Time, lastError string) error
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Transactor — транзакция пачки и точки сохранения получателей; реализация — repo.TxManager.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	WithinSavepoint(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Relay читает неотправленные сообщения и по очереди отдает их всем получателям. Доставка «хотя бы один
+// раз»: если один получатель ответил ошибкой, сообщение повторяется для всех, поэтому получатели отбрасывают
+// повторы по Event.ID. События одного VIN приходят в порядке записи; инстансов relay может быть несколько.
+// Сообщение, не ушедшее за MaxAttempts попыток, бросается, чтобы не держать свой VIN бесконечно.
+type Relay struct {
+	cfg        Config
+	outboxRepo Store
+	txManager  Transactor
+	publishers []events.Publisher
+	logger     *slog.Logger
+	poller     *worker.Poller
+}
+
+func NewRelay(
+	cfg Config,
+	outboxRepo Store,
+	txManager Transactor,
+	publishers []events.Publisher,
+	logger *slog.Logger,
+) *Relay {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := &Relay{
+		cfg:        cfg,
+		outboxRepo: outboxRepo,
+		txManager:  txManager,
+		publishers: publishers,
+		logger:     logger,
+	}
+	r.poller = &worker.Poller{
+		Interval:      cfg.PollInterval,
+		Poll:          r.poll,
+		Prune:         r.prune,
+		PruneInterval: pruneInterval,
+	}
+	return r
+}
+
+func (r *Relay) Start() {
+	r.poller.Start()
+}
+
+// Shutdown прерывает текущую пачку и ждет выхода из цикла. Транзакция пачки откатывается, сообщения
+// остаются неотправленными и уйдут после перезапуска.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	return r.poller.Shutdown(ctx)
+}
+
+func (r *Relay) poll(ctx context.Context) {
+	// пока пачка полная, в очереди, скорее всего, есть еще
+	for ctx.Err() == nil {
+		locked, err := r.relayBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.WarnContext(ctx, "failed to relay outbox messages", "error", err)
+			}
+			return
+		}
+		if locked < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// relayBatch отправляет одну пачку в транзакции, которая держит блокировку сообщений.
+// Возвращает число заблокированных сообщений.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	var locked int
+	err := r.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		messages, err := r.outboxRepo.LockPending(ctx, r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		locked = len(messages)
+
+		now := time.Now()
+		failedVINs := make(map[string]bool)
+		published := make([]int64, 0, len(messages))
+		for _, msg := range messages {
+			// после неудачи остальные сообщения VIN ждут, иначе порядок нарушится
+			if failedVINs[msg.VIN] {
+				continue
+			}
+
+			publishErr := r.publish(ctx, msg)
+			if publishErr == nil {
+				published = append(published, msg.ID)
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			attempts := msg.Attempts + 1
+			if attempts >= r.cfg.MaxAttempts {
+				// брошенное сообщение VIN не держит: следующие уходят, даже если они в этой же пачке
+				r.logger.ErrorContext(ctx, "outbox message dropped",
+					"event_id", msg.EventID,
+					"event_type", msg.EventType,
+					"vin", msg.VIN,
+					"attempts", attempts,
+					"error", publishErr,
+				)
+				if err := r.outboxRepo.MarkDead(ctx, msg.ID, attempts, now, publishErr.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			failedVINs[msg.VIN] = true
+			r.logger.WarnContext(ctx, "outbox message publish failed",
+				"event_id", msg.EventID,
+				"event_type", msg.EventType,
+				"attempts", attempts,
+				"error", publishErr,
+			)
+			err := r.outboxRepo.RecordFailure(ctx, msg.ID, attempts, now.Add(r.cfg.Backoff.Delay(attempts)), publishErr.Error())
+			if err != nil {
+				return err
+			}
+		}
+
+		return r.outboxRepo.MarkPublished(ctx, published, now)
+	})
+	return locked, err
+}
+
+// publish отдает сообщение всем получателям в точке сохранения: если получатель пишет в БД и падает,
+// откатывается только его запись, а транзакция пачки продолжает работать.
+func (r *Relay) publish(ctx context.Context, msg models.OutboxMessage) error {
+	var event events.Event
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return fmt.Errorf("decode outbox payload: %w", err)
+	}
+
+	return r.txManager.WithinSavepoint(ctx, func(ctx context.Context) error {
+		for _, publisher := range r.publishers {
+			if err := publisher.Publish(ctx, event); err != nil {
+				return fmt.Errorf("%T: %w", publisher, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Relay) prune(ctx context.Context) {
+	deleted, err := r.outboxRepo.DeleteFinishedBefore(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		r.logger.WarnContext(ctx, "failed to prune outbox", "error", err)
+		return
+	}
+	if deleted > 0 {
+		r.logger.InfoContext(ctx, "outbox pruned", "deleted", deleted)
+	}
+}
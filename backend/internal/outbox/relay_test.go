@@ -0,0 +1,246 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/worker"
+)
+
+// fakeStore повторяет выборку lockPendingOutboxSQL: сообщение VIN отдается, только если все более ранние
+// неотправленные и не брошенные сообщения этого VIN попали в ту же пачку.
+type fakeStore struct {
+	messages []*models.OutboxMessage
+}
+
+func (s *fakeStore) LockPending(_ context.Context, limit int) ([]models.OutboxMessage, error) {
+	now := time.Now()
+	due := make([]*models.OutboxMessage, 0, limit)
+	for _, msg := range s.messages {
+		if msg.PublishedAt == nil && msg.DeadAt == nil && !msg.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, msg)
+		}
+	}
+
+	locked := make([]models.OutboxMessage, 0, len(due))
+	for _, msg := range due {
+		blocked := false
+		for _, earlier := range s.messages {
+			if earlier.ID < msg.ID && earlier.VIN == msg.VIN && earlier.PublishedAt == nil &&
+				earlier.DeadAt == nil && !slices.Contains(due, earlier) {
+				blocked = true
+			}
+		}
+		if !blocked {
+			locked = append(locked, *msg)
+		}
+	}
+	return locked, nil
+}
+
+func (s *fakeStore) MarkPublished(_ context.Context, ids []int64, now time.Time) error {
+	for _, id := range ids {
+		msg := s.get(id)
+		msg.PublishedAt = &now
+		msg.LastError = nil
+	}
+	return nil
+}
+
+func (s *fakeStore) RecordFailure(
+	_ context.Context,
+	id int64,
+	attempts int,
+	nextAttemptAt time.Time,
+	lastError string,
+) error {
+	msg := s.get(id)
+	msg.Attempts = attempts
+	msg.NextAttemptAt = nextAttemptAt
+	msg.LastError = &lastError
+	return nil
+}
+
+func (s *fakeStore) MarkDead(_ context.Context, id int64, attempts int, now time.Time, lastError string) error {
+	msg := s.get(id)
+	msg.Attempts = attempts
+	msg.DeadAt = &now
+	msg.LastError = &lastError
+	return nil
+}
+
+func (s *fakeStore) DeleteFinishedBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *fakeStore) get(id int64) *models.OutboxMessage {
+	for _, msg := range s.messages {
+		if msg.ID == id {
+			return msg
+		}
+	}
+	panic("unknown outbox message")
+}
+
+// fakeTx выполняет fn без транзакции: блокировки и откаты здесь не проверяются.
+type fakeTx struct{}
+
+func (fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (fakeTx) WithinSavepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakePublisher отвечает ошибкой на события из failing и запоминает принятые.
+type fakePublisher struct {
+	failing   map[string]bool
+	published []string
+}
+
+func (p *fakePublisher) Publish(_ context.Context, batch ...events.Event) error {
+	for _, event := range batch {
+		if p.failing[event.ID] {
+			return errors.New("receiver unavailable")
+		}
+		p.published = append(p.published, event.ID)
+	}
+	return nil
+}
+
+func outboxMessage(t *testing.T, id int64, vin string) *models.OutboxMessage {
+	t.Helper()
+	payload, err := json.Marshal(events.Event{ID: eventID(id), Type: events.ClaimUpdated, VIN: vin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.OutboxMessage{
+		ID:            id,
+		EventID:       eventID(id),
+		EventType:     events.ClaimUpdated,
+		VIN:           vin,
+		Payload:       payload,
+		NextAttemptAt: time.Now().Add(-time.Minute),
+	}
+}
+
+func eventID(id int64) string {
+	return fmt.Sprintf("evt-%d", id)
+}
+
+func newTestRelay(store *fakeStore, publisher *fakePublisher) *Relay {
+	return NewRelay(
+		Config{
+			PollInterval: time.Second,
+			BatchSize:    10,
+			MaxAttempts:  3,
+			Backoff:      worker.Backoff{Initial: time.Minute, Max: time.Hour},
+		},
+		store,
+		fakeTx{},
+		[]events.Publisher{publisher},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+}
+
+func TestRelayHoldsBackLaterMessagesOfFailedVIN(t *testing.T) {
+	store := &fakeStore{messages: []*models.OutboxMessage{
+		outboxMessage(t, 1, "VIN-A"),
+		outboxMessage(t, 2, "VIN-B"),
+		outboxMessage(t, 3, "VIN-A"),
+	}}
+	publisher := &fakePublisher{failing: map[string]bool{eventID(1): true}}
+	relay := newTestRelay(store, publisher)
+
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{eventID(2)}; !slices.Equal(publisher.published, want) {
+		t.Fatalf("published = %v, want %v: VIN-A must wait for message 1", publisher.published, want)
+	}
+	failed := store.get(1)
+	if failed.Attempts != 1 || failed.LastError == nil || !failed.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("message 1 = attempts %d, last_error %v, next attempt %v; want a scheduled retry",
+			failed.Attempts, failed.LastError, failed.NextAttemptAt)
+	}
+	if held := store.get(3); held.Attempts != 0 || held.PublishedAt != nil {
+		t.Fatalf("message 3 = attempts %d, published %v; want untouched", held.Attempts, held.PublishedAt)
+	}
+
+	// пока 1 ждет повтора, 3 не отдается и в следующих пачках
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher.published) != 1 {
+		t.Fatalf("published = %v, want message 3 held back until message 1 is sent", publisher.published)
+	}
+
+	// получатель ожил, повтор наступил: VIN-A уходит в порядке записи
+	publisher.failing = nil
+	failed.NextAttemptAt = time.Now().Add(-time.Second)
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{eventID(2), eventID(1), eventID(3)}; !slices.Equal(publisher.published, want) {
+		t.Fatalf("published = %v, want %v", publisher.published, want)
+	}
+	if failed.LastError != nil {
+		t.Fatalf("message 1 last_error = %q after publish, want cleared", *failed.LastError)
+	}
+}
+
+func TestRelayDeadLettersAfterMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name          string
+		attemptsSoFar int
+		wantDead      bool
+		wantPublished []string
+	}{
+		{
+			name:          "last attempt fails: message dropped, VIN released",
+			attemptsSoFar: 2,
+			wantDead:      true,
+			wantPublished: []string{eventID(2)},
+		},
+		{
+			name:          "attempts left: VIN keeps waiting",
+			attemptsSoFar: 1,
+			wantDead:      false,
+			wantPublished: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := outboxMessage(t, 1, "VIN-A")
+			first.Attempts = tt.attemptsSoFar
+			store := &fakeStore{messages: []*models.OutboxMessage{first, outboxMessage(t, 2, "VIN-A")}}
+			publisher := &fakePublisher{failing: map[string]bool{eventID(1): true}}
+
+			if _, err := newTestRelay(store, publisher).relayBatch(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := first.DeadAt != nil; got != tt.wantDead {
+				t.Fatalf("message 1 dead = %v, want %v", got, tt.wantDead)
+			}
+			if first.Attempts != tt.attemptsSoFar+1 || first.LastError == nil {
+				t.Fatalf("message 1 = attempts %d, last_error %v; want attempts %d with error",
+					first.Attempts, first.LastError, tt.attemptsSoFar+1)
+			}
+			if !slices.Equal(publisher.published, tt.wantPublished) {
+				t.Fatalf("published = %v, want %v", publisher.published, tt.wantPublished)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+// Package pgnotify слушает уведомления PostgreSQL об изменении заявок (LISTEN claim_changes) и раздает их
+// подписчикам внутри процесса: так поток /claims/stream видит изменения, сделанные через любой инстанс API.
+package pgnotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"warranty_days/internal/config"
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+	"warranty_days/internal/worker"
+)
+
+const (
+	// Channel — канал pg_notify, в который пишет триггер claims_notify_change.
+	Channel = "claim_changes"
+
+	// reconnectInitial — пауза перед первой попыткой переподключения, дальше она удваивается.
+	reconnectInitial = 500 * time.Millisecond
+	// pingInterval — как часто проверять соединение без уведомлений: оборванный TCP иначе не заметить.
+	pingInterval = 30 * time.Second
+	// pruneInterval — как часто удалять изменения старше Retention.
+	pruneInterval = time.Hour
+	// catchUpBatch — сколько изменений читать из журнала за раз при догоне.
+	catchUpBatch = 500
+	// catchUpOverlap — догон начинается с изменений, записанных чуть раньше последнего полученного:
+	// транзакция с меньшим id могла закоммититься позже и прийти уже после обрыва.
+	catchUpOverlap = time.Minute
+)
+
+type Config struct {
+	// ReconnectMax — предел паузы между попытками переподключения.
+	ReconnectMax time.Duration
+	// Retention — сколько хранить журнал изменений; обрыв дольше этого догнать не получится.
+	Retention time.Duration
+}
+
+func ConfigFromApp(cfg config.Config) Config {
+	return Config{
+		ReconnectMax: cfg.ClaimNotifyReconnectMax,
+		Retention:    cfg.ClaimChangesRetention,
+	}
+}
+
+// Listener держит отдельное соединение с LISTEN и отдает каждое изменение заявки получателю как событие
+// claim.*. После обрыва он переподключается и добирает из журнала claim_changes то, что пришло без него.
+// Повторы возможны (догон перекрывается с уведомлениями), получатели сверяют состояние сами.
+type Listener struct {
+	cfg        Config
+	pool       *pgxpool.Pool
+	changeRepo *repo.ClaimChangeRepo
+	publisher  events.Publisher
+	logger     *slog.Logger
+
+	// последнее полученное изменение: с него начинается догон после переподключения
+	lastID        int64
+	lastChangedAt time.Time
+	lastPrune     time.Time
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+func NewListener(
+	cfg Config,
+	pool *pgxpool.Pool,
+	changeRepo *repo.ClaimChangeRepo,
+	publisher events.Publisher,
+	logger *slog.Logger,
+) *Listener {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Listener{
+		cfg:        cfg,
+		pool:       pool,
+		changeRepo: changeRepo,
+		publisher:  publisher,
+		logger:     logger,
+	}
+}
+
+func (l *Listener) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.stop = cancel
+	l.done = make(chan struct{})
+
+	go func() {
+		defer close(l.done)
+
+		backoff := worker.Backoff{Initial: reconnectInitial, Max: l.cfg.ReconnectMax}
+		failures := 0
+		for {
+			connected, err := l.listen(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if connected {
+				failures = 0
+			}
+			failures++
+			delay := backoff.Delay(failures)
+			l.logger.WarnContext(ctx, "claim notifications listener disconnected, reconnecting",
+				"retry_in", delay.String(),
+				"error", err,
+			)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// Shutdown закрывает соединение LISTEN и ждет выхода из цикла.
+func (l *Listener) Shutdown(ctx context.Context) error {
+	if l.stop == nil {
+		return nil
+	}
+	l.stop()
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// listen слушает канал до ошибки соединения. connected — LISTEN успел выполниться.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	poolConn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	// соединение с LISTEN в пул не возвращаем: другой запрос получил бы чужие уведомления
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return false, err
+	}
+
+	// догоняем после LISTEN: изменение, закоммиченное между догоном и ожиданием, придет уведомлением
+	if err := l.catchUp(ctx); err != nil {
+		return true, fmt.Errorf("catch up claim changes: %w", err)
+	}
+
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, pingInterval)
+		notification, err := conn.WaitForNotification(waitCtx)
+		cancel()
+
+		switch {
+		case err == nil:
+			var change models.ClaimChange
+			if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+				l.logger.WarnContext(ctx, "invalid claim change notification",
+					"payload", notification.Payload,
+					"error", err,
+				)
+				continue
+			}
+			l.deliver(ctx, change)
+		case ctx.Err() != nil:
+			return true, ctx.Err()
+		case errors.Is(err, context.DeadlineExceeded):
+			// уведомлений не было: проверяем, что соединение живо
+			if err := conn.Ping(ctx); err != nil {
+				return true, err
+			}
+		default:
+			return true, err
+		}
+		l.prune(ctx)
+	}
+}
+
+// catchUp отдает изменения, записанные, пока соединения не было. При первом подключении журнал не читается:
+// до старта процесса подписчиков не было.
+func (l *Listener) catchUp(ctx context.Context) error {
+	if l.lastChangedAt.IsZero() {
+		latest, err := l.changeRepo.LatestID(ctx)
+		if err != nil {
+			return err
+		}
+		l.lastID = latest
+		l.lastChangedAt = time.Now()
+		return nil
+	}
+
+	afterID := l.lastID
+	first, err := l.changeRepo.FirstIDSince(ctx, l.lastChangedAt.Add(-catchUpOverlap))
+	if err != nil {
+		return err
+	}
+	if first > 0 {
+		afterID = min(afterID, first-1)
+	}
+
+	caughtUp := 0
+	for {
+		changes, err := l.changeRepo.ListAfter(ctx, afterID, catchUpBatch)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			l.deliver(ctx, change)
+			afterID = change.ID
+		}
+		caughtUp += len(changes)
+		if len(changes) < catchUpBatch {
+			break
+		}
+	}
+	if caughtUp > 0 {
+		l.logger.InfoContext(ctx, "claim changes caught up after reconnect", "changes", caughtUp)
+	}
+	return nil
+}
+
+func (l *Listener) deliver(ctx context.Context, change models.ClaimChange) {
+	l.lastID = max(l.lastID, change.ID)
+	if change.ChangedAt.After(l.lastChangedAt) {
+		l.lastChangedAt = change.ChangedAt
+	}
+
+	event, err := changeEvent(change)
+	if err == nil {
+		err = l.publisher.Publish(ctx, event)
+	}
+	if err != nil {
+		l.logger.WarnContext(ctx, "failed to publish claim change", "change_id", change.ID, "error", err)
+	}
+}
+
+func (l *Listener) prune(ctx context.Context) {
+	now := time.Now()
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	deleted, err := l.changeRepo.DeleteBefore(ctx, now.Add(-l.cfg.Retention))
+	if err != nil {
+		l.logger.WarnContext(ctx, "failed to prune claim changes", "error", err)
+		return
+	}
+	if deleted > 0 {
+		l.logger.InfoContext(ctx, "claim changes pruned", "deleted", deleted)
+	}
+}
+
+// changeEvent — изменение в виде события claim.*. В data только id, vin и previous_vin: дат заявки
+// в журнале нет. ID стабилен для одного изменения, повтор после догона можно отбросить по нему.
+func changeEvent(change models.ClaimChange) (events.Event, error) {
+	data := events.ClaimData{ID: change.ClaimID, VIN: change.VIN}
+	if change.PreviousVIN != nil {
+		data.PreviousVIN = *change.PreviousVIN
+	}
+	event, err := events.New(change.EventType, change.VIN, data, change.ChangedAt)
+	if err != nil {
+		return events.Event{}, err
+	}
+	event.ID = fmt.Sprintf("%s:%d", Channel, change.ID)
+	return event, nil
+}
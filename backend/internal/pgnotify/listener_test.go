@@ -0,0 +1,92 @@
+package pgnotify
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+type recordingPublisher struct {
+	ids []string
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, published ...events.Event) error {
+	for _, event := range published {
+		p.ids = append(p.ids, event.ID)
+	}
+	return nil
+}
+
+func TestCatchUpAfterReconnect(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := &recordingPublisher{}
+	l := NewListener(Config{}, nil, repo.NewClaimChangeRepo(db), publisher, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	// первое подключение: журнал до старта не отдается, запоминается только последний id
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(id\), 0\) FROM "claim_changes"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(41))
+	if err := l.catchUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher.ids) != 0 {
+		t.Fatalf("first connect published %v, want nothing", publisher.ids)
+	}
+
+	// изменение пришло уведомлением позже, чем подключились
+	changedAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	l.deliver(ctx, models.ClaimChange{
+		ID: 42, EventType: events.ClaimUpdated, ClaimID: 7, VIN: "VIN1", ChangedAt: changedAt,
+	})
+
+	// после обрыва догон начинается с изменений за catchUpOverlap до последнего полученного:
+	// изменение 40 закоммитилось позже 42 и уведомлением не пришло
+	mock.ExpectQuery(`SELECT COALESCE\(MIN\(id\), 0\) FROM "claim_changes" WHERE changed_at >= \$1`).
+		WithArgs(changedAt.Add(-catchUpOverlap)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(40))
+	rows := sqlmock.NewRows([]string{"id", "event_type", "claim_id", "vin", "changed_at"})
+	for _, id := range []int64{40, 42, 43} {
+		rows.AddRow(id, events.ClaimUpdated, 7, "VIN1", changedAt)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "claim_changes" WHERE id > \$1 ORDER BY id LIMIT \$2`).
+		WithArgs(39, catchUpBatch).
+		WillReturnRows(rows)
+	if err := l.catchUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// повтор 42 допустим: получатели отбрасывают его по id события
+	want := []string{"claim_changes:42", "claim_changes:40", "claim_changes:42", "claim_changes:43"}
+	if !slices.Equal(publisher.ids, want) {
+		t.Fatalf("published %v, want %v", publisher.ids, want)
+	}
+	if l.lastID != 43 {
+		t.Fatalf("lastID = %d, want 43", l.lastID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
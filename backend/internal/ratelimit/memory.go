@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто MemoryLimiter выбрасывает заполнившиеся корзины.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+// refill возвращает число токенов на момент now, не больше емкости корзины.
+func (b *bucket) refill(now time.Time) float64 {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	return min(float64(b.limit.Requests), b.tokens+elapsed*b.limit.rate())
+}
+
+// MemoryLimiter хранит корзины в памяти процесса: при нескольких инстансах у каждого свой счетчик.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), updatedAt: now, limit: limit}
+		l.buckets[key] = b
+	}
+
+	b.tokens = b.refill(now)
+	b.updatedAt = now
+	if b.tokens < 1 {
+		return result(false, limit, b.tokens), nil
+	}
+
+	b.tokens--
+	return result(true, limit, b.tokens), nil
+}
+
+// sweep удаляет полные корзины: новая корзина для того же ключа будет такой же.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.refill(now) >= float64(b.limit.Requests) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// testClock — часы MemoryLimiter, которые двигает тест.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter() (*MemoryLimiter, *testClock) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := NewMemoryLimiter()
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestMemoryLimiterRefill(t *testing.T) {
+	// 3 запроса за 3 секунды: корзина на 3 токена, один токен в секунду
+	limit := Limit{Requests: 3, Per: 3 * time.Second}
+	limiter, clock := newTestLimiter()
+	start := clock.now
+
+	steps := []struct {
+		name          string
+		at            time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+		wantReset     time.Duration
+	}{
+		{name: "first request", at: 0, wantAllowed: true, wantRemaining: 2, wantReset: time.Second},
+		{name: "second request", at: 0, wantAllowed: true, wantRemaining: 1, wantReset: 2 * time.Second},
+		{name: "last token", at: 0, wantAllowed: true, wantRemaining: 0, wantReset: 3 * time.Second},
+		{name: "bucket empty", at: 0, wantRetry: time.Second, wantReset: 3 * time.Second},
+		{
+			name:      "half a token refilled",
+			at:        500 * time.Millisecond,
+			wantRetry: 500 * time.Millisecond,
+			wantReset: 2500 * time.Millisecond,
+		},
+		{name: "one token refilled", at: time.Second, wantAllowed: true, wantRemaining: 0, wantReset: 3 * time.Second},
+		{name: "empty again", at: time.Second, wantRetry: time.Second, wantReset: 3 * time.Second},
+		// за долгий простой корзина наполняется только до емкости
+		{name: "refill capped", at: time.Hour, wantAllowed: true, wantRemaining: 2, wantReset: time.Second},
+	}
+
+	for _, step := range steps {
+		clock.now = start.Add(step.at)
+		res, err := limiter.Allow(context.Background(), "client", limit)
+		if err != nil {
+			t.Fatalf("%s: Allow() error = %v", step.name, err)
+		}
+		if res.Allowed != step.wantAllowed || res.Remaining != step.wantRemaining ||
+			res.RetryAfter != step.wantRetry || res.ResetAfter != step.wantReset {
+			t.Fatalf(
+				"%s: Allow() = allowed %v, remaining %d, retry %s, reset %s; want %v, %d, %s, %s",
+				step.name, res.Allowed, res.Remaining, res.RetryAfter, res.ResetAfter,
+				step.wantAllowed, step.wantRemaining, step.wantRetry, step.wantReset,
+			)
+		}
+	}
+}
+
+func TestMemoryLimiterBuckets(t *testing.T) {
+	limit := Limit{Requests: 1, Per: time.Minute}
+
+	tests := []struct {
+		name        string
+		second      string
+		secondLimit Limit
+		wantAllowed bool
+	}{
+		{name: "same key", second: "a", secondLimit: limit},
+		{name: "other key has own bucket", second: "b", secondLimit: limit, wantAllowed: true},
+		// лимит группы поменяли в конфиге — старая корзина не считается
+		{
+			name:        "changed limit starts new bucket",
+			second:      "a",
+			secondLimit: Limit{Requests: 2, Per: time.Minute},
+			wantAllowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, _ := newTestLimiter()
+			if res, _ := limiter.Allow(context.Background(), "a", limit); !res.Allowed {
+				t.Fatal("first Allow() denied")
+			}
+			res, err := limiter.Allow(context.Background(), tt.second, tt.secondLimit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed != tt.wantAllowed {
+				t.Fatalf("second Allow() allowed = %v, want %v", res.Allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+// Полные корзины выбрасываются при очистке, неполные остаются.
+func TestMemoryLimiterSweep(t *testing.T) {
+	limiter, clock := newTestLimiter()
+	fast := Limit{Requests: 1, Per: time.Second}
+	slow := Limit{Requests: 1, Per: time.Hour}
+
+	for _, key := range []string{"fast", "slow"} {
+		limit := fast
+		if key == "slow" {
+			limit = slow
+		}
+		if _, err := limiter.Allow(context.Background(), key, limit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock.now = clock.now.Add(sweepInterval)
+	if _, err := limiter.Allow(context.Background(), "other", fast); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := limiter.buckets["fast"]; ok {
+		t.Fatal("refilled bucket was not swept")
+	}
+	if _, ok := limiter.buckets["slow"]; !ok {
+		t.Fatal("partially refilled bucket was swept")
+	}
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// bucketTTL — корзины, не тронутые дольше, удаляются. Для лимитов с периодом не больше bucketTTL
+// такая корзина уже полная, удаление ничего не меняет.
+const bucketTTL = 24 * time.Hour
+
+// Пополнение и списание — одним upsert под блокировкой строки, время берется у БД: инстансы
+// с расходящимися часами видят одну и ту же корзину. Если токена нет, WHERE не дает обновить
+// строку и RETURNING ничего не возвращает.
+const takeTokenSQL = `
+INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+VALUES (@key, @capacity - 1, now())
+ON CONFLICT (key) DO UPDATE SET
+    tokens = LEAST(@capacity, rate_limit_buckets.tokens
+        + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at)::float8 * @rate) - 1,
+    updated_at = now()
+WHERE LEAST(@capacity, rate_limit_buckets.tokens
+    + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at)::float8 * @rate) >= 1
+RETURNING tokens`
+
+const peekTokensSQL = `
+SELECT LEAST(@capacity, tokens + EXTRACT(EPOCH FROM now() - updated_at)::float8 * @rate) AS tokens
+FROM rate_limit_buckets
+WHERE key = @key`
+
+// PostgresLimiter хранит корзины в таблице rate_limit_buckets: лимит общий для всех инстансов.
+// Запрос к БД ограничен timeout: медленная БД не должна задерживать каждый запрос API.
+type PostgresLimiter struct {
+	db      *gorm.DB
+	timeout time.Duration
+	logger  *slog.Logger
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresLimiter(db *gorm.DB, timeout time.Duration, logger *slog.Logger) *PostgresLimiter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresLimiter{db: db, timeout: timeout, logger: logger}
+}
+
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.sweep()
+
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+
+	args := map[string]any{
+		"key":      key,
+		"capacity": float64(limit.Requests),
+		"rate":     limit.rate(),
+	}
+
+	var taken []float64
+	if err := l.db.WithContext(ctx).Raw(takeTokenSQL, args).Scan(&taken).Error; err != nil {
+		return Result{}, err
+	}
+	if len(taken) > 0 {
+		return result(true, limit, taken[0]), nil
+	}
+
+	var tokens []float64
+	if err := l.db.WithContext(ctx).Raw(peekTokensSQL, args).Scan(&tokens).Error; err != nil {
+		return Result{}, err
+	}
+	if len(tokens) == 0 {
+		// корзину удалила чистка между запросами — значит, она была полной
+		return result(true, limit, float64(limit.Requests-1)), nil
+	}
+	return result(false, limit, tokens[0]), nil
+}
+
+// sweep раз в sweepInterval удаляет старые корзины в фоне, не задерживая запрос.
+func (l *PostgresLimiter) sweep() {
+	l.mu.Lock()
+	if time.Since(l.lastSweep) < sweepInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastSweep = time.Now()
+	l.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := l.db.WithContext(ctx).
+			Exec("DELETE FROM rate_limit_buckets WHERE updated_at < ?", time.Now().Add(-bucketTTL)).Error
+		if err != nil {
+			l.logger.WarnContext(ctx, "rate limit buckets cleanup failed", "error", err)
+		}
+	}()
+}
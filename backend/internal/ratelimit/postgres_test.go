@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	takeTokenQuery  = `INSERT INTO rate_limit_buckets .* ON CONFLICT \(key\) DO UPDATE SET .* RETURNING tokens`
+	peekTokensQuery = `SELECT LEAST\(.*\) AS tokens FROM rate_limit_buckets WHERE key = \$3`
+)
+
+// newMockPostgresLimiter — PostgresLimiter поверх sqlmock без фоновой чистки: ее DELETE
+// сбил бы порядок ожидаемых запросов.
+func newMockPostgresLimiter(t *testing.T) (*PostgresLimiter, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		_ = sqlDB.Close()
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := NewPostgresLimiter(db, time.Second, slog.New(slog.DiscardHandler))
+	limiter.lastSweep = time.Now()
+	return limiter, mock
+}
+
+func tokenRows(tokens ...float64) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"tokens"})
+	for _, t := range tokens {
+		rows.AddRow(t)
+	}
+	return rows
+}
+
+func TestPostgresLimiterAllow(t *testing.T) {
+	// 4 запроса за 4 секунды: один токен в секунду
+	limit := Limit{Requests: 4, Per: 4 * time.Second}
+
+	tests := []struct {
+		name string
+		// taken — токены после списания; nil — upsert ничего не вернул (токена нет)
+		taken []float64
+		// peek — токены в корзине без списания; nil — строки нет
+		peek          []float64
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}{
+		{name: "token taken", taken: []float64{2.5}, wantAllowed: true, wantRemaining: 2},
+		// токена нет: upsert не обновил строку, остаток читается без списания
+		{name: "bucket empty", peek: []float64{0.25}, wantRetry: 750 * time.Millisecond},
+		// строку удалила чистка между upsert и чтением — корзина была полной
+		{name: "bucket swept", wantAllowed: true, wantRemaining: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, mock := newMockPostgresLimiter(t)
+			mock.ExpectQuery(takeTokenQuery).
+				WithArgs("user:1", 4.0, 4.0, 1.0, 4.0, 1.0).
+				WillReturnRows(tokenRows(tt.taken...))
+			if tt.taken == nil {
+				mock.ExpectQuery(peekTokensQuery).
+					WithArgs(4.0, 1.0, "user:1").
+					WillReturnRows(tokenRows(tt.peek...))
+			}
+
+			res, err := limiter.Allow(context.Background(), "user:1", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed != tt.wantAllowed || res.Remaining != tt.wantRemaining || res.RetryAfter != tt.wantRetry {
+				t.Fatalf("Allow() = allowed %v, remaining %d, retry %s; want %v, %d, %s",
+					res.Allowed, res.Remaining, res.RetryAfter, tt.wantAllowed, tt.wantRemaining, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestPostgresLimiterReturnsDBError(t *testing.T) {
+	limiter, mock := newMockPostgresLimiter(t)
+	errDB := errors.New("connection refused")
+	mock.ExpectQuery(takeTokenQuery).WillReturnError(errDB)
+
+	// ошибку решает вызывающий: RateLimit переключается на Fallback
+	_, err := limiter.Allow(context.Background(), "user:1", Limit{Requests: 1, Per: time.Minute})
+	if !errors.Is(err, errDB) {
+		t.Fatalf("Allow() error = %v, want %v", err, errDB)
+	}
+}
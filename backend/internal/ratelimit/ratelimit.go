@@ -0,0 +1,89 @@
+// Package ratelimit — token bucket для ограничения частоты запросов. Хранилище корзин — интерфейс Limiter:
+// в памяти процесса для одного инстанса и в Postgres для нескольких.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit — Requests запросов за Per: корзина вмещает Requests токенов и пополняется равномерно.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Enabled — нулевой Limit означает "без ограничения".
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// rate — токенов в секунду.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Per.String()
+}
+
+// ParseLimit читает лимит в формате "<запросов>/<период>", например "60/1m".
+func ParseLimit(raw string) (Limit, error) {
+	requests, per, ok := strings.Cut(strings.TrimSpace(raw), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q: want <requests>/<duration>", raw)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("invalid limit %q: requests must be a positive integer", raw)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: period must be a positive duration", raw)
+	}
+
+	return Limit{Requests: n, Per: d}, nil
+}
+
+// Result — решение по одному запросу и данные для заголовков RateLimit-*.
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// ResetAfter — через сколько корзина снова будет полной.
+	ResetAfter time.Duration
+	// RetryAfter — через сколько появится следующий токен; 0, если запрос пропущен.
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow списывает токен из корзины key, если он есть.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result считает Result по числу токенов, оставшихся после решения.
+func result(allowed bool, limit Limit, tokens float64) Result {
+	rate := limit.rate()
+	res := Result{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  max(0, int(math.Floor(tokens))),
+		ResetAfter: seconds((float64(limit.Requests) - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/models"
+)
+
+// ClaimChangeRepo читает журнал изменений заявок. Пишет в него только триггер на claims.
+type ClaimChangeRepo struct {
+	db *gorm.DB
+}
+
+func NewClaimChangeRepo(db *gorm.DB) *ClaimChangeRepo {
+	return &ClaimChangeRepo{db: db}
+}
+
+// LatestID — id последнего изменения, 0 для пустого журнала.
+func (r *ClaimChangeRepo) LatestID(ctx context.Context) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).
+		Model(&models.ClaimChange{}).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+// FirstIDSince — id первого изменения не раньше since, 0 если таких нет.
+func (r *ClaimChangeRepo) FirstIDSince(ctx context.Context, since time.Time) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).
+		Model(&models.ClaimChange{}).
+		Select("COALESCE(MIN(id), 0)").
+		Where("changed_at >= ?", since).
+		Scan(&id).Error
+	return id, err
+}
+
+// ListAfter возвращает до limit изменений с id больше afterID в порядке id.
+func (r *ClaimChangeRepo) ListAfter(ctx context.Context, afterID int64, limit int) ([]models.ClaimChange, error) {
+	var changes []models.ClaimChange
+	err := conn(ctx, r.db).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&changes).Error
+	return changes, err
+}
+
+// DeleteBefore удаляет изменения старше before.
+func (r *ClaimChangeRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, r.db).
+		Where("changed_at < ?", before).
+		Delete(&models.ClaimChange{})
+	return res.RowsAffected, res.Error
+}
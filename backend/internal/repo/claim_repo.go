@@ -4,14 +4,21 @@ package repo
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 	"time"
 
 	"warranty_days/internal/models"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var tracer = otel.Tracer("warranty_days/internal/repo")
+
 type ClaimRepo struct {
 	db *gorm.DB
 }
@@ -42,7 +49,7 @@ func (r *ClaimRepo) ListByVINCaseInsensitive(ctx context.Context, vin string) ([
 	vin = strings.TrimSpace(vin)
 
 	var claims []models.Claim
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("LOWER(vin) = LOWER(?)", vin).
 		Order("id DESC").
 		Find(&claims).Error
@@ -50,6 +57,56 @@ func (r *ClaimRepo) ListByVINCaseInsensitive(ctx context.Context, vin string) ([
 	return claims, err
 }
 
+// ClaimsStamp — сводка по заявкам VIN: меняется при добавлении, удалении и изменении заявки.
+// По ней строится ETag ответов, не загружая сами заявки.
+type ClaimsStamp struct {
+	Count        int64
+	MaxUpdatedAt time.Time
+}
+
+func (r *ClaimRepo) StampByVIN(ctx context.Context, vin string) (ClaimsStamp, error) {
+	vin = strings.TrimSpace(vin)
+
+	var holder struct {
+		Count        int64
+		MaxUpdatedAt *time.Time
+	}
+	err := conn(ctx, r.db).
+		Model(&models.Claim{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS max_updated_at").
+		Where("LOWER(vin) = LOWER(?)", vin).
+		Scan(&holder).Error
+	if err != nil {
+		return ClaimsStamp{}, err
+	}
+
+	stamp := ClaimsStamp{Count: holder.Count}
+	if holder.MaxUpdatedAt != nil {
+		stamp.MaxUpdatedAt = *holder.MaxUpdatedAt
+	}
+	return stamp, nil
+}
+
+// LatestRepairDate возвращает самую позднюю дату закрытия заказ-наряда по VIN.
+func (r *ClaimRepo) LatestRepairDate(ctx context.Context, vin string) (time.Time, error) {
+	vin = strings.TrimSpace(vin)
+
+	var holder struct {
+		RoCloseDate time.Time
+	}
+	err := conn(ctx, r.db).
+		Model(&models.Claim{}).
+		Select("ro_close_date").
+		Where("LOWER(vin) = LOWER(?)", vin).
+		Order("ro_close_date DESC").
+		Take(&holder).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return holder.RoCloseDate, nil
+}
+
 func (r *ClaimRepo) ListWarrantyYearRepairsByVIN(
 	ctx context.Context,
 	vin string,
@@ -60,11 +117,17 @@ func (r *ClaimRepo) ListWarrantyYearRepairsByVIN(
 		now = time.Now()
 	}
 
+	ctx, span := tracer.Start(ctx, "ClaimRepo.ListWarrantyYearRepairsByVIN", trace.WithAttributes(
+		attribute.String("warranty.vin", vin),
+		attribute.String("warranty.as_of", now.Format(time.DateOnly)),
+	))
+	defer span.End()
+
 	var retailHolder struct {
 		RetailDate time.Time
 	}
 
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&models.Claim{}).
 		Select("retail_date").
 		Where("LOWER(vin) = LOWER(?)", vin).
@@ -90,7 +153,7 @@ func (r *ClaimRepo) ListWarrantyYearRepairsByVIN(
 	newestEnd := periods[0].WarrantyEnd
 
 	var claims []models.Claim
-	err = r.db.WithContext(ctx).
+	err = conn(ctx, r.db).
 		Where("LOWER(vin) = LOWER(?)", vin).
 		Where("ro_open_date <= ? AND ro_close_date >= ?", newestEnd, oldestStart).
 		Order("ro_open_date ASC, id ASC").
@@ -99,6 +162,12 @@ func (r *ClaimRepo) ListWarrantyYearRepairsByVIN(
 		return WarrantyYearsResponse{}, err
 	}
 
+	_, calcSpan := tracer.Start(ctx, "warranty_year.calculate", trace.WithAttributes(
+		attribute.Int("warranty.periods", len(periods)),
+		attribute.Int("warranty.claims", len(claims)),
+	))
+	defer calcSpan.End()
+
 	for periodIdx := range periods {
 		items := make([]ClaimRepairDaysItem, 0)
 		totalDays := 0
@@ -129,37 +198,103 @@ func (r *ClaimRepo) ListWarrantyYearRepairsByVIN(
 	}, nil
 }
 
-func currentWarrantyYearWindow(retailDate time.Time, now time.Time) (time.Time, time.Time) {
+// GetByIDForUpdate читает заявку с блокировкой строки до конца транзакции: VIN заявки не поменяется,
+// пока изменение не закоммичено.
+func (r *ClaimRepo) GetByIDForUpdate(ctx context.Context, id int64) (*models.Claim, error) {
+	var claim models.Claim
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		Take(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// LockVINs берет транзакционные advisory-блокировки VIN: изменения одного VIN идут по очереди до коммита.
+// VIN сортируются, чтобы две транзакции с общими VIN не ждали друг друга по кругу. Нужна транзакция
+// (TxManager.WithinTx).
+func (r *ClaimRepo) LockVINs(ctx context.Context, vins []string) error {
+	keys := make([]string, 0, len(vins))
+	for _, vin := range vins {
+		key := strings.ToUpper(strings.TrimSpace(vin))
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if err := conn(ctx, r.db).Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ClaimRepo) Create(ctx context.Context, claim *models.Claim) error {
+	if claim == nil {
+		return errors.New("claim is nil")
+	}
+	return conn(ctx, r.db).Create(claim).Error
+}
+
+func (r *ClaimRepo) Update(ctx context.Context, claim *models.Claim) error {
+	res := conn(ctx, r.db).
+		Model(claim).
+		Select("vin", "retail_date", "ro_open_date", "ro_close_date", "updated_at").
+		Updates(claim)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *ClaimRepo) Delete(ctx context.Context, id int64) error {
+	res := conn(ctx, r.db).Where("id = ?", id).Delete(&models.Claim{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// warrantyYearStart — начало гарантийного года n (от нуля): годовщина retail date. Годовщина 29 февраля
+// в невисокосный год — 1 марта, как у AddDate; warrantyTotalsCTE считает так же. Считаем всегда от retail
+// date, а не от соседнего окна: иначе после 1 марта окна сползают на день.
+func warrantyYearStart(retailDate time.Time, n int) time.Time {
+	return toUTCDate(retailDate.AddDate(n, 0, 0))
+}
+
+// currentWarrantyYear — номер гарантийного года, в который попадает now; отрицательный, если гарантия еще не началась.
+func currentWarrantyYear(retailDate time.Time, now time.Time) int {
 	retailDate = toUTCDate(retailDate)
 	now = toUTCDate(now)
 
 	years := now.Year() - retailDate.Year()
-	start := toUTCDate(retailDate.AddDate(years, 0, 0))
-	if start.After(now) {
-		start = toUTCDate(start.AddDate(-1, 0, 0))
+	if warrantyYearStart(retailDate, years).After(now) {
+		years--
 	}
-
-	end := toUTCDate(start.AddDate(1, 0, 0).AddDate(0, 0, -1))
-	return start, end
+	return years
 }
 
 func buildWarrantyPeriods(retailDate time.Time, now time.Time) []WarrantyYearPeriod {
 	retailDate = toUTCDate(retailDate)
-	now = toUTCDate(now)
-
-	currentStart, currentEnd := currentWarrantyYearWindow(retailDate, now)
 	periods := make([]WarrantyYearPeriod, 0)
 
-	for start, end := currentStart, currentEnd; !start.Before(retailDate); {
+	for n := currentWarrantyYear(retailDate, now); n >= 0; n-- {
 		periods = append(periods, WarrantyYearPeriod{
-			WarrantyStart: start,
-			WarrantyEnd:   end,
+			WarrantyStart: warrantyYearStart(retailDate, n),
+			WarrantyEnd:   warrantyYearStart(retailDate, n+1).AddDate(0, 0, -1),
 			TotalDays:     0,
 			Items:         []ClaimRepairDaysItem{},
 		})
-
-		start = toUTCDate(start.AddDate(-1, 0, 0))
-		end = toUTCDate(end.AddDate(-1, 0, 0))
 	}
 
 	return periods
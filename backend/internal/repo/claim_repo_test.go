@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildWarrantyPeriodsLeapDay(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	retail := date("2024-02-29")
+
+	// годовщина 29 февраля в невисокосный год — 1 марта; окна идут подряд, без пропусков и наложений
+	tests := []struct {
+		asOf string
+		want [][2]string
+	}{
+		{asOf: "2024-02-28"},
+		{asOf: "2025-02-28", want: [][2]string{{"2024-02-29", "2025-02-28"}}},
+		{asOf: "2025-03-01", want: [][2]string{
+			{"2025-03-01", "2026-02-28"},
+			{"2024-02-29", "2025-02-28"},
+		}},
+		{asOf: "2028-02-28", want: [][2]string{
+			{"2027-03-01", "2028-02-28"},
+			{"2026-03-01", "2027-02-28"},
+			{"2025-03-01", "2026-02-28"},
+			{"2024-02-29", "2025-02-28"},
+		}},
+		{asOf: "2028-02-29", want: [][2]string{
+			{"2028-02-29", "2029-02-28"},
+			{"2027-03-01", "2028-02-28"},
+			{"2026-03-01", "2027-02-28"},
+			{"2025-03-01", "2026-02-28"},
+			{"2024-02-29", "2025-02-28"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.asOf, func(t *testing.T) {
+			periods := buildWarrantyPeriods(retail, date(tt.asOf))
+			if len(periods) != len(tt.want) {
+				t.Fatalf("buildWarrantyPeriods() returned %d periods, want %d", len(periods), len(tt.want))
+			}
+			for i, p := range periods {
+				got := [2]string{p.WarrantyStart.Format(time.DateOnly), p.WarrantyEnd.Format(time.DateOnly)}
+				if got != tt.want[i] {
+					t.Errorf("period %d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
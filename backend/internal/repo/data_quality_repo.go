@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// DataQualityCheck — проверка заявок: Query отбирает id заявок с проблемой.
+type DataQualityCheck struct {
+	Name  string
+	Query string
+}
+
+// DataQualityChecks — проверки, которые прогоняет задача data_quality_scan.
+var DataQualityChecks = []DataQualityCheck{
+	{
+		// VIN — 17 символов без I, O и Q
+		Name:  "invalid_vin",
+		Query: `SELECT id FROM claims WHERE UPPER(TRIM(vin)) !~ '^[A-HJ-NPR-Z0-9]{17}$'`,
+	},
+	{
+		Name:  "ro_close_before_open",
+		Query: `SELECT id FROM claims WHERE ro_close_date < ro_open_date`,
+	},
+	{
+		Name:  "ro_open_before_retail",
+		Query: `SELECT id FROM claims WHERE ro_open_date < retail_date`,
+	},
+	{
+		Name:  "future_dates",
+		Query: `SELECT id FROM claims WHERE GREATEST(retail_date, ro_open_date, ro_close_date) > current_date`,
+	},
+	{
+		// расчет берет самую раннюю дату продажи VIN, остальные даты молча игнорируются
+		Name: "retail_date_mismatch",
+		Query: `SELECT id FROM claims WHERE UPPER(vin) IN (
+			SELECT UPPER(vin) FROM claims GROUP BY UPPER(vin) HAVING COUNT(DISTINCT retail_date) > 1
+		)`,
+	},
+	{
+		// лишние копии: первая заявка с такими датами считается оригиналом
+		Name: "duplicate_claims",
+		Query: `SELECT c.id FROM claims c WHERE EXISTS (
+			SELECT 1 FROM claims d
+			WHERE UPPER(d.vin) = UPPER(c.vin)
+				AND d.ro_open_date = c.ro_open_date
+				AND d.ro_close_date = c.ro_close_date
+				AND d.id < c.id
+		)`,
+	},
+	{
+		// пересекающиеся ремонты одного VIN: дни в пересечении считаются дважды
+		Name: "overlapping_repairs",
+		Query: `SELECT c.id FROM claims c WHERE EXISTS (
+			SELECT 1 FROM claims o
+			WHERE UPPER(o.vin) = UPPER(c.vin)
+				AND o.id <> c.id
+				AND o.ro_open_date <= c.ro_close_date
+				AND c.ro_open_date <= o.ro_close_date
+				AND (o.ro_open_date, o.ro_close_date) IS DISTINCT FROM (c.ro_open_date, c.ro_close_date)
+		)`,
+	},
+}
+
+// DataQualityFinding — результат одной проверки: сколько заявок с проблемой и первые из них.
+type DataQualityFinding struct {
+	Check     string
+	Count     int64
+	SampleIDs []int64
+}
+
+type DataQualityRepo struct {
+	db *gorm.DB
+}
+
+func NewDataQualityRepo(db *gorm.DB) *DataQualityRepo {
+	return &DataQualityRepo{db: db}
+}
+
+// Run выполняет проверку и возвращает число найденных заявок и до sample их id.
+func (r *DataQualityRepo) Run(ctx context.Context, check DataQualityCheck, sample int) (DataQualityFinding, error) {
+	finding := DataQualityFinding{Check: check.Name, SampleIDs: []int64{}}
+
+	err := conn(ctx, r.db).
+		Raw("SELECT COUNT(*) FROM (" + check.Query + ") found").
+		Scan(&finding.Count).Error
+	if err != nil || finding.Count == 0 {
+		return finding, err
+	}
+
+	err = conn(ctx, r.db).
+		Raw("SELECT id FROM ("+check.Query+") found ORDER BY id LIMIT ?", sample).
+		Scan(&finding.SampleIDs).Error
+	return finding, err
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/models"
+)
+
+type EmailVerificationRepo struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepo(db *gorm.DB) *EmailVerificationRepo {
+	return &EmailVerificationRepo{db: db}
+}
+
+func (r *EmailVerificationRepo) Create(ctx context.Context, verification *models.EmailVerification) error {
+	if verification == nil {
+		return errors.New("email verification is nil")
+	}
+	return conn(ctx, r.db).Create(verification).Error
+}
+
+// Consume помечает неиспользованный и не истекший токен использованным.
+// Возвращает gorm.ErrRecordNotFound, если такого токена нет.
+func (r *EmailVerificationRepo) Consume(
+	ctx context.Context,
+	tokenHash string,
+	now time.Time,
+) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := conn(ctx, r.db).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Take(&verification).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res := conn(ctx, r.db).
+		Model(&models.EmailVerification{}).
+		Where("id = ? AND used_at IS NULL", verification.ID).
+		Update("used_at", now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	verification.UsedAt = &now
+	return &verification, nil
+}
+
+// DeleteExpiredBefore удаляет токены, истекшие раньше before, — использованные и нет.
+func (r *EmailVerificationRepo) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, r.db).
+		Where("expires_at < ?", before).
+		Delete(&models.EmailVerification{})
+	return res.RowsAffected, res.Error
+}
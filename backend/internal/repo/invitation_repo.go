@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/models"
+)
+
+type InvitationRepo struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepo(db *gorm.DB) *InvitationRepo {
+	return &InvitationRepo{db: db}
+}
+
+func (r *InvitationRepo) Create(ctx context.Context, invitation *models.Invitation) error {
+	if invitation == nil {
+		return errors.New("invitation is nil")
+	}
+	return conn(ctx, r.db).Create(invitation).Error
+}
+
+func (r *InvitationRepo) GetByCodeHash(ctx context.Context, codeHash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := conn(ctx, r.db).
+		Where("code_hash = ?", codeHash).
+		Take(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *InvitationRepo) List(ctx context.Context, limit int) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := conn(ctx, r.db).
+		Order("id DESC").
+		Limit(limit).
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// MarkUsed атомарно помечает приглашение использованным.
+// Возвращает gorm.ErrRecordNotFound, если приглашение уже использовано или истекло.
+func (r *InvitationRepo) MarkUsed(ctx context.Context, id int64, userID int64, now time.Time) error {
+	res := conn(ctx, r.db).
+		Model(&models.Invitation{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Updates(map[string]any{"used_at": now, "used_by": userID})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteUnusedExpiredBefore удаляет неиспользованные приглашения, истекшие раньше before. Использованные
+// остаются: по ним видно, кто кого пригласил.
+func (r *InvitationRepo) DeleteUnusedExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, r.db).
+		Where("used_at IS NULL AND expires_at < ?", before).
+		Delete(&models.Invitation{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"warranty_days/internal/models"
+)
+
+type JobRepo struct {
+	db *gorm.DB
+}
+
+func NewJobRepo(db *gorm.DB) *JobRepo {
+	return &JobRepo{db: db}
+}
+
+// States — состояние задач, которые хоть раз ставили на паузу, по имени.
+func (r *JobRepo) States(ctx context.Context) (map[string]models.ScheduledJob, error) {
+	var jobs []models.ScheduledJob
+	if err := conn(ctx, r.db).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]models.ScheduledJob, len(jobs))
+	for _, job := range jobs {
+		states[job.Name] = job
+	}
+	return states, nil
+}
+
+func (r *JobRepo) SetPaused(
+	ctx context.Context,
+	name string,
+	paused bool,
+	updatedBy int64,
+	now time.Time,
+) (*models.ScheduledJob, error) {
+	job := models.ScheduledJob{Name: name, Paused: paused, UpdatedBy: &updatedBy, UpdatedAt: now}
+	err := conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"paused", "updated_by", "updated_at"}),
+		}).
+		Create(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Insert добавляет запуск. false — у задачи уже есть запуск в очереди или в работе, либо слот расписания
+// уже запущен: это решают уникальные индексы, поэтому гонка между инстансами невозможна.
+func (r *JobRepo) Insert(ctx context.Context, run *models.JobRun) (bool, error) {
+	res := conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(run)
+	return res.RowsAffected == 1, res.Error
+}
+
+// ClaimQueued переводит запуски из очереди в работу и возвращает их.
+func (r *JobRepo) ClaimQueued(ctx context.Context, names []string, now time.Time) ([]models.JobRun, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var runs []models.JobRun
+	err := conn(ctx, r.db).
+		Model(&runs).
+		Clauses(clause.Returning{}).
+		Where("status = ? AND job_name IN ?", models.JobRunQueued, names).
+		Updates(map[string]any{"status": models.JobRunRunning, "started_at": now}).Error
+	return runs, err
+}
+
+func (r *JobRepo) Finish(
+	ctx context.Context,
+	id int64,
+	status string,
+	now time.Time,
+	result json.RawMessage,
+	runErr *string,
+) error {
+	updates := map[string]any{"status": status, "finished_at": now, "error": runErr}
+	if result != nil {
+		updates["result"] = result
+	}
+	return conn(ctx, r.db).
+		Model(&models.JobRun{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// FailRunning завершает ошибкой запуски, оставшиеся в работе: их выполнял прежний лидер.
+func (r *JobRepo) FailRunning(ctx context.Context, now time.Time, runErr string) (int64, error) {
+	res := conn(ctx, r.db).
+		Model(&models.JobRun{}).
+		Where("status = ?", models.JobRunRunning).
+		Updates(map[string]any{"status": models.JobRunFailed, "finished_at": now, "error": runErr})
+	return res.RowsAffected, res.Error
+}
+
+// LastScheduledFor — последний запущенный слот расписания задачи; nil, если по расписанию она не запускалась.
+func (r *JobRepo) LastScheduledFor(ctx context.Context, name string) (*time.Time, error) {
+	var holder struct {
+		Last *time.Time
+	}
+	err := conn(ctx, r.db).
+		Model(&models.JobRun{}).
+		Select("MAX(scheduled_for) AS last").
+		Where("job_name = ?", name).
+		Scan(&holder).Error
+	return holder.Last, err
+}
+
+// LastRuns — последний запуск каждой задачи по имени.
+func (r *JobRepo) LastRuns(ctx context.Context) (map[string]models.JobRun, error) {
+	var runs []models.JobRun
+	err := conn(ctx, r.db).
+		Raw("SELECT DISTINCT ON (job_name) * FROM job_runs ORDER BY job_name, id DESC").
+		Scan(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[string]models.JobRun, len(runs))
+	for _, run := range runs {
+		last[run.JobName] = run
+	}
+	return last, nil
+}
+
+// ListRuns — история запусков задачи, новые первыми. Пустой status — все статусы.
+func (r *JobRepo) ListRuns(ctx context.Context, name string, status string, limit int) ([]models.JobRun, error) {
+	query := conn(ctx, r.db).Where("job_name = ?", name)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var runs []models.JobRun
+	err := query.Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// DeleteFinishedBefore удаляет завершенные запуски старше before.
+func (r *JobRepo) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, r.db).
+		Where("finished_at IS NOT NULL AND finished_at < ?", before).
+		Delete(&models.JobRun{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"warranty_days/internal/models"
+)
+
+type MFARepo struct {
+	db *gorm.DB
+}
+
+func NewMFARepo(db *gorm.DB) *MFARepo {
+	return &MFARepo{db: db}
+}
+
+// ReplaceRecoveryCodes удаляет старые коды восстановления пользователя и сохраняет новые.
+func (r *MFARepo) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	if err := r.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	codes := make([]models.MFARecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, models.MFARecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return conn(ctx, r.db).Create(&codes).Error
+}
+
+func (r *MFARepo) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	return conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Delete(&models.MFARecoveryCode{}).Error
+}
+
+// ConsumeRecoveryCode помечает код использованным.
+// Возвращает gorm.ErrRecordNotFound, если кода нет или он уже использован.
+func (r *MFARepo) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string, now time.Time) error {
+	res := conn(ctx, r.db).
+		Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *MFARepo) CreateChallenge(ctx context.Context, challenge *models.MFALoginChallenge) error {
+	if challenge == nil {
+		return errors.New("mfa challenge is nil")
+	}
+	return conn(ctx, r.db).Create(challenge).Error
+}
+
+// StartChallengeAttempt засчитывает попытку ввода кода до его проверки, чтобы параллельные запросы
+// не обошли лимит. Возвращает gorm.ErrRecordNotFound, если челлендж истек, использован или попытки кончились.
+func (r *MFARepo) StartChallengeAttempt(
+	ctx context.Context,
+	id string,
+	userID int64,
+	maxAttempts int,
+	now time.Time,
+) error {
+	res := conn(ctx, r.db).
+		Model(&models.MFALoginChallenge{}).
+		Where("id = ? AND user_id = ? AND consumed_at IS NULL AND expires_at > ? AND attempts < ?",
+			id, userID, now, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// StartUserAttempt засчитывает попытку ввода кода вне логина (отключение 2FA) в строке mfa_challenges
+// с id key: та же таблица и тот же лимит, что у челленджа логина. Истекшее окно начинается заново.
+// Возвращает gorm.ErrRecordNotFound, если попытки в текущем окне кончились.
+func (r *MFARepo) StartUserAttempt(
+	ctx context.Context,
+	key string,
+	userID int64,
+	maxAttempts int,
+	window time.Duration,
+	now time.Time,
+) error {
+	res := conn(ctx, r.db).Exec(`
+		INSERT INTO mfa_challenges (id, user_id, attempts, expires_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			attempts = CASE WHEN mfa_challenges.expires_at <= ? THEN 1 ELSE mfa_challenges.attempts + 1 END,
+			expires_at = CASE WHEN mfa_challenges.expires_at <= ? THEN EXCLUDED.expires_at
+				ELSE mfa_challenges.expires_at END
+		WHERE mfa_challenges.expires_at <= ? OR mfa_challenges.attempts < ?`,
+		key, userID, now.Add(window), now, now, now, maxAttempts)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteChallenge удаляет челлендж или счетчик попыток.
+func (r *MFARepo) DeleteChallenge(ctx context.Context, id string) error {
+	return conn(ctx, r.db).
+		Where("id = ?", id).
+		Delete(&models.MFALoginChallenge{}).Error
+}
+
+// ConsumeChallenge помечает челлендж использованным.
+// Возвращает gorm.ErrRecordNotFound, если его уже использовал другой запрос.
+func (r *MFARepo) ConsumeChallenge(ctx context.Context, id string, now time.Time) error {
+	res := conn(ctx, r.db).
+		Model(&models.MFALoginChallenge{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteChallengesExpiredBefore удаляет челленджи, истекшие раньше before, — использованные и нет.
+func (r *MFARepo) DeleteChallengesExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, r.db).
+		Where("expires_at < ?", before).
+		Delete(&models.MFALoginChallenge{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *MFARepo) ListPolicies(ctx context.Context) ([]models.MFARolePolicy, error) {
+	var policies []models.MFARolePolicy
+	err := conn(ctx, r.db).
+		Order("role ASC").
+		Find(&policies).Error
+	return policies, err
+}
+
+func (r *MFARepo) UpsertPolicy(ctx context.Context, policy *models.MFARolePolicy) error {
+	if policy == nil {
+		return errors.New("mfa policy is nil")
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "role"}},
+			DoUpdates: clause.AssignmentColumns([]string{"required", "updated_by", "updated_at"}),
+		}).
+		Create(policy).Error
+}
+
+func (r *MFARepo) IsRequiredForRole(ctx context.Context, role string) (bool, error) {
+	var policy models.MFARolePolicy
+	err := conn(ctx, r.db).
+		Where("role = ?", role).
+		Take(&policy).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return policy.Required, nil
+}
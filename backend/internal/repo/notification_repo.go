@@ -0,0 +1,158 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"warranty_days/internal/models"
+)
+
+// Письма забираются с арендой, как доставки вебхуков: next_attempt_at сдвигается на время попытки.
+const claimDueNotificationsSQL = `
+UPDATE notifications n
+SET next_attempt_at = now() + make_interval(secs => @lease), updated_at = now()
+WHERE n.id IN (
+    SELECT id
+    FROM notifications
+    WHERE status = 'pending' AND next_attempt_at <= now()
+    ORDER BY next_attempt_at, id
+    LIMIT @limit
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING n.*`
+
+// Адресаты письма: активные пользователи с подтвержденным email и нужной ролью, не отказавшиеся от этого типа.
+const notificationRecipientsSQL = `
+SELECT u.id AS user_id, u.email, COALESCE(p.language, '') AS language
+FROM users u
+LEFT JOIN user_preferences p ON p.user_id = u.id
+WHERE u.is_active
+    AND u.email_verified_at IS NOT NULL
+    AND u.role IN @roles
+    AND NOT EXISTS (
+        SELECT 1 FROM notification_opt_outs o WHERE o.user_id = u.id AND o.type = @type
+    )
+ORDER BY u.id`
+
+type NotificationRepo struct {
+	db *gorm.DB
+}
+
+// NotificationRecipient — кому и на каком языке отправить письмо. Пустой Language — язык по умолчанию.
+type NotificationRecipient struct {
+	UserID   int64
+	Email    string
+	Language string
+}
+
+// NotificationAttempt — итог одной попытки отправки.
+type NotificationAttempt struct {
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	Error         *string
+	SentAt        *time.Time
+}
+
+func NewNotificationRepo(db *gorm.DB) *NotificationRepo {
+	return &NotificationRepo{db: db}
+}
+
+// Enqueue ставит письма в очередь. Письмо о том же событии тому же адресату пропускается.
+func (r *NotificationRepo) Enqueue(ctx context.Context, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "event_id"}, {Name: "recipient"}},
+			DoNothing: true,
+		}).
+		Create(&notifications).Error
+}
+
+// ClaimDue забирает до limit писем, у которых подошло время попытки.
+func (r *NotificationRepo) ClaimDue(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := conn(ctx, r.db).
+		Raw(claimDueNotificationsSQL, map[string]any{"limit": limit, "lease": lease.Seconds()}).
+		Scan(&notifications).Error
+	return notifications, err
+}
+
+// RecordAttempt сохраняет итог попытки. После отправки тело письма стирается.
+func (r *NotificationRepo) RecordAttempt(ctx context.Context, id int64, attempt NotificationAttempt) error {
+	updates := map[string]any{
+		"status":          attempt.Status,
+		"attempts":        attempt.Attempts,
+		"next_attempt_at": attempt.NextAttemptAt,
+		"last_error":      attempt.Error,
+		"sent_at":         attempt.SentAt,
+		"updated_at":      time.Now(),
+	}
+	if attempt.Status == models.NotificationSent {
+		updates["text_body"] = ""
+		updates["html_body"] = ""
+	}
+	return conn(ctx, r.db).
+		Model(&models.Notification{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// DeleteFinishedBefore удаляет отправленные и брошенные письма, созданные раньше before.
+func (r *NotificationRepo) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, r.db).
+		Where("status IN ? AND created_at < ?", []string{models.NotificationSent, models.NotificationDead}, before).
+		Delete(&models.Notification{})
+	return res.RowsAffected, res.Error
+}
+
+// Recipients — адресаты письма типа среди пользователей с одной из ролей roles.
+func (r *NotificationRepo) Recipients(
+	ctx context.Context,
+	notificationType string,
+	roles []string,
+) ([]NotificationRecipient, error) {
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	var recipients []NotificationRecipient
+	err := conn(ctx, r.db).
+		Raw(notificationRecipientsSQL, map[string]any{"type": notificationType, "roles": roles}).
+		Scan(&recipients).Error
+	return recipients, err
+}
+
+// OptOuts — типы писем, от которых пользователь отказался.
+func (r *NotificationRepo) OptOuts(ctx context.Context, userID int64) ([]string, error) {
+	var types []string
+	err := conn(ctx, r.db).
+		Model(&models.NotificationOptOut{}).
+		Where("user_id = ?", userID).
+		Order("type ASC").
+		Pluck("type", &types).Error
+	return types, err
+}
+
+// SetOptOut включает или выключает отказ от писем типа; повторный вызов ничего не меняет.
+func (r *NotificationRepo) SetOptOut(ctx context.Context, userID int64, notificationType string, optOut bool) error {
+	if !optOut {
+		return conn(ctx, r.db).
+			Where("user_id = ? AND type = ?", userID, notificationType).
+			Delete(&models.NotificationOptOut{}).Error
+	}
+
+	row := models.NotificationOptOut{UserID: userID, Type: notificationType}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&row).Error
+}
@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/models"
+)
+
+// Сообщения блокируются до конца транзакции relay, другие инстансы их пропускают. Сообщение VIN отдается,
+// только если все более ранние неотправленные сообщения этого VIN попали в ту же пачку: иначе более раннее
+// держит другой инстанс или оно ждет повтора, и порядок по VIN нарушился бы. Брошенные (dead_at) не ждем.
+const lockPendingOutboxSQL = `
+WITH locked AS (
+    SELECT *
+    FROM outbox
+    WHERE published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= now()
+    ORDER BY id
+    LIMIT @limit
+    FOR UPDATE SKIP LOCKED
+)
+SELECT l.*
+FROM locked l
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM outbox p
+    WHERE p.vin = l.vin
+      AND p.published_at IS NULL
+      AND p.dead_at IS NULL
+      AND p.id < l.id
+      AND p.id NOT IN (SELECT id FROM locked)
+)
+ORDER BY l.id`
+
+type OutboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(db *gorm.DB) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+// Add записывает сообщения. Вызывается в транзакции изменения заявок — событие не теряется и не уходит
+// раньше коммита.
+func (r *OutboxRepo) Add(ctx context.Context, messages []models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(&messages).Error
+}
+
+// LockPending блокирует до limit неотправленных сообщений в порядке записи. Нужна транзакция
+// (TxManager.WithinTx): блокировка держится до ее конца.
+func (r *OutboxRepo) LockPending(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := conn(ctx, r.db).
+		Raw(lockPendingOutboxSQL, map[string]any{"limit": limit}).
+		Scan(&messages).Error
+	return messages, err
+}
+
+func (r *OutboxRepo) MarkPublished(ctx context.Context, ids []int64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Model(&models.OutboxMessage{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{"published_at": now, "last_error": nil}).Error
+}
+
+// RecordFailure откладывает сообщение до nextAttemptAt. Более поздние сообщения того же VIN ждут вместе с ним.
+func (r *OutboxRepo) RecordFailure(
+	ctx context.Context,
+	id int64,
+	attempts int,
+	nextAttemptAt time.Time,
+	lastError string,
+) error {
+	return conn(ctx, r.db).
+		Model(&models.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
+
+// MarkDead бросает сообщение после последней попытки: оно больше не отправляется и не задерживает
+// следующие сообщения своего VIN.
+func (r *OutboxRepo) MarkDead(ctx context.Context, id int64, attempts int, now time.Time, lastError string) error {
+	return conn(ctx, r.db).
+		Model(&models.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":   attempts,
+			"dead_at":    now,
+			"last_error": lastError,
+		}).Error
+}
+
+// DeleteFinishedBefore удаляет отправленные и брошенные сообщения старше before.
+func (r *OutboxRepo) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, r.db).
+		Where("published_at < ? OR dead_at < ?", before, before).
+		Delete(&models.OutboxMessage{})
+	return res.RowsAffected, res.Error
+}
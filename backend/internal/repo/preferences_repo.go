@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"warranty_days/internal/models"
+)
+
+type PreferencesRepo struct {
+	db *gorm.DB
+}
+
+func NewPreferencesRepo(db *gorm.DB) *PreferencesRepo {
+	return &PreferencesRepo{db: db}
+}
+
+// Get возвращает настройки пользователя или дефолтные, если он их еще не сохранял.
+func (r *PreferencesRepo) Get(ctx context.Context, userID int64) (models.UserPreferences, error) {
+	var prefs models.UserPreferences
+	err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Take(&prefs).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.DefaultUserPreferences(userID), nil
+		}
+		return models.UserPreferences{}, err
+	}
+	return prefs, nil
+}
+
+func (r *PreferencesRepo) Upsert(ctx context.Context, prefs *models.UserPreferences) error {
+	if prefs == nil {
+		return errors.New("preferences is nil")
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"timezone", "language", "default_as_of", "updated_at"}),
+		}).
+		Create(prefs).Error
+}
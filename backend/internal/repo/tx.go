@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+type txContextKey struct{}
+
+// TxManager выполняет несколько операций репозиториев в одной транзакции.
+// Транзакция передается через context, репозитории подхватывают ее сами.
+type TxManager struct {
+	db *gorm.DB
+}
+
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// WithinReadSnapshot выполняет fn в транзакции REPEATABLE READ только на чтение: все запросы fn видят один
+// снимок данных. Внутри уже открытой транзакции работает в ней.
+func (m *TxManager) WithinReadSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
+// WithinSavepoint выполняет fn в точке сохранения текущей транзакции: ошибка fn откатывает только
+// сделанное в fn, и транзакция остается рабочей. Вне транзакции работает как WithinTx.
+func (m *TxManager) WithinSavepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, ok := ctx.Value(txContextKey{}).(*gorm.DB)
+	if !ok {
+		return m.WithinTx(ctx, fn)
+	}
+
+	// вложенный Transaction у GORM — SAVEPOINT / ROLLBACK TO SAVEPOINT
+	return tx.WithContext(ctx).Transaction(func(sp *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, sp))
+	})
+}
+
+// conn возвращает транзакцию из контекста, если она есть, иначе базовое соединение.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -25,14 +26,14 @@ func (r *UserRepo) Create(ctx context.Context, user *models.User) error {
 
 	user.Email = normalizeEmail(user.Email)
 
-	return r.db.WithContext(ctx).Create(user).Error
+	return conn(ctx, r.db).Create(user).Error
 }
 
 func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	email = normalizeEmail(email)
 
 	var user models.User
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("LOWER(email) = LOWER(?)", email).
 		Take(&user).Error
 	if err != nil {
@@ -44,13 +45,64 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User,
 
 func (r *UserRepo) GetByID(ctx context.Context, id int64) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
+	err := conn(ctx, r.db).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+func (r *UserRepo) UpdatePasswordHash(ctx context.Context, id int64, hash string) error {
+	return conn(ctx, r.db).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Update("password_hash", hash).Error
+}
+
+// MarkEmailVerified активирует аккаунт после подтверждения email. Срабатывает только для аккаунта,
+// который еще ждет подтверждения: выключенный администратором аккаунт повторная ссылка не включит.
+func (r *UserRepo) MarkEmailVerified(ctx context.Context, id int64, now time.Time) error {
+	return conn(ctx, r.db).
+		Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Updates(map[string]any{"is_active": true, "email_verified_at": now}).Error
+}
+
+// SetPendingTOTPSecret сохраняет секрет новой привязки. 2FA включается только после EnableTOTP.
+func (r *UserRepo) SetPendingTOTPSecret(ctx context.Context, id int64, encryptedSecret string) error {
+	return conn(ctx, r.db).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"totp_secret": encryptedSecret, "totp_enabled_at": nil, "totp_last_step": 0}).Error
+}
+
+func (r *UserRepo) EnableTOTP(ctx context.Context, id int64, now time.Time) error {
+	return conn(ctx, r.db).
+		Model(&models.User{}).
+		Where("id = ? AND totp_secret IS NOT NULL", id).
+		Update("totp_enabled_at", now).Error
+}
+
+func (r *UserRepo) DisableTOTP(ctx context.Context, id int64) error {
+	return conn(ctx, r.db).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"totp_secret": nil, "totp_enabled_at": nil, "totp_last_step": 0}).Error
+}
+
+// AdvanceTOTPStep запоминает шаг последнего принятого кода.
+// Возвращает false, если этот или более поздний шаг уже использован (повтор кода).
+func (r *UserRepo) AdvanceTOTPStep(ctx context.Context, id int64, step int64) (bool, error) {
+	res := conn(ctx, r.db).
+		Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
 func normalizeEmail(email string) string {
 	return strings.ToLower(strings.TrimSpace(email))
 }
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/models"
+)
+
+// warrantyTotalsCTE — текущий гарантийный год и сумма дней ремонта в нем для каждого VIN на дату @now,
+// в totals. Окно гарантийного года и подсчет дней те же, что у ListWarrantyYearRepairsByVIN: годовщина
+// считается как в warrantyYearStart (make_date от первого числа месяца плюс день, так 29 февраля
+// в невисокосный год дает 1 марта, а не 28 февраля, как date + interval). Другие запросы по дням
+// гарантийного года читают vin_warranty_status, а не считают заново.
+const warrantyTotalsCTE = `
+WITH vins AS (
+    SELECT UPPER(vin) AS vin, MIN(retail_date)::date AS retail_date
+    FROM claims
+    GROUP BY UPPER(vin)
+), parts AS (
+    SELECT vin, retail_date,
+        date_part('year', retail_date)::int AS y,
+        date_part('month', retail_date)::int AS m,
+        date_part('day', retail_date)::int - 1 AS d,
+        date_part('year', @now::date)::int - date_part('year', retail_date)::int AS years
+    FROM vins
+    WHERE retail_date <= @now::date
+), windows AS (
+    SELECT vin, retail_date,
+        make_date(y + n, m, 1) + d AS warranty_start,
+        make_date(y + n + 1, m, 1) + d - 1 AS warranty_end
+    FROM parts,
+        LATERAL (
+            SELECT CASE WHEN make_date(y + years, m, 1) + d > @now::date THEN years - 1 ELSE years END AS n
+        ) current_year
+), totals AS (
+    SELECT w.vin, w.retail_date, w.warranty_start, w.warranty_end,
+        COALESCE(SUM(
+            LEAST(c.ro_close_date::date, w.warranty_end) - GREATEST(c.ro_open_date::date, w.warranty_start) + 1
+        ), 0) AS total_days
+    FROM windows w
+    LEFT JOIN claims c ON UPPER(c.vin) = w.vin
+        AND c.ro_open_date::date <= w.warranty_end
+        AND c.ro_close_date::date >= w.warranty_start
+    GROUP BY w.vin, w.retail_date, w.warranty_start, w.warranty_end
+)`
+
+// Строка обновляется, только если что-то изменилось: updated_at — время последнего изменения статуса.
+const recalculateWarrantyStatusSQL = warrantyTotalsCTE + `
+INSERT INTO vin_warranty_status AS s (vin, retail_date, warranty_start, warranty_end, total_days, status, updated_at)
+SELECT vin, retail_date, warranty_start, warranty_end, total_days,
+    CASE
+        WHEN total_days > @limit THEN 'exceeded'
+        WHEN @warning > 0 AND total_days >= @warning THEN 'approaching'
+        ELSE 'ok'
+    END,
+    @updated_at
+FROM totals
+ON CONFLICT (vin) DO UPDATE SET
+    retail_date = EXCLUDED.retail_date,
+    warranty_start = EXCLUDED.warranty_start,
+    warranty_end = EXCLUDED.warranty_end,
+    total_days = EXCLUDED.total_days,
+    status = EXCLUDED.status,
+    updated_at = EXCLUDED.updated_at
+WHERE (s.retail_date, s.warranty_start, s.total_days, s.status)
+    IS DISTINCT FROM (EXCLUDED.retail_date, EXCLUDED.warranty_start, EXCLUDED.total_days, EXCLUDED.status)`
+
+type WarrantyStatusRepo struct {
+	db *gorm.DB
+}
+
+func NewWarrantyStatusRepo(db *gorm.DB) *WarrantyStatusRepo {
+	return &WarrantyStatusRepo{db: db}
+}
+
+// Recalculate пересчитывает статус всех VIN на дату now. Возвращает число новых и изменившихся строк.
+func (r *WarrantyStatusRepo) Recalculate(
+	ctx context.Context,
+	now time.Time,
+	warning int,
+	limit int,
+) (int64, error) {
+	res := conn(ctx, r.db).Exec(recalculateWarrantyStatusSQL, map[string]any{
+		"now":        toUTCDate(now),
+		"warning":    warning,
+		"limit":      limit,
+		"updated_at": now,
+	})
+	return res.RowsAffected, res.Error
+}
+
+// DeleteStale удаляет статусы VIN, у которых не осталось заявок или гарантия еще не началась.
+func (r *WarrantyStatusRepo) DeleteStale(ctx context.Context, now time.Time) (int64, error) {
+	res := conn(ctx, r.db).
+		Where("vin NOT IN (SELECT UPPER(vin) FROM claims WHERE retail_date <= ?::date)", toUTCDate(now)).
+		Delete(&models.VINWarrantyStatus{})
+	return res.RowsAffected, res.Error
+}
+
+// CountWithStatus — число VIN в статусе status на момент последнего пересчета.
+func (r *WarrantyStatusRepo) CountWithStatus(ctx context.Context, status string) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&models.VINWarrantyStatus{}).
+		Where("status = ?", status).
+		Count(&count).Error
+	return count, err
+}
+
+// CountByStatus — число VIN в каждом статусе.
+func (r *WarrantyStatusRepo) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := conn(ctx, r.db).
+		Model(&models.VINWarrantyStatus{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{
+		models.WarrantyStatusOK:          0,
+		models.WarrantyStatusApproaching: 0,
+		models.WarrantyStatusExceeded:    0,
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
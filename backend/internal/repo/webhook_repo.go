@@ -0,0 +1,209 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"warranty_days/internal/models"
+)
+
+// Доставки забираются с арендой: next_attempt_at сдвигается на время попытки, и другой инстанс
+// (или этот же после падения) возьмет доставку снова только после ее окончания. Доставка берется, только
+// если у подписки нет более ранней pending-доставки того же VIN: та ждет повтора или уже в работе,
+// и события VIN дошли бы не по порядку. Так по каждой паре (подписка, VIN) в полете не больше одной доставки.
+const claimDueDeliveriesSQL = `
+UPDATE webhook_deliveries d
+SET next_attempt_at = now() + make_interval(secs => @lease), updated_at = now()
+WHERE d.id IN (
+    SELECT wd.id
+    FROM webhook_deliveries wd
+    JOIN webhook_subscriptions s ON s.id = wd.subscription_id AND s.is_active
+    WHERE wd.status = 'pending' AND wd.next_attempt_at <= now()
+      AND NOT EXISTS (
+          SELECT 1
+          FROM webhook_deliveries p
+          WHERE p.subscription_id = wd.subscription_id
+            AND p.vin = wd.vin
+            AND p.status = 'pending'
+            AND p.id < wd.id
+      )
+    ORDER BY wd.next_attempt_at, wd.id
+    LIMIT @limit
+    FOR UPDATE OF wd SKIP LOCKED
+)
+RETURNING d.*`
+
+type WebhookRepo struct {
+	db *gorm.DB
+}
+
+// DeliveryAttempt — итог одной попытки доставки.
+type DeliveryAttempt struct {
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	StatusCode    *int
+	Error         *string
+	DeliveredAt   *time.Time
+}
+
+func NewWebhookRepo(db *gorm.DB) *WebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	if sub == nil {
+		return errors.New("webhook subscription is nil")
+	}
+	return conn(ctx, r.db).Create(sub).Error
+}
+
+func (r *WebhookRepo) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := conn(ctx, r.db).Where("id = ?", id).Take(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := conn(ctx, r.db).Order("id ASC").Find(&subs).Error
+	return subs, err
+}
+
+func (r *WebhookRepo) GetSubscriptions(ctx context.Context, ids []int64) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := conn(ctx, r.db).Where("id IN ?", ids).Find(&subs).Error
+	return subs, err
+}
+
+// ActiveSubscriptionsFor возвращает включенные подписки на тип события.
+func (r *WebhookRepo) ActiveSubscriptionsFor(
+	ctx context.Context,
+	eventType string,
+) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := conn(ctx, r.db).
+		Where("is_active AND event_types @> jsonb_build_array(?::text)", eventType).
+		Order("id ASC").
+		Find(&subs).Error
+	return subs, err
+}
+
+// UpdateSubscription сохраняет изменяемые поля; секрет и автор не меняются.
+func (r *WebhookRepo) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	res := conn(ctx, r.db).
+		Model(sub).
+		Select("url", "description", "event_types", "is_active", "updated_at").
+		Updates(sub)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, id int64) error {
+	res := conn(ctx, r.db).Where("id = ?", id).Delete(&models.WebhookSubscription{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries ставит доставки в очередь. Повторная публикация того же события подписчику пропускается.
+func (r *WebhookRepo) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "event_id"}},
+			DoNothing: true,
+		}).
+		Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries забирает до limit доставок, у которых подошло время попытки.
+func (r *WebhookRepo) ClaimDueDeliveries(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := conn(ctx, r.db).
+		Raw(claimDueDeliveriesSQL, map[string]any{"limit": limit, "lease": lease.Seconds()}).
+		Scan(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, id int64, attempt DeliveryAttempt) error {
+	return conn(ctx, r.db).
+		Model(&models.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":           attempt.Status,
+			"attempts":         attempt.Attempts,
+			"next_attempt_at":  attempt.NextAttemptAt,
+			"last_status_code": attempt.StatusCode,
+			"last_error":       attempt.Error,
+			"delivered_at":     attempt.DeliveredAt,
+			"updated_at":       time.Now(),
+		}).Error
+}
+
+// ListDeliveries — журнал доставок подписки, новые первыми. Пустой status — все статусы.
+func (r *WebhookRepo) ListDeliveries(
+	ctx context.Context,
+	subscriptionID int64,
+	status string,
+	limit int,
+) ([]models.WebhookDelivery, error) {
+	query := conn(ctx, r.db).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// RequeueDelivery ставит доставку в очередь заново с полным запасом попыток.
+func (r *WebhookRepo) RequeueDelivery(
+	ctx context.Context,
+	subscriptionID int64,
+	deliveryID int64,
+	now time.Time,
+) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	res := conn(ctx, r.db).
+		Model(&delivery).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).
+		// ошибка прошлого круга попыток к новому не относится
+		Updates(map[string]any{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"last_error":      nil,
+			"updated_at":      now,
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &delivery, nil
+}
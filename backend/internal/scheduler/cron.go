@@ -0,0 +1,236 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("invalid cron expression")
+
+// cronMacros — сокращения в духе cron.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	weekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// cronSearchLimit — дальше этого расписание считается невыполнимым (например, 30 февраля).
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// Schedule — разобранное cron-выражение из пяти полей: минута, час, день месяца, месяц, день недели.
+// Поддерживаются *, списки, диапазоны, шаги (*/15, 1-5/2), имена месяцев и дней недели и макросы @daily и др.
+// Как в классическом cron, если ограничены и день месяца, и день недели, подходит любой из них.
+//
+// Переход на летнее и зимнее время обрабатывается как в Vixie cron. Задача с заданными минутой и часом
+// ("30 2 * * *") идет по часам на стене: время, выпавшее при переводе вперед, наступает сразу после перевода
+// (02:30 -> 03:30), а повторившееся при переводе назад запускает задачу один раз. Задачи с * или шагом от *
+// в минуте или часе ("*/15 * * * *", "0 * * * *") идут по реальному времени.
+type Schedule struct {
+	spec string
+
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+	// wallClock — минута и час заданы явно: слоты считаются по часам на стене, см. Schedule.
+	wallClock bool
+}
+
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	expr := spec
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("%w %q: want 5 fields, got %d", ErrInvalidSchedule, spec, len(fields))
+	}
+
+	s := Schedule{spec: spec}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: minute: %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: hour: %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: day of month: %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: month: %v", ErrInvalidSchedule, spec, err)
+	}
+	// 7 — тоже воскресенье
+	if s.dow, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: day of week: %v", ErrInvalidSchedule, spec, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*" || fields[2] == "?"
+	s.dowAny = fields[4] == "*" || fields[4] == "?"
+	s.wallClock = !strings.ContainsAny(fields[0][:1]+fields[1][:1], "*?")
+	return s, nil
+}
+
+func (s Schedule) String() string {
+	return s.spec
+}
+
+// Next — первый момент расписания строго после after, в часовом поясе after. Нулевое время — расписание
+// невыполнимо.
+func (s Schedule) Next(after time.Time) time.Time {
+	if !s.wallClock {
+		return s.next(after)
+	}
+
+	// ищем по часам на стене без переводов времени (UTC), затем переносим найденное время в пояс after
+	loc := after.Location()
+	wall := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, time.UTC)
+	for {
+		wall = s.next(wall)
+		if wall.IsZero() {
+			return time.Time{}
+		}
+		t := firstOccurrence(time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, loc))
+		// повторившийся при переводе назад слот уже прошел в первый раз
+		if t.After(after) {
+			return t
+		}
+	}
+}
+
+// next ищет слот по реальному времени: минута за минутой в поясе after.
+func (s Schedule) next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case !bitSet(s.month, int(month)):
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		case !bitSet(s.hour, t.Hour()):
+			next := time.Date(year, month, day, t.Hour()+1, 0, 0, 0, loc)
+			// при переводе часов назад тот же час повторяется — не даем времени пойти вспять
+			if !next.After(t) {
+				next = t.Truncate(time.Hour).Add(time.Hour)
+			}
+			t = next
+		case !bitSet(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// firstOccurrence возвращает первое из двух мгновений с тем же временем на стене, если t попало в час,
+// повторившийся при переводе часов назад. time.Date в этом случае может вернуть любое из них.
+func firstOccurrence(t time.Time) time.Time {
+	_, offset := t.Zone()
+	// за 12 часов до t действовало смещение до перевода: переводы не бывают чаще
+	_, before := t.Add(-12 * time.Hour).Zone()
+	if before <= offset {
+		return t
+	}
+	earlier := t.Add(-time.Duration(before-offset) * time.Second)
+	if earlier.Day() == t.Day() && earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute() {
+		return earlier
+	}
+	return t
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	domOK := bitSet(s.dom, t.Day())
+	dowOK := bitSet(s.dow, int(t.Weekday()))
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowOK
+	case s.dowAny:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// parseCronField разбирает поле в битовую маску допустимых значений.
+func parseCronField(field string, lo, hi int, names map[string]int) (uint64, error) {
+	var mask uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		var from, to int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			from, to = lo, hi
+		default:
+			startPart, endPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if from, err = cronValue(startPart, names); err != nil {
+				return 0, err
+			}
+			to = from
+			if isRange {
+				if to, err = cronValue(endPart, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// 5/15 — с 5 до конца диапазона
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+
+		for v := from; v <= to; v += step {
+			mask |= 1 << v
+		}
+	}
+	return mask, nil
+}
+
+func cronValue(value string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return v, nil
+}
+
+func bitSet(mask uint64, v int) bool {
+	return mask&(1<<v) != 0
+}
@@ -0,0 +1,232 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "every minute", spec: "* * * * *"},
+		{name: "lists ranges and steps", spec: "0,30 8-18/2 1-15 */3 mon-fri"},
+		{name: "names in any case", spec: "0 0 * JAN,Jul Sun"},
+		{name: "question mark", spec: "0 0 ? * ?"},
+		{name: "sunday as 7", spec: "0 0 * * 7"},
+		{name: "macro with spaces and case", spec: "  @Daily "},
+		{name: "empty", spec: "", wantErr: true},
+		{name: "four fields", spec: "* * * *", wantErr: true},
+		{name: "six fields", spec: "0 * * * * *", wantErr: true},
+		{name: "minute out of range", spec: "60 * * * *", wantErr: true},
+		{name: "hour out of range", spec: "* 24 * * *", wantErr: true},
+		{name: "day of month zero", spec: "* * 0 * *", wantErr: true},
+		{name: "month out of range", spec: "* * * 13 *", wantErr: true},
+		{name: "day of week out of range", spec: "* * * * 8", wantErr: true},
+		{name: "zero step", spec: "*/0 * * * *", wantErr: true},
+		{name: "reversed range", spec: "5-1 * * * *", wantErr: true},
+		{name: "unknown name", spec: "* * * foo *", wantErr: true},
+		{name: "unknown macro", spec: "@every 5m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchedule(tt.spec)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ParseSchedule(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSchedule) {
+				t.Fatalf("ParseSchedule(%q) error = %v, want %v", tt.spec, err, ErrInvalidSchedule)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  string
+		after string
+		want  string
+	}{
+		{name: "step", spec: "*/15 * * * *", after: "2026-01-01T10:07:30Z", want: "2026-01-01T10:15:00Z"},
+		{name: "strictly after", spec: "*/15 * * * *", after: "2026-01-01T10:15:00Z", want: "2026-01-01T10:30:00Z"},
+		{name: "step from offset", spec: "5/20 * * * *", after: "2026-01-01T10:30:00Z", want: "2026-01-01T10:45:00Z"},
+		{name: "next day", spec: "0 3 * * *", after: "2026-01-01T03:00:00Z", want: "2026-01-02T03:00:00Z"},
+		// 2026-01-01 — четверг
+		{name: "day of week only", spec: "0 0 * * 1", after: "2026-01-01T00:00:00Z", want: "2026-01-05T00:00:00Z"},
+		{name: "day of month only", spec: "0 0 13 * *", after: "2026-01-01T00:00:00Z", want: "2026-01-13T00:00:00Z"},
+		// ограничены оба поля — подходит любое: пятница 2-го раньше 13-го
+		{name: "day of month or week", spec: "0 0 13 * 5", after: "2026-01-01T00:00:00Z", want: "2026-01-02T00:00:00Z"},
+		{name: "first or monday", spec: "0 0 1 * mon", after: "2026-01-01T00:00:00Z", want: "2026-01-05T00:00:00Z"},
+		{name: "question mark day", spec: "0 0 ? * mon-fri", after: "2026-01-02T00:00:00Z", want: "2026-01-05T00:00:00Z"},
+		{name: "sunday as 7", spec: "0 9 * * 7", after: "2026-01-01T00:00:00Z", want: "2026-01-04T09:00:00Z"},
+		{name: "month names", spec: "0 0 1 jan,jul *", after: "2026-02-01T00:00:00Z", want: "2026-07-01T00:00:00Z"},
+		{name: "31st skips short months", spec: "0 12 31 * *", after: "2026-04-01T00:00:00Z", want: "2026-05-31T12:00:00Z"},
+		{name: "leap day", spec: "0 0 29 2 *", after: "2026-03-01T00:00:00Z", want: "2028-02-29T00:00:00Z"},
+		{name: "impossible date", spec: "0 0 30 2 *", after: "2026-01-01T00:00:00Z"},
+		{name: "@yearly", spec: "@yearly", after: "2026-03-01T00:00:00Z", want: "2027-01-01T00:00:00Z"},
+		{name: "@annually", spec: "@annually", after: "2026-03-01T00:00:00Z", want: "2027-01-01T00:00:00Z"},
+		{name: "@monthly", spec: "@monthly", after: "2026-01-31T23:59:00Z", want: "2026-02-01T00:00:00Z"},
+		{name: "@weekly", spec: "@weekly", after: "2026-01-01T00:00:00Z", want: "2026-01-04T00:00:00Z"},
+		{name: "@daily", spec: "@daily", after: "2026-01-01T00:00:00Z", want: "2026-01-02T00:00:00Z"},
+		{name: "@midnight", spec: "@midnight", after: "2026-01-01T23:59:59Z", want: "2026-01-02T00:00:00Z"},
+		{name: "@hourly", spec: "@hourly", after: "2026-01-01T10:00:00Z", want: "2026-01-01T11:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkNext(t, tt.spec, time.UTC, tt.after, tt.want)
+		})
+	}
+}
+
+// Переводы часов: в Европе/Берлине 2026-03-29 02:00 -> 03:00 и 2026-10-25 03:00 -> 02:00,
+// в Нью-Йорке 2026-11-01 02:00 -> 01:00.
+func TestScheduleNextDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		spec  string
+		loc   *time.Location
+		after string
+		want  string
+	}{
+		// выпавшее время наступает сразу после перевода, а не переносится на следующий день
+		{
+			name:  "skipped wall time runs after the jump",
+			spec:  "30 2 * * *",
+			loc:   berlin,
+			after: "2026-03-28T12:00:00+01:00",
+			want:  "2026-03-29T03:30:00+02:00",
+		},
+		{
+			name:  "day after skipped wall time",
+			spec:  "30 2 * * *",
+			loc:   berlin,
+			after: "2026-03-29T03:30:00+02:00",
+			want:  "2026-03-30T02:30:00+02:00",
+		},
+		{
+			name:  "hour after the jump",
+			spec:  "0 3 * * *",
+			loc:   berlin,
+			after: "2026-03-29T01:00:00+01:00",
+			want:  "2026-03-29T03:00:00+02:00",
+		},
+		// повторившееся время — только первый раз
+		{
+			name:  "repeated wall time first occurrence",
+			spec:  "30 2 * * *",
+			loc:   berlin,
+			after: "2026-10-25T00:00:00+02:00",
+			want:  "2026-10-25T02:30:00+02:00",
+		},
+		{
+			name:  "repeated wall time runs once",
+			spec:  "30 2 * * *",
+			loc:   berlin,
+			after: "2026-10-25T02:30:00+02:00",
+			want:  "2026-10-26T02:30:00+01:00",
+		},
+		{
+			name:  "repeated wall time started during second pass",
+			spec:  "30 2 * * *",
+			loc:   berlin,
+			after: "2026-10-25T02:10:00+01:00",
+			want:  "2026-10-26T02:30:00+01:00",
+		},
+		{
+			name:  "repeated wall time in new york",
+			spec:  "30 1 * * *",
+			loc:   newYork,
+			after: "2026-11-01T01:30:00-04:00",
+			want:  "2026-11-02T01:30:00-05:00",
+		},
+		{
+			name:  "hour after fall back",
+			spec:  "0 3 * * *",
+			loc:   berlin,
+			after: "2026-10-25T00:00:00+02:00",
+			want:  "2026-10-25T03:00:00+01:00",
+		},
+		// интервальные задачи идут по реальному времени
+		{
+			name:  "interval across spring jump",
+			spec:  "*/30 * * * *",
+			loc:   berlin,
+			after: "2026-03-29T01:30:00+01:00",
+			want:  "2026-03-29T03:00:00+02:00",
+		},
+		{
+			name:  "interval repeats during fall back",
+			spec:  "*/30 * * * *",
+			loc:   berlin,
+			after: "2026-10-25T02:30:00+02:00",
+			want:  "2026-10-25T02:00:00+01:00",
+		},
+		{
+			name:  "hourly during fall back",
+			spec:  "@hourly",
+			loc:   berlin,
+			after: "2026-10-25T02:00:00+02:00",
+			want:  "2026-10-25T02:00:00+01:00",
+		},
+		{
+			name:  "hourly across spring jump",
+			spec:  "0 * * * *",
+			loc:   berlin,
+			after: "2026-03-29T01:00:00+01:00",
+			want:  "2026-03-29T03:00:00+02:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkNext(t, tt.spec, tt.loc, tt.after, tt.want)
+		})
+	}
+}
+
+// checkNext сравнивает Next с want (RFC 3339); пустой want — расписание невыполнимо.
+func checkNext(t *testing.T, spec string, loc *time.Location, after, want string) {
+	t.Helper()
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	afterTime, err := time.Parse(time.RFC3339, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := schedule.Next(afterTime.In(loc))
+	if want == "" {
+		if !got.IsZero() {
+			t.Fatalf("Next(%s) = %s, want zero time", after, got)
+		}
+		return
+	}
+	wantTime, err := time.Parse(time.RFC3339, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(wantTime) {
+		t.Fatalf("Next(%s) = %s, want %s", after, got.Format(time.RFC3339), want)
+	}
+	if got.Location() != loc {
+		t.Fatalf("Next(%s) location = %s, want %s", after, got.Location(), loc)
+	}
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// leaderLockKey — ключ pg_try_advisory_lock, общий для всех инстансов.
+const leaderLockKey int64 = 0x77645f6a6f6273
+
+// PostgresLocker — лидерство через advisory-блокировку Postgres на отдельном соединении.
+type PostgresLocker struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresLocker(pool *pgxpool.Pool) *PostgresLocker {
+	return &PostgresLocker{pool: pool}
+}
+
+func (l *PostgresLocker) Connect(ctx context.Context) (LockSession, error) {
+	poolConn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// блокировка привязана к сессии — соединение в пул не возвращаем
+	return postgresSession{conn: poolConn.Hijack()}, nil
+}
+
+type postgresSession struct {
+	conn *pgx.Conn
+}
+
+func (s postgresSession) TryLock(ctx context.Context) (bool, error) {
+	var locked bool
+	err := s.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&locked)
+	return locked, err
+}
+
+func (s postgresSession) Ping(ctx context.Context) error {
+	return s.conn.Ping(ctx)
+}
+
+func (s postgresSession) Close() {
+	_ = s.conn.Close(context.Background())
+}
@@ -0,0 +1,468 @@
+// Package scheduler запускает периодические задачи по cron-расписанию. Инстансов API может быть несколько:
+// задачи выполняет только лидер — инстанс, который держит advisory-блокировку Postgres.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"warranty_days/internal/config"
+	"warranty_days/internal/models"
+)
+
+const (
+	// finishTimeout — на запись результата запуска; контекст задачи к этому моменту может быть отменен.
+	finishTimeout = 10 * time.Second
+	// pruneInterval — как часто удалять историю старше HistoryRetention.
+	pruneInterval = time.Hour
+	// interruptedError — ошибка запусков, которые оборвались вместе с прежним лидером.
+	interruptedError = "interrupted: scheduler leader changed"
+)
+
+type Config struct {
+	// PollInterval — как часто проверять расписание, очередь ручных запусков и лидерство.
+	PollInterval time.Duration
+	// Location — часовой пояс cron-выражений.
+	Location *time.Location
+	// Timeout — предел одного запуска.
+	Timeout          time.Duration
+	HistoryRetention time.Duration
+}
+
+func ConfigFromApp(cfg config.Config) Config {
+	return Config{
+		PollInterval:     cfg.SchedulerPollInterval,
+		Location:         cfg.SchedulerLocation,
+		Timeout:          cfg.SchedulerJobTimeout,
+		HistoryRetention: cfg.SchedulerHistoryRetention,
+	}
+}
+
+// Store — история запусков, пауза и очередь ручных запусков; реализация — repo.JobRepo.
+type Store interface {
+	States(ctx context.Context) (map[string]models.ScheduledJob, error)
+	Insert(ctx context.Context, run *models.JobRun) (bool, error)
+	ClaimQueued(ctx context.Context, names []string, now time.Time) ([]models.JobRun, error)
+	Finish(
+		ctx context.Context,
+		id int64,
+		status string,
+		now time.Time,
+		result json.RawMessage,
+		runErr *string,
+	) error
+	FailRunning(ctx context.Context, now time.Time, runErr string) (int64, error)
+	LastScheduledFor(ctx context.Context, name string) (*time.Time, error)
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Locker открывает сессию, на которой держится блокировка лидера; реализация — PostgresLocker.
+type Locker interface {
+	Connect(ctx context.Context) (LockSession, error)
+}
+
+// LockSession — сессия с блокировкой лидера: блокировка живет, пока жива сессия, и снимается при Close
+// или обрыве соединения.
+type LockSession interface {
+	// TryLock берет блокировку, если ее никто не держит; false — лидер другой инстанс.
+	TryLock(ctx context.Context) (bool, error)
+	// Ping проверяет, что сессия, а с ней и блокировка, еще жива.
+	Ping(ctx context.Context) error
+	Close()
+}
+
+// Job — периодическая задача. Run возвращает сводку работы, она сохраняется в истории запусков.
+type Job struct {
+	Name        string
+	Description string
+	// Schedule — cron-выражение, см. ParseSchedule.
+	Schedule string
+	Run      func(ctx context.Context) (any, error)
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+}
+
+// Scheduler знает все задачи на каждом инстансе (для API администратора), а выполняет их только после Start
+// и только пока он лидер. Пауза и ручной запуск хранятся в БД, поэтому их видит любой лидер.
+type Scheduler struct {
+	cfg     Config
+	locker  Locker
+	jobRepo Store
+	entries []entry
+	logger  *slog.Logger
+	nowFn   func() time.Time
+
+	// только в цикле планировщика: сессия с блокировкой, лидерство и следующий слот каждой задачи
+	session   LockSession
+	leader    *leadership
+	next      map[string]time.Time
+	lastPrune time.Time
+
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+func New(
+	cfg Config,
+	locker Locker,
+	jobRepo Store,
+	jobs []Job,
+	logger *slog.Logger,
+) (*Scheduler, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	entries := make([]entry, 0, len(jobs))
+	seen := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		if seen[job.Name] {
+			return nil, fmt.Errorf("job %s is registered twice", job.Name)
+		}
+		seen[job.Name] = true
+
+		schedule, err := ParseSchedule(job.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		entries = append(entries, entry{job: job, schedule: schedule})
+	}
+
+	return &Scheduler{
+		cfg:     cfg,
+		locker:  locker,
+		jobRepo: jobRepo,
+		entries: entries,
+		logger:  logger,
+		nowFn:   time.Now,
+		next:    make(map[string]time.Time),
+		running: make(map[string]bool),
+	}, nil
+}
+
+// Jobs — зарегистрированные задачи в порядке регистрации.
+func (s *Scheduler) Jobs() []Job {
+	jobs := make([]Job, 0, len(s.entries))
+	for _, e := range s.entries {
+		jobs = append(jobs, e.job)
+	}
+	return jobs
+}
+
+func (s *Scheduler) Job(name string) (Job, bool) {
+	for _, e := range s.entries {
+		if e.job.Name == name {
+			return e.job, true
+		}
+	}
+	return Job{}, false
+}
+
+// NextRun — следующий слот расписания задачи после now; нулевое время, если задачи нет.
+func (s *Scheduler) NextRun(name string, now time.Time) time.Time {
+	for _, e := range s.entries {
+		if e.job.Name == name {
+			return e.schedule.Next(now.In(s.cfg.Location))
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		s.loop(ctx)
+	}()
+}
+
+// Shutdown отменяет выполняющиеся задачи и ждет их завершения: запуски сохраняются как failed.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+	s.stop()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop держит отдельную сессию: блокировка лидера живет, пока живо соединение, поэтому лидер,
+// потерявший БД, перестает быть лидером сам, а другой инстанс забирает блокировку.
+func (s *Scheduler) loop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	defer s.disconnect()
+
+	for {
+		s.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll — один шаг цикла: восстановить сессию, взять или проверить лидерство и, если лидер, запустить задачи.
+func (s *Scheduler) poll(ctx context.Context) {
+	if s.session == nil {
+		session, err := s.locker.Connect(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.WarnContext(ctx, "scheduler cannot connect to postgres", "error", err)
+			}
+			return
+		}
+		s.session = session
+	}
+
+	var err error
+	if s.leader == nil {
+		var locked bool
+		locked, err = s.session.TryLock(ctx)
+		if err == nil && locked {
+			s.leader = newLeadership(ctx)
+			s.lead(s.leader.ctx)
+		}
+	} else {
+		err = s.session.Ping(ctx)
+	}
+
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.WarnContext(ctx, "scheduler connection lost", "error", err)
+			if s.leader != nil {
+				s.logger.WarnContext(ctx, "scheduler leadership lost, cancelling running jobs")
+			}
+		}
+		s.disconnect()
+		return
+	}
+
+	if s.leader != nil {
+		s.tick(s.leader.ctx)
+	}
+}
+
+// disconnect снимает лидерство, дожидаясь отмененных задач, и закрывает сессию вместе с блокировкой.
+func (s *Scheduler) disconnect() {
+	if s.leader != nil {
+		s.leader.resign()
+		s.wg.Wait()
+		s.leader = nil
+	}
+	if s.session != nil {
+		s.session.Close()
+		s.session = nil
+	}
+}
+
+// leadership — контекст, в котором лидер запускает задачи; resign отменяет их при потере лидерства.
+type leadership struct {
+	ctx    context.Context
+	resign context.CancelFunc
+}
+
+func newLeadership(ctx context.Context) *leadership {
+	leaderCtx, resign := context.WithCancel(ctx)
+	return &leadership{ctx: leaderCtx, resign: resign}
+}
+
+// lead готовит нового лидера: закрывает запуски прежнего и продолжает расписание с последнего слота.
+// Пропущенный, пока лидера не было, слот запускается один раз, сразу.
+func (s *Scheduler) lead(ctx context.Context) {
+	s.logger.InfoContext(ctx, "scheduler leadership acquired")
+	now := s.nowFn()
+
+	interrupted, err := s.jobRepo.FailRunning(ctx, now, interruptedError)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to close interrupted job runs", "error", err)
+	} else if interrupted > 0 {
+		s.logger.WarnContext(ctx, "job runs interrupted by leader change", "runs", interrupted)
+	}
+
+	for _, e := range s.entries {
+		from := now
+		last, err := s.jobRepo.LastScheduledFor(ctx, e.job.Name)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to read last job slot", "job", e.job.Name, "error", err)
+		} else if last != nil {
+			from = *last
+		}
+		s.next[e.job.Name] = e.schedule.Next(from.In(s.cfg.Location))
+	}
+}
+
+// tick запускает ручные запуски из очереди и задачи, у которых подошел слот расписания.
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.nowFn()
+
+	states, err := s.jobRepo.States(ctx)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to read job states", "error", err)
+		return
+	}
+
+	idle := make([]string, 0, len(s.entries))
+	for _, e := range s.entries {
+		if !s.isRunning(e.job.Name) {
+			idle = append(idle, e.job.Name)
+		}
+	}
+	queued, err := s.jobRepo.ClaimQueued(ctx, idle, now)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to claim queued job runs", "error", err)
+	}
+	for _, run := range queued {
+		for _, e := range s.entries {
+			if e.job.Name == run.JobName {
+				s.start(ctx, e.job, run)
+			}
+		}
+	}
+
+	for _, e := range s.entries {
+		slot := s.next[e.job.Name]
+		if slot.IsZero() || now.Before(slot) {
+			continue
+		}
+		s.next[e.job.Name] = e.schedule.Next(now.In(s.cfg.Location))
+
+		// на паузе слот пропускается; после снятия паузы задача ждет следующего
+		if states[e.job.Name].Paused {
+			continue
+		}
+
+		run := models.JobRun{
+			JobName:      e.job.Name,
+			Source:       models.JobSourceSchedule,
+			Status:       models.JobRunRunning,
+			ScheduledFor: &slot,
+			StartedAt:    &now,
+		}
+		inserted, err := s.jobRepo.Insert(ctx, &run)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to start scheduled job", "job", e.job.Name, "error", err)
+			continue
+		}
+		if !inserted {
+			s.logger.InfoContext(ctx, "job slot skipped: previous run is still active or slot already ran",
+				"job", e.job.Name,
+				"scheduled_for", slot,
+			)
+			continue
+		}
+		s.start(ctx, e.job, run)
+	}
+
+	s.prune(ctx, now)
+}
+
+func (s *Scheduler) start(ctx context.Context, job Job, run models.JobRun) {
+	s.setRunning(job.Name, true)
+	s.wg.Go(func() {
+		defer s.setRunning(job.Name, false)
+		s.execute(ctx, job, run)
+	})
+}
+
+func (s *Scheduler) execute(ctx context.Context, job Job, run models.JobRun) {
+	runCtx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	logger := s.logger.With("job", job.Name, "run_id", run.ID, "source", run.Source)
+	logger.InfoContext(ctx, "job started")
+	started := time.Now()
+
+	result, err := safeRun(runCtx, job)
+
+	var raw json.RawMessage
+	if result != nil {
+		var marshalErr error
+		if raw, marshalErr = json.Marshal(result); marshalErr != nil {
+			logger.WarnContext(ctx, "failed to encode job result", "error", marshalErr)
+			raw = nil
+		}
+	}
+
+	status := models.JobRunSucceeded
+	var runErr *string
+	if err != nil {
+		status = models.JobRunFailed
+		msg := err.Error()
+		runErr = &msg
+		logger.ErrorContext(ctx, "job failed", "duration", time.Since(started).String(), "error", err)
+	} else {
+		logger.InfoContext(ctx, "job finished", "duration", time.Since(started).String())
+	}
+
+	finishCtx, cancelFinish := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancelFinish()
+	if err := s.jobRepo.Finish(finishCtx, run.ID, status, s.nowFn(), raw, runErr); err != nil {
+		logger.ErrorContext(ctx, "failed to record job result", "error", err)
+	}
+}
+
+// safeRun превращает панику задачи в ошибку запуска: планировщик продолжает работать.
+func safeRun(ctx context.Context, job Job) (result any, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) prune(ctx context.Context, now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	deleted, err := s.jobRepo.DeleteFinishedBefore(ctx, now.Add(-s.cfg.HistoryRetention))
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to prune job runs", "error", err)
+		return
+	}
+	if deleted > 0 {
+		s.logger.InfoContext(ctx, "job runs pruned", "deleted", deleted)
+	}
+}
+
+func (s *Scheduler) isRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[name]
+}
+
+func (s *Scheduler) setRunning(name string, running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if running {
+		s.running[name] = true
+	} else {
+		delete(s.running, name)
+	}
+}
@@ -0,0 +1,360 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"warranty_days/internal/models"
+)
+
+// memoryStore — Store в памяти, общий для нескольких планировщиков, как таблица job_runs для инстансов.
+type memoryStore struct {
+	mu       sync.Mutex
+	runs     []models.JobRun
+	paused   map[string]bool
+	failRuns int
+}
+
+func (m *memoryStore) States(context.Context) (map[string]models.ScheduledJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states := make(map[string]models.ScheduledJob, len(m.paused))
+	for name, paused := range m.paused {
+		states[name] = models.ScheduledJob{Name: name, Paused: paused}
+	}
+	return states, nil
+}
+
+// Insert повторяет уникальные индексы job_runs: один активный запуск задачи и один запуск слота.
+func (m *memoryStore) Insert(_ context.Context, run *models.JobRun) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.runs {
+		active := r.Status == models.JobRunQueued || r.Status == models.JobRunRunning
+		sameSlot := r.ScheduledFor != nil && run.ScheduledFor != nil && r.ScheduledFor.Equal(*run.ScheduledFor)
+		if r.JobName == run.JobName && (active || sameSlot) {
+			return false, nil
+		}
+	}
+	run.ID = int64(len(m.runs) + 1)
+	m.runs = append(m.runs, *run)
+	return true, nil
+}
+
+func (m *memoryStore) ClaimQueued(_ context.Context, names []string, now time.Time) ([]models.JobRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed []models.JobRun
+	for i, r := range m.runs {
+		for _, name := range names {
+			if r.JobName == name && r.Status == models.JobRunQueued {
+				m.runs[i].Status = models.JobRunRunning
+				m.runs[i].StartedAt = &now
+				claimed = append(claimed, m.runs[i])
+			}
+		}
+	}
+	return claimed, nil
+}
+
+func (m *memoryStore) Finish(
+	_ context.Context,
+	id int64,
+	status string,
+	now time.Time,
+	_ json.RawMessage,
+	runErr *string,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[id-1].Status = status
+	m.runs[id-1].FinishedAt = &now
+	m.runs[id-1].Error = runErr
+	return nil
+}
+
+func (m *memoryStore) FailRunning(_ context.Context, now time.Time, runErr string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failRuns++
+	var failed int64
+	for i, r := range m.runs {
+		if r.Status == models.JobRunRunning {
+			m.runs[i].Status = models.JobRunFailed
+			m.runs[i].FinishedAt = &now
+			m.runs[i].Error = &runErr
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+func (m *memoryStore) LastScheduledFor(_ context.Context, name string) (*time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var last *time.Time
+	for _, r := range m.runs {
+		if r.JobName == name && r.ScheduledFor != nil && (last == nil || r.ScheduledFor.After(*last)) {
+			last = r.ScheduledFor
+		}
+	}
+	return last, nil
+}
+
+func (m *memoryStore) DeleteFinishedBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryStore) snapshot() []models.JobRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.JobRun(nil), m.runs...)
+}
+
+// memoryLock — advisory-блокировка, общая для инстансов; каждый инстанс подключается к ней своим Locker.
+type memoryLock struct {
+	mu     sync.Mutex
+	holder *memorySession
+}
+
+func (l *memoryLock) Connect(context.Context) (LockSession, error) {
+	return &memorySession{lock: l}, nil
+}
+
+// memorySession — сессия одного инстанса; drop имитирует обрыв соединения: Postgres снимает блокировку сам.
+type memorySession struct {
+	lock    *memoryLock
+	dropped bool
+}
+
+var errConnectionLost = errors.New("connection lost")
+
+func (s *memorySession) TryLock(context.Context) (bool, error) {
+	s.lock.mu.Lock()
+	defer s.lock.mu.Unlock()
+	if s.dropped {
+		return false, errConnectionLost
+	}
+	if s.lock.holder == nil {
+		s.lock.holder = s
+	}
+	return s.lock.holder == s, nil
+}
+
+func (s *memorySession) Ping(context.Context) error {
+	s.lock.mu.Lock()
+	defer s.lock.mu.Unlock()
+	if s.dropped {
+		return errConnectionLost
+	}
+	return nil
+}
+
+func (s *memorySession) Close() {
+	s.drop()
+}
+
+func (s *memorySession) drop() {
+	s.lock.mu.Lock()
+	defer s.lock.mu.Unlock()
+	s.dropped = true
+	if s.lock.holder == s {
+		s.lock.holder = nil
+	}
+}
+
+// testClock — часы планировщика, которые двигает тест.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func newTestScheduler(t *testing.T, lock *memoryLock, store *memoryStore, clock *testClock, jobs ...Job) *Scheduler {
+	t.Helper()
+	s, err := New(Config{Location: time.UTC, Timeout: time.Minute}, lock, store, jobs, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.nowFn = clock.Now
+	// история не чистится: DeleteFinishedBefore в тестах не проверяется
+	s.lastPrune = clock.Now().Add(time.Hour)
+	return s
+}
+
+func TestLeaderChangeOnConnectionLoss(t *testing.T) {
+	ctx := context.Background()
+	clock := &testClock{now: time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)}
+	store := &memoryStore{}
+	lock := &memoryLock{}
+
+	// запуск прежнего лидера, упавшего посреди задачи
+	store.runs = []models.JobRun{{ID: 1, JobName: "recalc", Source: models.JobSourceManual, Status: models.JobRunRunning}}
+
+	started := make(chan struct{}, 1)
+	job := Job{Name: "recalc", Schedule: "@hourly", Run: func(ctx context.Context) (any, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	a := newTestScheduler(t, lock, store, clock, job)
+	b := newTestScheduler(t, lock, store, clock, job)
+	defer a.disconnect()
+	defer b.disconnect()
+
+	a.poll(ctx)
+	b.poll(ctx)
+	if a.leader == nil || b.leader != nil {
+		t.Fatalf("leaders after first poll: a %v, b %v; want only a", a.leader != nil, b.leader != nil)
+	}
+	// новый лидер закрывает запуски прежнего
+	if runs := store.snapshot(); store.failRuns != 1 || runs[0].Status != models.JobRunFailed ||
+		runs[0].Error == nil || *runs[0].Error != interruptedError {
+		t.Fatalf("after leader change: FailRunning called %d times, run %+v", store.failRuns, runs[0])
+	}
+
+	// a запускает задачу вручную, затем теряет соединение
+	store.runs = append(store.runs, models.JobRun{
+		ID: 2, JobName: "recalc", Source: models.JobSourceManual, Status: models.JobRunQueued,
+	})
+	a.poll(ctx)
+	<-started
+	a.session.(*memorySession).drop()
+
+	a.poll(ctx)
+	if a.leader != nil || a.session != nil {
+		t.Fatal("a kept leadership after the connection was lost")
+	}
+	// задачи потерявшего лидерство отменены и записаны как failed
+	if run := store.snapshot()[1]; run.Status != models.JobRunFailed {
+		t.Fatalf("run of the old leader = %+v, want failed", run)
+	}
+
+	b.poll(ctx)
+	if b.leader == nil {
+		t.Fatal("b did not take over the released lock")
+	}
+	if store.failRuns != 2 {
+		t.Fatalf("FailRunning called %d times, want once per leader", store.failRuns)
+	}
+
+	// a переподключается, но лидер уже b
+	a.poll(ctx)
+	if a.leader != nil {
+		t.Fatal("a took leadership while b holds the lock")
+	}
+}
+
+func TestLeaderCatchesUpMissedSlotOnce(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	clock := &testClock{now: start}
+	store := &memoryStore{}
+	lock := &memoryLock{}
+
+	// последний запуск по расписанию был в 10:00; слоты 11:00 и 12:00 прошли без лидера
+	lastSlot := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	store.runs = []models.JobRun{{
+		ID: 1, JobName: "hourly", Source: models.JobSourceSchedule, Status: models.JobRunSucceeded, ScheduledFor: &lastSlot,
+	}}
+
+	done := func(context.Context) (any, error) { return nil, nil }
+	s := newTestScheduler(t, lock, store, clock,
+		Job{Name: "hourly", Schedule: "@hourly", Run: done},
+		// без истории задача ждет следующего слота, а не запускается сразу
+		Job{Name: "new", Schedule: "@hourly", Run: done},
+	)
+	defer s.disconnect()
+
+	s.poll(ctx)
+	s.wg.Wait()
+	clock.Set(start.Add(time.Minute))
+	s.poll(ctx)
+	s.wg.Wait()
+
+	runs := store.snapshot()
+	if len(runs) != 2 {
+		t.Fatalf("runs = %+v, want one catch-up run", runs)
+	}
+	want := time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)
+	if run := runs[1]; run.JobName != "hourly" || run.ScheduledFor == nil || !run.ScheduledFor.Equal(want) ||
+		run.Status != models.JobRunSucceeded {
+		t.Fatalf("catch-up run = %+v, want hourly for %s", run, want)
+	}
+	// следующий слот — по текущему времени, пропущенные не догоняются по одному
+	if next := s.next["hourly"]; !next.Equal(time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Fatalf("next hourly slot = %s, want 13:00", next)
+	}
+}
+
+func TestPausedJobSkipsSlotButRunsManualTrigger(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 12, 59, 0, 0, time.UTC)
+	clock := &testClock{now: start}
+	store := &memoryStore{paused: map[string]bool{"recalc": true}}
+	lock := &memoryLock{}
+
+	var (
+		mu   sync.Mutex
+		runs int
+	)
+	job := Job{Name: "recalc", Schedule: "@hourly", Run: func(context.Context) (any, error) {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		return nil, nil
+	}}
+	s := newTestScheduler(t, lock, store, clock, job)
+	defer s.disconnect()
+
+	s.poll(ctx)
+	clock.Set(start.Add(time.Minute))
+	s.poll(ctx)
+	s.wg.Wait()
+	if got := store.snapshot(); len(got) != 0 || runs != 0 {
+		t.Fatalf("paused job ran: %d runs, history %+v", runs, got)
+	}
+
+	// ручной запуск из API администратора проходит и на паузе
+	store.runs = append(store.runs, models.JobRun{
+		ID: 1, JobName: "recalc", Source: models.JobSourceManual, Status: models.JobRunQueued,
+	})
+	s.poll(ctx)
+	s.wg.Wait()
+	if got := store.snapshot(); runs != 1 || got[0].Status != models.JobRunSucceeded {
+		t.Fatalf("manual run: %d runs, history %+v", runs, got)
+	}
+
+	// после снятия паузы пропущенный слот не догоняется, задача ждет следующего
+	store.mu.Lock()
+	store.paused["recalc"] = false
+	store.mu.Unlock()
+	clock.Set(start.Add(2 * time.Minute))
+	s.poll(ctx)
+	s.wg.Wait()
+	if runs != 1 {
+		t.Fatalf("unpaused job ran before its next slot: %d runs", runs)
+	}
+	clock.Set(time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC))
+	s.poll(ctx)
+	s.wg.Wait()
+	if got := store.snapshot(); runs != 2 || got[1].Source != models.JobSourceSchedule {
+		t.Fatalf("after unpause: %d runs, history %+v", runs, got)
+	}
+}
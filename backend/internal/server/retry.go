@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"warranty_days/internal/worker"
+)
+
+// Retry вызывает fn, пока она не вернет nil или не будет отменен ctx.
+// Пауза между попытками растет вдвое до Backoff.Max.
+func Retry(
+	ctx context.Context,
+	logger *slog.Logger,
+	name string,
+	backoff worker.Backoff,
+	fn func(ctx context.Context) error,
+) error {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				logger.InfoContext(ctx, "dependency is available", "dependency", name, "attempt", attempt)
+			}
+			return nil
+		}
+
+		delay := backoff.Delay(attempt)
+		logger.WarnContext(ctx, "dependency is not available, retrying",
+			"dependency", name,
+			"attempt", attempt,
+			"retry_in", delay.String(),
+			"error", err,
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
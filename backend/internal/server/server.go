@@ -0,0 +1,145 @@
+// Package server runs the HTTP server with timeouts and graceful shutdown
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"warranty_days/internal/config"
+)
+
+type Config struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// ShutdownTimeout — сколько ждем завершения активных запросов после сигнала.
+	ShutdownTimeout time.Duration
+	// CloseTimeout — сколько ждем каждый ресурс из OnShutdown. Отдельно от ShutdownTimeout:
+	// запросы, занявшие весь свой срок, не оставляют ресурсы без времени на закрытие.
+	CloseTimeout time.Duration
+}
+
+func ConfigFromApp(cfg config.Config) Config {
+	return Config{
+		Addr:              cfg.HTTPAddr,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+		ShutdownTimeout:   cfg.HTTPShutdownTimeout,
+		CloseTimeout:      cfg.HTTPCloseTimeout,
+	}
+}
+
+type closer struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	closeTimeout    time.Duration
+	logger          *slog.Logger
+	closers         []closer
+}
+
+func New(cfg Config, handler http.Handler, logger *slog.Logger) *Server {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+		closeTimeout:    cfg.CloseTimeout,
+		logger:          logger,
+	}
+}
+
+// OnShutdown регистрирует ресурс, который закрывается после остановки HTTP-сервера.
+// Ресурсы закрываются в порядке регистрации, каждый не дольше CloseTimeout.
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.closers = append(s.closers, closer{name: name, fn: fn})
+}
+
+// OnDrain регистрирует функцию, которая вызывается в начале остановки, до ожидания активных запросов.
+// Shutdown не прерывает долгие запросы (потоки SSE) — они должны завершиться сами по этому сигналу.
+func (s *Server) OnDrain(fn func()) {
+	s.httpServer.RegisterOnShutdown(fn)
+}
+
+// Run слушает адрес до отмены ctx (SIGTERM/SIGINT), затем дожидается активных запросов
+// в пределах ShutdownTimeout и закрывает зарегистрированные ресурсы.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", s.httpServer.Addr, err)
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info("http server listening", "http_addr", listener.Addr().String())
+		serveErr <- s.httpServer.Serve(listener)
+	}()
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = fmt.Errorf("http server: %w", err)
+		}
+	case <-ctx.Done():
+		s.logger.Info("shutdown signal received, draining requests", "timeout", s.shutdownTimeout.String())
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		s.logger.Error("http server shutdown did not complete, closing connections", "error", err)
+		_ = s.httpServer.Close()
+		runErr = errors.Join(runErr, fmt.Errorf("http shutdown: %w", err))
+	}
+
+	for _, c := range s.closers {
+		if err := s.close(c); err != nil {
+			s.logger.Error("failed to close resource", "resource", c.name, "error", err)
+			runErr = errors.Join(runErr, fmt.Errorf("close %s: %w", c.name, err))
+			continue
+		}
+		s.logger.Info("resource closed", "resource", c.name)
+	}
+
+	s.logger.Info("http server stopped")
+	return runErr
+}
+
+// close закрывает ресурс со своим таймаутом: зависший ресурс не съедает время следующих.
+func (s *Server) close(c closer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.closeTimeout)
+	defer cancel()
+	return c.fn(ctx)
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"warranty_days/internal/worker"
+)
+
+func TestServeDrainsRequestsBeforeClosers(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+
+	drain := make(chan struct{})
+	started := make(chan struct{})
+	// долгий запрос вроде SSE: завершается только по сигналу OnDrain
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-drain
+		record("request")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	srv := New(Config{ShutdownTimeout: 5 * time.Second, CloseTimeout: time.Second}, handler, slog.New(slog.DiscardHandler))
+	srv.OnDrain(func() { close(drain) })
+	errClose := errors.New("close failed")
+	srv.OnShutdown("first", func(context.Context) error { record("first"); return errClose })
+	srv.OnShutdown("second", func(context.Context) error { record("second"); return nil })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, listener) }()
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			status <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	if got := <-status; got != http.StatusNoContent {
+		t.Fatalf("in-flight request status = %d, want %d", got, http.StatusNoContent)
+	}
+	// ошибка одного ресурса не мешает закрыть остальные и попадает в результат Serve
+	if err := <-served; !errors.Is(err, errClose) {
+		t.Fatalf("Serve() error = %v, want %v", err, errClose)
+	}
+	if want := []string{"request", "first", "second"}; !slices.Equal(events, want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+}
+
+func TestServeGivesEachCloserItsOwnTimeout(t *testing.T) {
+	started := make(chan struct{})
+	// запрос занимает весь ShutdownTimeout: OnDrain не слушает
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+	srv := New(Config{ShutdownTimeout: 20 * time.Millisecond, CloseTimeout: time.Second}, handler,
+		slog.New(slog.DiscardHandler))
+
+	var stuck, next error
+	// зависший ресурс отпускается по своему таймауту и не забирает время следующего
+	srv.OnShutdown("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		stuck = ctx.Err()
+		return nil
+	})
+	srv.OnShutdown("next", func(ctx context.Context) error {
+		next = ctx.Err()
+		return nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, listener) }()
+	go func() {
+		if resp, err := http.Get("http://" + listener.Addr().String()); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	<-started
+	startedAt := time.Now()
+	cancel()
+
+	if err := <-served; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Serve() error = %v, want http shutdown deadline", err)
+	}
+	if elapsed := time.Since(startedAt); !errors.Is(stuck, context.DeadlineExceeded) || elapsed < time.Second {
+		t.Fatalf("stuck closer released with %v after %s, want its own 1s timeout", stuck, elapsed)
+	}
+	if next != nil {
+		t.Fatalf("next closer context = %v, want a fresh timeout", next)
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	backoff := worker.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+	attempts := 0
+	err := Retry(ctx, slog.New(slog.DiscardHandler), "db", backoff, func(context.Context) error {
+		attempts++
+		if attempts == 3 {
+			cancel()
+		}
+		return errors.New("unavailable")
+	})
+	if !errors.Is(err, context.Canceled) || attempts != 3 {
+		t.Fatalf("Retry() = %v after %d attempts, want context.Canceled after 3", err, attempts)
+	}
+}
@@ -5,10 +5,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/mail"
 	"strings"
+	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"warranty_days/internal/auth"
@@ -19,9 +20,11 @@ import (
 var (
 	ErrInvalidEmail       = errors.New("invalid email")
 	ErrEmailAlreadyExists = errors.New("email already exists")
-	ErrWeakPassword       = errors.New("password must be at least 8 characters")
+	ErrWeakPassword       = errors.New("weak password")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserInactive       = errors.New("user is inactive")
+	ErrEmailNotVerified   = errors.New("email verification is pending")
+	ErrMFAEnrollRequired  = errors.New("two-factor authentication enrollment is required")
 )
 
 type TokenPair struct {
@@ -29,86 +32,108 @@ type TokenPair struct {
 	RefreshToken string
 }
 
+// LoginResult — либо готовая пара токенов, либо челлендж второго фактора.
+type LoginResult struct {
+	Tokens *TokenPair
+	MFA    *MFAChallenge
+}
+
+type MFAChallenge struct {
+	Token string
+	// EnrollmentRequired — 2FA обязательна для роли, но еще не настроена.
+	EnrollmentRequired bool
+}
+
 type TokenService interface {
-	GenerateAccessToken(userID int64, email string) (string, error)
+	GenerateAccessToken(userID int64, email, role string) (string, error)
 	GenerateRefreshToken(userID int64, email string) (string, error)
+	GenerateMFAChallengeToken(userID int64, email, tokenType, challengeID string) (string, error)
 	ParseAndValidate(tokenStr string, expectedType string) (*auth.Claims, error)
 }
 
 type AuthService struct {
-	userRepo      *repo.UserRepo
-	tokenService  TokenService
-	passwordCost  int
-	minPasswordLn int
+	userRepo        *repo.UserRepo
+	mfaRepo         *repo.MFARepo
+	tokenService    TokenService
+	passwordHashers *auth.PasswordHashers
 }
 
-func NewAuthService(userRepo *repo.UserRepo, tokenService TokenService) *AuthService {
+func NewAuthService(
+	userRepo *repo.UserRepo,
+	mfaRepo *repo.MFARepo,
+	tokenService TokenService,
+	passwordHashers *auth.PasswordHashers,
+) *AuthService {
 	return &AuthService{
-		userRepo:      userRepo,
-		tokenService:  tokenService,
-		passwordCost:  bcrypt.DefaultCost,
-		minPasswordLn: 8,
+		userRepo:        userRepo,
+		mfaRepo:         mfaRepo,
+		tokenService:    tokenService,
+		passwordHashers: passwordHashers,
 	}
 }
 
-func (s *AuthService) Register(ctx context.Context, email, password string) (*models.User, error) {
+func (s *AuthService) Login(ctx context.Context, email, password string) (*LoginResult, error) {
 	email = normalizeEmail(email)
 
 	if err := validateEmail(email); err != nil {
-		return nil, err
-	}
-	if len(password) < s.minPasswordLn {
-		return nil, ErrWeakPassword
+		return nil, ErrInvalidCredentials
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.passwordCost)
+	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return nil, fmt.Errorf("hash password: %w", err)
-	}
-
-	user := &models.User{
-		Email:        email,
-		PasswordHash: string(hash),
-		IsActive:     true,
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.passwordHashers.VerifyDummy(password)
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("get user by email: %w", err)
 	}
 
-	err = s.userRepo.Create(ctx, user)
+	ok, needsRehash, err := s.passwordHashers.Verify(password, user.PasswordHash)
 	if err != nil {
-		// Если пользователь уже есть.
-		// На сервисном слое нормализуем DB-ошибку в бизнес-ошибку.
-		if isUniqueViolation(err) {
-			return nil, ErrEmailAlreadyExists
-		}
-		return nil, fmt.Errorf("create user: %w", err)
+		return nil, fmt.Errorf("verify password: %w", err)
 	}
-
-	return user, nil
-}
-
-func (s *AuthService) Login(ctx context.Context, email, password string) (*TokenPair, error) {
-	email = normalizeEmail(email)
-
-	if err := validateEmail(email); err != nil {
+	if !ok {
 		return nil, ErrInvalidCredentials
 	}
+	if needsRehash {
+		s.rehashPassword(ctx, user, password)
+	}
 
-	user, err := s.userRepo.GetByEmail(ctx, email)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrInvalidCredentials
-		}
-		return nil, fmt.Errorf("get user by email: %w", err)
+	// Статус аккаунта раскрываем только после проверки пароля.
+	if err := checkUserActive(user); err != nil {
+		return nil, err
 	}
 
-	if !user.IsActive {
-		return nil, ErrUserInactive
+	// Полные токены выдаем только после второго фактора.
+	challengeType, err := s.mfaChallengeType(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challengeType != "" {
+		challengeID, err := s.createMFAChallenge(ctx, user, challengeType)
+		if err != nil {
+			return nil, err
+		}
+		token, err := s.tokenService.GenerateMFAChallengeToken(user.ID, user.Email, challengeType, challengeID)
+		if err != nil {
+			return nil, fmt.Errorf("generate mfa challenge token: %w", err)
+		}
+		return &LoginResult{MFA: &MFAChallenge{
+			Token:              token,
+			EnrollmentRequired: challengeType == auth.TokenTypeMFAEnroll,
+		}}, nil
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, ErrInvalidCredentials
+	tokens, err := s.IssueTokens(user)
+	if err != nil {
+		return nil, err
 	}
+	return &LoginResult{Tokens: tokens}, nil
+}
 
-	access, err := s.tokenService.GenerateAccessToken(user.ID, user.Email)
+// IssueTokens выдает пару токенов пользователю, который уже прошел все шаги аутентификации.
+func (s *AuthService) IssueTokens(user *models.User) (*TokenPair, error) {
+	access, err := s.tokenService.GenerateAccessToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("generate access token: %w", err)
 	}
@@ -124,6 +149,67 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*Token
 	}, nil
 }
 
+// rehashPassword переводит хеш на текущий алгоритм и параметры.
+// Ошибка не мешает логину: попробуем снова при следующем входе.
+func (s *AuthService) rehashPassword(ctx context.Context, user *models.User, password string) {
+	hash, err := s.passwordHashers.Hash(password)
+	if err == nil {
+		err = s.userRepo.UpdatePasswordHash(ctx, user.ID, hash)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "password rehash failed", "user_id", user.ID, "error", err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+// createMFAChallenge сохраняет челлендж ввода кода, чтобы ограничить число попыток на один mfa_token.
+// Токену настройки 2FA челлендж не нужен: он не выдает полные токены сам по себе.
+func (s *AuthService) createMFAChallenge(ctx context.Context, user *models.User, challengeType string) (string, error) {
+	if challengeType != auth.TokenTypeMFA {
+		return "", nil
+	}
+
+	id, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("generate mfa challenge id: %w", err)
+	}
+	challenge := &models.MFALoginChallenge{
+		ID:        id,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(auth.MFAChallengeTTL),
+	}
+	if err := s.mfaRepo.CreateChallenge(ctx, challenge); err != nil {
+		return "", fmt.Errorf("create mfa challenge: %w", err)
+	}
+	return id, nil
+}
+
+func (s *AuthService) mfaChallengeType(ctx context.Context, user *models.User) (string, error) {
+	if user.MFAEnabled() {
+		return auth.TokenTypeMFA, nil
+	}
+
+	required, err := s.mfaRepo.IsRequiredForRole(ctx, user.Role)
+	if err != nil {
+		return "", fmt.Errorf("get mfa policy: %w", err)
+	}
+	if required {
+		return auth.TokenTypeMFAEnroll, nil
+	}
+	return "", nil
+}
+
+func checkUserActive(user *models.User) error {
+	if user.IsPendingVerification() {
+		return ErrEmailNotVerified
+	}
+	if !user.IsActive {
+		return ErrUserInactive
+	}
+	return nil
+}
+
 func normalizeEmail(email string) string {
 	return strings.ToLower(strings.TrimSpace(email))
 }
@@ -174,22 +260,19 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenP
 		return nil, fmt.Errorf("get user by id: %w", err)
 	}
 
-	if !user.IsActive {
-		return nil, ErrUserInactive
+	if err := checkUserActive(user); err != nil {
+		return nil, err
 	}
 
-	access, err := s.tokenService.GenerateAccessToken(user.ID, user.Email)
+	// Если политика 2FA для роли включилась после логина, сессию продлевать нельзя:
+	// пользователь должен перелогиниться и настроить второй фактор.
+	challengeType, err := s.mfaChallengeType(ctx, user)
 	if err != nil {
-		return nil, fmt.Errorf("generate access token: %w", err)
+		return nil, err
 	}
-
-	refresh, err := s.tokenService.GenerateRefreshToken(user.ID, user.Email)
-	if err != nil {
-		return nil, fmt.Errorf("generate refresh token: %w", err)
+	if challengeType == auth.TokenTypeMFAEnroll {
+		return nil, ErrMFAEnrollRequired
 	}
 
-	return &TokenPair{
-		AccessToken:  access,
-		RefreshToken: refresh,
-	}, nil
+	return s.IssueTokens(user)
 }
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+var (
+	ErrClaimNotFound     = errors.New("claim not found")
+	ErrInvalidClaimDates = errors.New("ro_close_date must not be before ro_open_date")
+)
+
+// WarrantyThresholds — пороги событий warranty.threshold_*: Warning — предупреждение (0 — не отправлять),
+// Limit — законный лимит дней ремонта в гарантийном году.
+type WarrantyThresholds struct {
+	Warning int
+	Limit   int
+}
+
+type ClaimInput struct {
+	VIN         string
+	RetailDate  time.Time
+	RoOpenDate  time.Time
+	RoCloseDate time.Time
+}
+
+// ClaimWriteService — изменение заявок. Вместе с каждым изменением в той же транзакции пишет в outbox
+// событие claim.* и, если сумма дней ремонта VIN перешла порог, событие warranty.threshold_*.
+type ClaimWriteService struct {
+	claimRepo  *repo.ClaimRepo
+	outboxRepo *repo.OutboxRepo
+	txManager  *repo.TxManager
+	thresholds WarrantyThresholds
+	nowFn      func() time.Time
+}
+
+func NewClaimWriteService(
+	claimRepo *repo.ClaimRepo,
+	outboxRepo *repo.OutboxRepo,
+	txManager *repo.TxManager,
+	thresholds WarrantyThresholds,
+) *ClaimWriteService {
+	return &ClaimWriteService{
+		claimRepo:  claimRepo,
+		outboxRepo: outboxRepo,
+		txManager:  txManager,
+		thresholds: thresholds,
+		nowFn:      time.Now,
+	}
+}
+
+func (s *ClaimWriteService) Create(ctx context.Context, input ClaimInput) (*models.Claim, error) {
+	claim := &models.Claim{}
+	if err := applyClaimInput(claim, input); err != nil {
+		return nil, err
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return s.tracked(ctx, []string{claim.VIN}, func() (events.Event, error) {
+			if err := s.claimRepo.Create(ctx, claim); err != nil {
+				return events.Event{}, err
+			}
+			return s.claimEvent(events.ClaimCreated, *claim, "")
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+func (s *ClaimWriteService) Update(ctx context.Context, id int64, input ClaimInput) (*models.Claim, error) {
+	var claim *models.Claim
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		claim, err = s.claimRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		previousVIN := claim.VIN
+		if err := applyClaimInput(claim, input); err != nil {
+			return err
+		}
+
+		vins := []string{claim.VIN}
+		if !strings.EqualFold(previousVIN, claim.VIN) {
+			vins = append(vins, previousVIN)
+		} else {
+			previousVIN = ""
+		}
+
+		return s.tracked(ctx, vins, func() (events.Event, error) {
+			if err := s.claimRepo.Update(ctx, claim); err != nil {
+				return events.Event{}, err
+			}
+			return s.claimEvent(events.ClaimUpdated, *claim, previousVIN)
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrClaimNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+func (s *ClaimWriteService) Delete(ctx context.Context, id int64) error {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		claim, err := s.claimRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.tracked(ctx, []string{claim.VIN}, func() (events.Event, error) {
+			if err := s.claimRepo.Delete(ctx, id); err != nil {
+				return events.Event{}, err
+			}
+			return s.claimEvent(events.ClaimDeleted, *claim, "")
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrClaimNotFound
+	}
+	return err
+}
+
+// tracked выполняет изменение и пишет в outbox его событие вместе с событиями порогов: текущий
+// гарантийный год каждого VIN считается до и после изменения в той же транзакции. Перед первым чтением
+// VIN блокируются до коммита: параллельное изменение того же VIN ждет, поэтому пороги не срабатывают
+// дважды или ни разу, а id сообщений outbox одного VIN растут в порядке коммитов — на этом держится
+// порядок отправки по VIN.
+func (s *ClaimWriteService) tracked(
+	ctx context.Context,
+	vins []string,
+	change func() (events.Event, error),
+) error {
+	if err := s.claimRepo.LockVINs(ctx, vins); err != nil {
+		return err
+	}
+	now := s.nowFn()
+
+	before := make([]repo.WarrantyYearPeriod, len(vins))
+	for i, vin := range vins {
+		period, _, err := s.currentYear(ctx, vin, now)
+		if err != nil {
+			return err
+		}
+		before[i] = period
+	}
+
+	event, err := change()
+	if err != nil {
+		return err
+	}
+
+	published := []events.Event{event}
+	for i, vin := range vins {
+		after, ok, err := s.currentYear(ctx, vin, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		threshold, err := s.thresholdEvent(vin, before[i].TotalDays, after, now)
+		if err != nil {
+			return err
+		}
+		if threshold != nil {
+			published = append(published, *threshold)
+		}
+	}
+	return s.enqueue(ctx, published)
+}
+
+// currentYear — текущий гарантийный год VIN; false, если заявок по VIN нет.
+func (s *ClaimWriteService) currentYear(
+	ctx context.Context,
+	vin string,
+	now time.Time,
+) (repo.WarrantyYearPeriod, bool, error) {
+	report, err := s.claimRepo.ListWarrantyYearRepairsByVIN(ctx, vin, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repo.WarrantyYearPeriod{}, false, nil
+	}
+	if err != nil {
+		return repo.WarrantyYearPeriod{}, false, err
+	}
+	if len(report.Periods) == 0 {
+		return repo.WarrantyYearPeriod{}, false, nil
+	}
+	return report.Periods[0], true, nil
+}
+
+// thresholdEvent срабатывает только при пересечении порога вверх: повторное изменение уже
+// превысившего лимит VIN событий не дает. Если изменение перешагнуло оба порога, отправляется только exceeded.
+func (s *ClaimWriteService) thresholdEvent(
+	vin string,
+	previousDays int,
+	current repo.WarrantyYearPeriod,
+	now time.Time,
+) (*events.Event, error) {
+	var (
+		eventType string
+		threshold int
+	)
+	switch {
+	case previousDays <= s.thresholds.Limit && current.TotalDays > s.thresholds.Limit:
+		eventType, threshold = events.ThresholdExceeded, s.thresholds.Limit
+	case s.thresholds.Warning > 0 && previousDays < s.thresholds.Warning &&
+		current.TotalDays >= s.thresholds.Warning && current.TotalDays <= s.thresholds.Limit:
+		eventType, threshold = events.ThresholdApproaching, s.thresholds.Warning
+	default:
+		return nil, nil
+	}
+
+	event, err := events.New(eventType, vin, events.ThresholdData{
+		WarrantyStart: current.WarrantyStart.Format(time.DateOnly),
+		WarrantyEnd:   current.WarrantyEnd.Format(time.DateOnly),
+		TotalDays:     current.TotalDays,
+		PreviousDays:  previousDays,
+		Threshold:     threshold,
+		Limit:         s.thresholds.Limit,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *ClaimWriteService) claimEvent(eventType string, claim models.Claim, previousVIN string) (events.Event, error) {
+	return events.New(eventType, claim.VIN, events.ClaimData{
+		ID:          claim.ID,
+		VIN:         claim.VIN,
+		RetailDate:  claim.RetailDate.Format(time.DateOnly),
+		RoOpenDate:  claim.RoOpenDate.Format(time.DateOnly),
+		RoCloseDate: claim.RoCloseDate.Format(time.DateOnly),
+		PreviousVIN: previousVIN,
+	}, s.nowFn())
+}
+
+// enqueue пишет события в outbox; их отправит outbox.Relay после коммита.
+func (s *ClaimWriteService) enqueue(ctx context.Context, published []events.Event) error {
+	messages := make([]models.OutboxMessage, 0, len(published))
+	for _, event := range published {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, models.OutboxMessage{
+			EventID:       event.ID,
+			EventType:     event.Type,
+			VIN:           event.VIN,
+			Payload:       payload,
+			OccurredAt:    event.OccurredAt,
+			NextAttemptAt: event.OccurredAt,
+		})
+	}
+	return s.outboxRepo.Add(ctx, messages)
+}
+
+func applyClaimInput(claim *models.Claim, input ClaimInput) error {
+	vin := strings.ToUpper(strings.TrimSpace(input.VIN))
+	if vin == "" {
+		return ErrVINRequired
+	}
+	if input.RoCloseDate.Before(input.RoOpenDate) {
+		return ErrInvalidClaimDates
+	}
+
+	claim.VIN = vin
+	claim.RetailDate = input.RetailDate
+	claim.RoOpenDate = input.RoOpenDate
+	claim.RoCloseDate = input.RoCloseDate
+	return nil
+}
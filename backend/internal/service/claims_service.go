@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+var (
+	ErrVINRequired    = errors.New("vin is required")
+	ErrClaimsNotFound = errors.New("claims not found for vin")
+)
+
+// WellFormedVIN проверяет формат VIN по ISO 3779: 17 букв и цифр без I, O и Q. Регистр не важен.
+// Заявок у такого VIN может еще не быть, а VIN другого формата не принадлежит ни одной машине.
+func WellFormedVIN(vin string) bool {
+	if len(vin) != 17 {
+		return false
+	}
+	for _, c := range strings.ToUpper(vin) {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'A' && c <= 'Z' && c != 'I' && c != 'O' && c != 'Q':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ClaimsService — расчеты по заявкам, общие для всех версий API. Представление (DTO) остается за хендлером.
+type ClaimsService struct {
+	claimRepo  *repo.ClaimRepo
+	txManager  *repo.TxManager
+	profileSvc *ProfileService
+	logger     *slog.Logger
+}
+
+type ClaimsResult struct {
+	Claims []models.Claim
+	// Location — часовой пояс пользователя, в нем показываются служебные метки времени.
+	Location *time.Location
+}
+
+type WarrantyYearResult struct {
+	Report repo.WarrantyYearsResponse
+	AsOf   time.Time
+}
+
+// ClaimsStampResult — все, от чего зависит ответ со списком заявок, без самих заявок.
+type ClaimsStampResult struct {
+	Stamp    repo.ClaimsStamp
+	Location *time.Location
+}
+
+// WarrantyYearStampResult — все, от чего зависит расчет по гарантийным годам, без самого расчета.
+type WarrantyYearStampResult struct {
+	Stamp repo.ClaimsStamp
+	AsOf  time.Time
+}
+
+func NewClaimsService(
+	claimRepo *repo.ClaimRepo,
+	txManager *repo.TxManager,
+	profileSvc *ProfileService,
+	logger *slog.Logger,
+) *ClaimsService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ClaimsService{claimRepo: claimRepo, txManager: txManager, profileSvc: profileSvc, logger: logger}
+}
+
+// ReadSnapshot выполняет fn для VIN в одном снимке БД. Версия (ClaimsStamp, WarrantyYearStamp) и данные,
+// прочитанные в fn, согласованы: запись между ними не даст ETag одной версии на данные другой.
+func (s *ClaimsService) ReadSnapshot(ctx context.Context, vin string, fn func(ctx context.Context) error) error {
+	if strings.TrimSpace(vin) == "" {
+		return ErrVINRequired
+	}
+	return s.txManager.WithinReadSnapshot(ctx, fn)
+}
+
+func (s *ClaimsService) ClaimsByVIN(ctx context.Context, userID int64, vin string) (ClaimsResult, error) {
+	vin = strings.TrimSpace(vin)
+	if vin == "" {
+		return ClaimsResult{}, ErrVINRequired
+	}
+
+	claims, err := s.claimRepo.ListByVINCaseInsensitive(ctx, vin)
+	if err != nil {
+		return ClaimsResult{}, fmt.Errorf("list claims by vin: %w", err)
+	}
+
+	loc := PreferencesLocation(s.preferences(ctx, userID))
+	for i := range claims {
+		claims[i].CreatedAt = claims[i].CreatedAt.In(loc)
+		claims[i].UpdatedAt = claims[i].UpdatedAt.In(loc)
+	}
+
+	return ClaimsResult{Claims: claims, Location: loc}, nil
+}
+
+// ClaimsStamp — дешевая проверка актуальности ClaimsByVIN для условных запросов.
+func (s *ClaimsService) ClaimsStamp(ctx context.Context, userID int64, vin string) (ClaimsStampResult, error) {
+	vin = strings.TrimSpace(vin)
+	if vin == "" {
+		return ClaimsStampResult{}, ErrVINRequired
+	}
+
+	stamp, err := s.claimRepo.StampByVIN(ctx, vin)
+	if err != nil {
+		return ClaimsStampResult{}, fmt.Errorf("claims stamp by vin: %w", err)
+	}
+
+	return ClaimsStampResult{Stamp: stamp, Location: PreferencesLocation(s.preferences(ctx, userID))}, nil
+}
+
+// WarrantyYearStamp — дешевая проверка актуальности WarrantyYear для условных запросов: дата расчета
+// разрешается так же, как в WarrantyYear, но периоды не считаются.
+func (s *ClaimsService) WarrantyYearStamp(
+	ctx context.Context,
+	userID int64,
+	vin string,
+	asOf *time.Time,
+) (WarrantyYearStampResult, error) {
+	vin = strings.TrimSpace(vin)
+	if vin == "" {
+		return WarrantyYearStampResult{}, ErrVINRequired
+	}
+
+	stamp, err := s.claimRepo.StampByVIN(ctx, vin)
+	if err != nil {
+		return WarrantyYearStampResult{}, fmt.Errorf("claims stamp by vin: %w", err)
+	}
+	if stamp.Count == 0 {
+		return WarrantyYearStampResult{}, ErrClaimsNotFound
+	}
+
+	resolved, err := s.resolveAsOf(ctx, userID, vin, asOf)
+	if err != nil {
+		return WarrantyYearStampResult{}, err
+	}
+
+	return WarrantyYearStampResult{Stamp: stamp, AsOf: resolved}, nil
+}
+
+// WarrantyYear считает дни ремонта по гарантийным годам на дату asOf.
+// Если asOf не задан, берется настройка пользователя default_as_of.
+func (s *ClaimsService) WarrantyYear(
+	ctx context.Context,
+	userID int64,
+	vin string,
+	asOf *time.Time,
+) (WarrantyYearResult, error) {
+	vin = strings.TrimSpace(vin)
+	if vin == "" {
+		return WarrantyYearResult{}, ErrVINRequired
+	}
+
+	resolved, err := s.resolveAsOf(ctx, userID, vin, asOf)
+	if err != nil {
+		return WarrantyYearResult{}, err
+	}
+
+	report, err := s.claimRepo.ListWarrantyYearRepairsByVIN(ctx, vin, resolved)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return WarrantyYearResult{}, ErrClaimsNotFound
+		}
+		return WarrantyYearResult{}, fmt.Errorf("list warranty-year repairs: %w", err)
+	}
+
+	return WarrantyYearResult{Report: report, AsOf: resolved}, nil
+}
+
+// resolveAsOf: явная дата, иначе default_as_of пользователя. "Сегодня" берется в часовом поясе пользователя.
+func (s *ClaimsService) resolveAsOf(ctx context.Context, userID int64, vin string, asOf *time.Time) (time.Time, error) {
+	if asOf != nil {
+		return *asOf, nil
+	}
+
+	prefs := s.preferences(ctx, userID)
+	if prefs.DefaultAsOf == models.AsOfLastRepair {
+		latest, err := s.claimRepo.LatestRepairDate(ctx, vin)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return time.Time{}, ErrClaimsNotFound
+			}
+			return time.Time{}, fmt.Errorf("latest repair date: %w", err)
+		}
+		return latest, nil
+	}
+
+	return time.Now().In(PreferencesLocation(prefs)), nil
+}
+
+// preferences возвращает настройки пользователя; при ошибке — дефолтные, расчет из-за них не падает.
+func (s *ClaimsService) preferences(ctx context.Context, userID int64) models.UserPreferences {
+	if userID == 0 || s.profileSvc == nil {
+		return models.DefaultUserPreferences(userID)
+	}
+
+	prefs, err := s.profileSvc.Preferences(ctx, userID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to load user preferences", "user_id", userID, "error", err)
+		return models.DefaultUserPreferences(userID)
+	}
+	return prefs
+}
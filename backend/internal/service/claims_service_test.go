@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"warranty_days/internal/repo"
+)
+
+func TestClaimsReadSnapshotSharesOneTransaction(t *testing.T) {
+	db, mock := newMockGorm(t)
+	svc := NewClaimsService(repo.NewClaimRepo(db), repo.NewTxManager(db), nil, nil)
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// версия и заявки — между BEGIN и COMMIT одной транзакции
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS count, MAX\(updated_at\) AS max_updated_at FROM "claims"`).
+		WithArgs("VIN1").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "max_updated_at"}).AddRow(1, updatedAt))
+	mock.ExpectQuery(`SELECT \* FROM "claims" WHERE LOWER\(vin\) = LOWER\(\$1\)`).
+		WithArgs("VIN1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "vin", "updated_at"}).AddRow(1, "VIN1", updatedAt))
+	mock.ExpectCommit()
+
+	var (
+		stamp  ClaimsStampResult
+		claims ClaimsResult
+	)
+	err := svc.ReadSnapshot(context.Background(), "VIN1", func(ctx context.Context) error {
+		var err error
+		if stamp, err = svc.ClaimsStamp(ctx, 0, "VIN1"); err != nil {
+			return err
+		}
+		claims, err = svc.ClaimsByVIN(ctx, 0, "VIN1")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stamp.Stamp.Count != 1 || len(claims.Claims) != 1 {
+		t.Fatalf("snapshot = stamp %+v, %d claims; want one claim in both", stamp.Stamp, len(claims.Claims))
+	}
+}
+
+func TestClaimsReadSnapshotRequiresVIN(t *testing.T) {
+	db, _ := newMockGorm(t)
+	svc := NewClaimsService(repo.NewClaimRepo(db), repo.NewTxManager(db), nil, nil)
+
+	// без VIN транзакция не открывается
+	err := svc.ReadSnapshot(context.Background(), " ", func(context.Context) error {
+		t.Fatal("fn called without vin")
+		return nil
+	})
+	if !errors.Is(err, ErrVINRequired) {
+		t.Fatalf("ReadSnapshot() error = %v, want ErrVINRequired", err)
+	}
+}
+
+func TestWellFormedVIN(t *testing.T) {
+	tests := []struct {
+		vin  string
+		want bool
+	}{
+		{vin: "XWENE81BBM0000385", want: true},
+		{vin: "xwene81bbm0000385", want: true},
+		{vin: "VIN1"},
+		{vin: "XWENE81BBM00003850"},
+		// I, O и Q в VIN не используются: их путают с 1 и 0
+		{vin: "XWENE81BBM000O385"},
+		{vin: "XWENE81BBM-000385"},
+	}
+
+	for _, tt := range tests {
+		if got := WellFormedVIN(tt.vin); got != tt.want {
+			t.Errorf("WellFormedVIN(%q) = %v, want %v", tt.vin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+	"warranty_days/internal/scheduler"
+)
+
+var (
+	ErrJobNotFound         = errors.New("job not found")
+	ErrJobRunInProgress    = errors.New("job already has a queued or running run")
+	ErrInvalidJobRunStatus = errors.New("invalid job run status")
+)
+
+// JobInfo — задача планировщика с состоянием из БД.
+type JobInfo struct {
+	Job    scheduler.Job
+	Paused bool
+	// NextRunAt — следующий слот расписания; nil, пока задача на паузе.
+	NextRunAt *time.Time
+	LastRun   *models.JobRun
+}
+
+// JobService — периодические задачи для администратора: история, ручной запуск, пауза.
+type JobService struct {
+	jobRepo   *repo.JobRepo
+	scheduler *scheduler.Scheduler
+	nowFn     func() time.Time
+}
+
+func NewJobService(jobRepo *repo.JobRepo, scheduler *scheduler.Scheduler) *JobService {
+	return &JobService{jobRepo: jobRepo, scheduler: scheduler, nowFn: time.Now}
+}
+
+func (s *JobService) List(ctx context.Context) ([]JobInfo, error) {
+	states, err := s.jobRepo.States(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lastRuns, err := s.jobRepo.LastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := s.scheduler.Jobs()
+	infos := make([]JobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		var lastRun *models.JobRun
+		if run, ok := lastRuns[job.Name]; ok {
+			lastRun = &run
+		}
+		infos = append(infos, s.info(job, states[job.Name].Paused, lastRun))
+	}
+	return infos, nil
+}
+
+func (s *JobService) Get(ctx context.Context, name string) (*JobInfo, error) {
+	infos, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.Job.Name == name {
+			return &info, nil
+		}
+	}
+	return nil, ErrJobNotFound
+}
+
+// Runs — история запусков задачи. status пустой или один из models.JobRun*.
+func (s *JobService) Runs(ctx context.Context, name string, status string, limit int) ([]models.JobRun, error) {
+	switch status {
+	case "", models.JobRunQueued, models.JobRunRunning, models.JobRunSucceeded, models.JobRunFailed:
+	default:
+		return nil, ErrInvalidJobRunStatus
+	}
+	if _, ok := s.scheduler.Job(name); !ok {
+		return nil, ErrJobNotFound
+	}
+	return s.jobRepo.ListRuns(ctx, name, status, limit)
+}
+
+// Trigger ставит ручной запуск в очередь; его заберет лидер планировщика на ближайшей проверке.
+// Пауза ручной запуск не запрещает.
+func (s *JobService) Trigger(ctx context.Context, name string, userID int64) (*models.JobRun, error) {
+	if _, ok := s.scheduler.Job(name); !ok {
+		return nil, ErrJobNotFound
+	}
+
+	run := models.JobRun{
+		JobName:     name,
+		Source:      models.JobSourceManual,
+		Status:      models.JobRunQueued,
+		TriggeredBy: &userID,
+	}
+	inserted, err := s.jobRepo.Insert(ctx, &run)
+	if err != nil {
+		return nil, err
+	}
+	if !inserted {
+		return nil, ErrJobRunInProgress
+	}
+	return &run, nil
+}
+
+// SetPaused ставит задачу на паузу или снимает с нее. Уже идущий запуск пауза не прерывает.
+func (s *JobService) SetPaused(ctx context.Context, name string, paused bool, userID int64) (*JobInfo, error) {
+	if _, ok := s.scheduler.Job(name); !ok {
+		return nil, ErrJobNotFound
+	}
+	if _, err := s.jobRepo.SetPaused(ctx, name, paused, userID, s.nowFn()); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, name)
+}
+
+func (s *JobService) info(job scheduler.Job, paused bool, lastRun *models.JobRun) JobInfo {
+	info := JobInfo{Job: job, Paused: paused, LastRun: lastRun}
+	if !paused {
+		if next := s.scheduler.NextRun(job.Name, s.nowFn()); !next.IsZero() {
+			info.NextRunAt = &next
+		}
+	}
+	return info
+}
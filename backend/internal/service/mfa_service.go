@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+const (
+	recoveryCodesCount = 10
+	recoveryCodeLength = 10
+	// 32 символа без похожих (i/l/1, o) — без смещения при выборе по байту
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+	// после стольких неверных кодов mfa_token перестает работать, нужно снова войти по паролю
+	mfaChallengeMaxAttempts = 5
+	// mfaDisableAttemptWindow — окно, в котором на отключение 2FA дается mfaChallengeMaxAttempts попыток.
+	mfaDisableAttemptWindow = 15 * time.Minute
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+	ErrMFARequiredByRole = errors.New("two-factor authentication is required for this role")
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa token")
+	ErrMFAAttemptsLimit  = errors.New("too many two-factor authentication attempts")
+)
+
+type MFAEnrollment struct {
+	Secret        string
+	OTPAuthURI    string
+	RecoveryCodes []string
+}
+
+type MFAService struct {
+	userRepo     *repo.UserRepo
+	mfaRepo      *repo.MFARepo
+	txManager    *repo.TxManager
+	tokenService TokenService
+	authSvc      *AuthService
+	secretBox    *auth.SecretBox
+	issuer       string
+	nowFn        func() time.Time
+}
+
+func NewMFAService(
+	userRepo *repo.UserRepo,
+	mfaRepo *repo.MFARepo,
+	txManager *repo.TxManager,
+	tokenService TokenService,
+	authSvc *AuthService,
+	secretBox *auth.SecretBox,
+	issuer string,
+) *MFAService {
+	return &MFAService{
+		userRepo:     userRepo,
+		mfaRepo:      mfaRepo,
+		txManager:    txManager,
+		tokenService: tokenService,
+		authSvc:      authSvc,
+		secretBox:    secretBox,
+		issuer:       issuer,
+		nowFn:        time.Now,
+	}
+}
+
+// Enroll начинает привязку аутентификатора. 2FA включается только после ConfirmEnrollment.
+func (s *MFAService) Enroll(ctx context.Context, userID int64) (*MFAEnrollment, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate totp secret: %w", err)
+	}
+	encrypted, err := s.secretBox.Seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt totp secret: %w", err)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("generate recovery codes: %w", err)
+	}
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.SetPendingTOTPSecret(ctx, user.ID, encrypted); err != nil {
+			return fmt.Errorf("save totp secret: %w", err)
+		}
+		if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+			return fmt.Errorf("save recovery codes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret:        secret,
+		OTPAuthURI:    auth.TOTPURI(s.issuer, user.Email, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// ConfirmEnrollment включает 2FA после первого корректного кода из приложения.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID int64, code string) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err := s.checkTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	now := s.nowFn()
+	if err := s.userRepo.EnableTOTP(ctx, user.ID, now); err != nil {
+		return nil, fmt.Errorf("enable totp: %w", err)
+	}
+	user.TOTPEnabledAt = &now
+
+	return user, nil
+}
+
+// Disable отключает 2FA. Требует действующий код или код восстановления. Попытки ограничены,
+// как у второго шага логина: иначе украденный access-токен позволил бы перебрать TOTP-код.
+func (s *MFAService) Disable(ctx context.Context, userID int64, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled() {
+		return ErrMFANotEnrolled
+	}
+
+	required, err := s.mfaRepo.IsRequiredForRole(ctx, user.Role)
+	if err != nil {
+		return fmt.Errorf("get mfa policy: %w", err)
+	}
+	if required {
+		return ErrMFARequiredByRole
+	}
+
+	// попытка засчитывается до проверки, чтобы параллельные запросы не обошли лимит
+	attemptKey := fmt.Sprintf("disable:%d", user.ID)
+	err = s.mfaRepo.StartUserAttempt(
+		ctx, attemptKey, user.ID, mfaChallengeMaxAttempts, mfaDisableAttemptWindow, s.nowFn(),
+	)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMFAAttemptsLimit
+		}
+		return fmt.Errorf("start mfa disable attempt: %w", err)
+	}
+
+	if err := s.checkCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.DisableTOTP(ctx, user.ID); err != nil {
+			return fmt.Errorf("disable totp: %w", err)
+		}
+		if err := s.mfaRepo.DeleteRecoveryCodes(ctx, user.ID); err != nil {
+			return fmt.Errorf("delete recovery codes: %w", err)
+		}
+		if err := s.mfaRepo.DeleteChallenge(ctx, attemptKey); err != nil {
+			return fmt.Errorf("reset mfa disable attempts: %w", err)
+		}
+		return nil
+	})
+}
+
+// VerifyLogin завершает логин: проверяет челлендж-токен и код, выдает полные токены.
+// Каждая проверка кода засчитывается челленджу токена, после успешной он гасится.
+func (s *MFAService) VerifyLogin(ctx context.Context, mfaToken, code string) (*TokenPair, error) {
+	claims, err := s.tokenService.ParseAndValidate(strings.TrimSpace(mfaToken), auth.TokenTypeMFA)
+	if err != nil || claims.ID == "" {
+		return nil, ErrInvalidMFAToken
+	}
+
+	err = s.mfaRepo.StartChallengeAttempt(ctx, claims.ID, claims.UserID, mfaChallengeMaxAttempts, s.nowFn())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, fmt.Errorf("start mfa challenge attempt: %w", err)
+	}
+
+	user, err := s.getUser(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	if err := checkUserActive(user); err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled() {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := s.checkCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ConsumeChallenge(ctx, claims.ID, s.nowFn()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, fmt.Errorf("consume mfa challenge: %w", err)
+	}
+
+	return s.authSvc.IssueTokens(user)
+}
+
+func (s *MFAService) ListPolicies(ctx context.Context) ([]models.MFARolePolicy, error) {
+	policies, err := s.mfaRepo.ListPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list mfa policies: %w", err)
+	}
+	return policies, nil
+}
+
+func (s *MFAService) SetPolicy(
+	ctx context.Context,
+	adminID int64,
+	role string,
+	required bool,
+) (*models.MFARolePolicy, error) {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return nil, ErrInvalidRole
+	}
+
+	policy := &models.MFARolePolicy{
+		Role:      role,
+		Required:  required,
+		UpdatedBy: &adminID,
+		UpdatedAt: s.nowFn(),
+	}
+	if err := s.mfaRepo.UpsertPolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("save mfa policy: %w", err)
+	}
+	return policy, nil
+}
+
+// checkCode принимает TOTP-код или одноразовый код восстановления.
+func (s *MFAService) checkCode(ctx context.Context, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrInvalidMFACode
+	}
+
+	if normalized := normalizeRecoveryCode(code); len(normalized) == recoveryCodeLength {
+		err := s.mfaRepo.ConsumeRecoveryCode(ctx, user.ID, auth.HashOpaqueToken(normalized), s.nowFn())
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidMFACode
+			}
+			return fmt.Errorf("consume recovery code: %w", err)
+		}
+		return nil
+	}
+
+	return s.checkTOTP(ctx, user, code)
+}
+
+func (s *MFAService) checkTOTP(ctx context.Context, user *models.User, code string) error {
+	if user.TOTPSecret == nil {
+		return ErrMFANotEnrolled
+	}
+
+	secret, err := s.secretBox.Open(*user.TOTPSecret)
+	if err != nil {
+		return fmt.Errorf("decrypt totp secret: %w", err)
+	}
+
+	step, ok := auth.ValidateTOTP(secret, code, s.nowFn(), user.TOTPLastStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	// Условное обновление защищает от повторного использования кода в параллельных запросах.
+	advanced, err := s.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return fmt.Errorf("save totp step: %w", err)
+	}
+	if !advanced {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) getUser(ctx context.Context, userID int64) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("get user by id: %w", err)
+	}
+	return user, nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	buf := make([]byte, recoveryCodeLength)
+	for range recoveryCodesCount {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+
+		raw := make([]byte, recoveryCodeLength)
+		for i, b := range buf {
+			raw[i] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+		}
+
+		half := recoveryCodeLength / 2
+		codes = append(codes, string(raw[:half])+"-"+string(raw[half:]))
+		hashes = append(hashes, auth.HashOpaqueToken(string(raw)))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+const (
+	// секрет из тестовых векторов RFC 6238; код 287082 действует на шаге 1 (t=59s)
+	testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	testTOTPCode   = "287082"
+
+	testMFAPassword = "correct horse battery"
+
+	startAttemptSQL     = `UPDATE "mfa_challenges" SET "attempts"=attempts \+ 1 WHERE id = .+ AND attempts < `
+	consumeChallengeSQL = `UPDATE "mfa_challenges" SET "consumed_at"=.+ WHERE id = .+ AND consumed_at IS NULL`
+)
+
+func newTestMFA(t *testing.T) (*MFAService, *auth.JWTService, sqlmock.Sqlmock, models.User) {
+	t.Helper()
+	db, mock := newMockGorm(t)
+
+	box, err := auth.NewSecretBox("test-mfa-encryption-key-0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := box.Seal(testTOTPSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabledAt := time.Unix(0, 0)
+	user := models.User{
+		ID: 7, Email: "mfa.user@example.com", Role: models.RoleUser, IsActive: true,
+		EmailVerifiedAt: &enabledAt, TOTPSecret: &secret, TOTPEnabledAt: &enabledAt,
+	}
+
+	hashers, err := auth.NewPasswordHashersFor(auth.PasswordAlgorithmBcrypt, auth.Argon2idParams{}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.PasswordHash, err = hashers.Hash(testMFAPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	jwtSvc := auth.NewJWTService("secret", "test", time.Minute, time.Hour)
+	userRepo := repo.NewUserRepo(db)
+	mfaRepo := repo.NewMFARepo(db)
+	authSvc := NewAuthService(userRepo, mfaRepo, jwtSvc, hashers)
+	svc := NewMFAService(userRepo, mfaRepo, repo.NewTxManager(db), jwtSvc, authSvc, box, "test")
+	svc.nowFn = func() time.Time { return time.Unix(59, 0) }
+	return svc, jwtSvc, mock, user
+}
+
+func TestLoginStoresMFAChallengeForTokenID(t *testing.T) {
+	svc, jwtSvc, mock, user := newTestMFA(t)
+
+	var challengeID string
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows(user))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "mfa_challenges"`).
+		WithArgs(captureString{&challengeID}, user.ID, 0, sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, err := svc.authSvc.Login(context.Background(), user.Email, testMFAPassword)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if result.Tokens != nil || result.MFA == nil || result.MFA.EnrollmentRequired {
+		t.Fatalf("Login() = %+v, want an mfa challenge", result)
+	}
+
+	claims, err := jwtSvc.ParseAndValidate(result.MFA.Token, auth.TokenTypeMFA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ID == "" || claims.ID != challengeID {
+		t.Fatalf("mfa token jti = %q, stored challenge id = %q", claims.ID, challengeID)
+	}
+}
+
+func TestVerifyLoginConsumesChallenge(t *testing.T) {
+	svc, jwtSvc, mock, user := newTestMFA(t)
+	token, err := jwtSvc.GenerateMFAChallengeToken(user.ID, user.Email, auth.TokenTypeMFA, "challenge-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectWrite(mock, startAttemptSQL, 1, "challenge-1", user.ID, sqlmock.AnyArg(), mfaChallengeMaxAttempts)
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows(user))
+	expectWrite(mock, `UPDATE "users" SET "totp_last_step"`, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), user.ID, 1)
+	expectWrite(mock, consumeChallengeSQL, 1, sqlmock.AnyArg(), "challenge-1")
+
+	tokens, err := svc.VerifyLogin(context.Background(), token, testTOTPCode)
+	if err != nil {
+		t.Fatalf("VerifyLogin() error = %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("VerifyLogin() tokens = %+v, want both issued", tokens)
+	}
+
+	// использованный челлендж условное обновление уже не находит
+	expectWrite(mock, startAttemptSQL, 0, "challenge-1", user.ID, sqlmock.AnyArg(), mfaChallengeMaxAttempts)
+	if _, err := svc.VerifyLogin(context.Background(), token, testTOTPCode); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Fatalf("second VerifyLogin() error = %v, want ErrInvalidMFAToken", err)
+	}
+}
+
+func TestVerifyLoginStopsAfterMaxAttempts(t *testing.T) {
+	svc, jwtSvc, mock, user := newTestMFA(t)
+	token, err := jwtSvc.GenerateMFAChallengeToken(user.ID, user.Email, auth.TokenTypeMFA, "challenge-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range mfaChallengeMaxAttempts {
+		expectWrite(mock, startAttemptSQL, 1, "challenge-1", user.ID, sqlmock.AnyArg(), mfaChallengeMaxAttempts)
+		mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows(user))
+		if _, err := svc.VerifyLogin(context.Background(), token, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("VerifyLogin(wrong code) error = %v, want ErrInvalidMFACode", err)
+		}
+	}
+
+	// попытки кончились: даже верный код не принимается, и до пользователя запрос не доходит
+	expectWrite(mock, startAttemptSQL, 0, "challenge-1", user.ID, sqlmock.AnyArg(), mfaChallengeMaxAttempts)
+	if _, err := svc.VerifyLogin(context.Background(), token, testTOTPCode); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Fatalf("VerifyLogin() after %d failures error = %v, want ErrInvalidMFAToken", mfaChallengeMaxAttempts, err)
+	}
+}
+
+func TestVerifyLoginRejectsTokenWithoutChallenge(t *testing.T) {
+	svc, jwtSvc, _, user := newTestMFA(t)
+
+	enrollToken, err := jwtSvc.GenerateMFAChallengeToken(user.ID, user.Email, auth.TokenTypeMFAEnroll, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, err := jwtSvc.GenerateAccessToken(user.ID, user.Email, user.Role)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"enroll token": enrollToken, "access token": accessToken} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := svc.VerifyLogin(context.Background(), token, testTOTPCode); !errors.Is(err, ErrInvalidMFAToken) {
+				t.Fatalf("VerifyLogin() error = %v, want ErrInvalidMFAToken", err)
+			}
+		})
+	}
+}
+
+func TestDisableStopsAfterMaxAttempts(t *testing.T) {
+	svc, _, mock, user := newTestMFA(t)
+	const startUserAttemptSQL = `INSERT INTO mfa_challenges .+ ON CONFLICT \(id\) DO UPDATE`
+	expectAttempt := func(rowsAffected int64) {
+		mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows(user))
+		mock.ExpectQuery(`SELECT \* FROM "mfa_role_policies"`).WillReturnRows(sqlmock.NewRows([]string{"role"}))
+		mock.ExpectExec(startUserAttemptSQL).
+			WithArgs("disable:7", user.ID, time.Unix(59, 0).Add(mfaDisableAttemptWindow),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), mfaChallengeMaxAttempts).
+			WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+	}
+
+	for range mfaChallengeMaxAttempts {
+		expectAttempt(1)
+		if err := svc.Disable(context.Background(), user.ID, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("Disable(wrong code) error = %v, want ErrInvalidMFACode", err)
+		}
+	}
+
+	// попытки в окне кончились: верный код не проверяется и 2FA остается включенной
+	expectAttempt(0)
+	if err := svc.Disable(context.Background(), user.ID, testTOTPCode); !errors.Is(err, ErrMFAAttemptsLimit) {
+		t.Fatalf("Disable() after %d failures error = %v, want ErrMFAAttemptsLimit", mfaChallengeMaxAttempts, err)
+	}
+}
+
+func TestDisableResetsAttempts(t *testing.T) {
+	svc, _, mock, user := newTestMFA(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows(user))
+	mock.ExpectQuery(`SELECT \* FROM "mfa_role_policies"`).WillReturnRows(sqlmock.NewRows([]string{"role"}))
+	mock.ExpectExec(`INSERT INTO mfa_challenges`).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectWrite(mock, `UPDATE "users" SET "totp_last_step"`, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), user.ID, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "mfa_recovery_codes"`).WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectExec(`DELETE FROM "mfa_challenges" WHERE id = \$1`).
+		WithArgs("disable:7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := svc.Disable(context.Background(), user.ID, testTOTPCode); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	// часовые пояса нужны и в контейнерах без системной tzdata
+	_ "time/tzdata"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+var (
+	ErrInvalidTimezone    = errors.New("invalid timezone")
+	ErrInvalidLanguage    = errors.New("invalid language")
+	ErrInvalidDefaultAsOf = errors.New("invalid default_as_of")
+
+	ErrNotificationTypeNotFound = errors.New("notification type not found")
+	ErrNotificationRequired     = errors.New("notification is required")
+	ErrNotificationNotAllowed   = errors.New("notification is not available for this role")
+)
+
+type Profile struct {
+	User        *models.User
+	Preferences models.UserPreferences
+}
+
+// NotificationSetting — получает ли пользователь письма этого типа.
+type NotificationSetting struct {
+	Type     string
+	Enabled  bool
+	Required bool
+}
+
+// PreferencesPatch — частичное обновление, nil-поля не меняются.
+type PreferencesPatch struct {
+	Timezone    *string
+	Language    *string
+	DefaultAsOf *string
+}
+
+type ProfileService struct {
+	userRepo         *repo.UserRepo
+	preferencesRepo  *repo.PreferencesRepo
+	notificationRepo *repo.NotificationRepo
+	nowFn            func() time.Time
+}
+
+func NewProfileService(
+	userRepo *repo.UserRepo,
+	preferencesRepo *repo.PreferencesRepo,
+	notificationRepo *repo.NotificationRepo,
+) *ProfileService {
+	return &ProfileService{
+		userRepo:         userRepo,
+		preferencesRepo:  preferencesRepo,
+		notificationRepo: notificationRepo,
+		nowFn:            time.Now,
+	}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, userID int64) (*Profile, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("get user by id: %w", err)
+	}
+
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Profile{User: user, Preferences: prefs}, nil
+}
+
+func (s *ProfileService) UpdatePreferences(
+	ctx context.Context,
+	userID int64,
+	patch PreferencesPatch,
+) (*Profile, error) {
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Timezone != nil {
+		tz := strings.TrimSpace(*patch.Timezone)
+		if _, err := time.LoadLocation(tz); err != nil || tz == "" || tz == "Local" {
+			return nil, ErrInvalidTimezone
+		}
+		prefs.Timezone = tz
+	}
+	if patch.Language != nil {
+		lang := strings.ToLower(strings.TrimSpace(*patch.Language))
+		if lang != models.LanguageRU && lang != models.LanguageEN {
+			return nil, ErrInvalidLanguage
+		}
+		prefs.Language = lang
+	}
+	if patch.DefaultAsOf != nil {
+		asOf := strings.ToLower(strings.TrimSpace(*patch.DefaultAsOf))
+		if asOf != models.AsOfToday && asOf != models.AsOfLastRepair {
+			return nil, ErrInvalidDefaultAsOf
+		}
+		prefs.DefaultAsOf = asOf
+	}
+
+	prefs.UpdatedAt = s.nowFn()
+	if err := s.preferencesRepo.Upsert(ctx, &prefs); err != nil {
+		return nil, fmt.Errorf("save preferences: %w", err)
+	}
+
+	return s.GetProfile(ctx, userID)
+}
+
+func (s *ProfileService) Preferences(ctx context.Context, userID int64) (models.UserPreferences, error) {
+	prefs, err := s.preferencesRepo.Get(ctx, userID)
+	if err != nil {
+		return models.UserPreferences{}, fmt.Errorf("get preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// NotificationSettings — типы писем, доступные роли role. По умолчанию пользователь получает каждый,
+// от обязательных отказаться нельзя.
+func (s *ProfileService) NotificationSettings(
+	ctx context.Context,
+	userID int64,
+	role string,
+) ([]NotificationSetting, error) {
+	optOuts, err := s.notificationRepo.OptOuts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get notification opt-outs: %w", err)
+	}
+
+	settings := make([]NotificationSetting, 0, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		if !models.NotificationAllowed(notificationType, role) {
+			continue
+		}
+		required := models.NotificationRequired(notificationType)
+		settings = append(settings, NotificationSetting{
+			Type:     notificationType,
+			Enabled:  required || !slices.Contains(optOuts, notificationType),
+			Required: required,
+		})
+	}
+	return settings, nil
+}
+
+// SetNotification включает или выключает письма одного типа и возвращает все настройки.
+func (s *ProfileService) SetNotification(
+	ctx context.Context,
+	userID int64,
+	role string,
+	notificationType string,
+	enabled bool,
+) ([]NotificationSetting, error) {
+	if !models.KnownNotificationType(notificationType) {
+		return nil, ErrNotificationTypeNotFound
+	}
+	if models.NotificationRequired(notificationType) {
+		if !enabled {
+			return nil, ErrNotificationRequired
+		}
+		return s.NotificationSettings(ctx, userID, role)
+	}
+	// отказаться можно всегда: отказ сохранится и при смене роли
+	if enabled && !models.NotificationAllowed(notificationType, role) {
+		return nil, ErrNotificationNotAllowed
+	}
+
+	if err := s.notificationRepo.SetOptOut(ctx, userID, notificationType, !enabled); err != nil {
+		return nil, fmt.Errorf("save notification opt-out: %w", err)
+	}
+	return s.NotificationSettings(ctx, userID, role)
+}
+
+// PreferencesLocation возвращает часовой пояс пользователя; при ошибке — UTC.
+func PreferencesLocation(prefs models.UserPreferences) *time.Location {
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+const (
+	selectOptOutsSQL     = `SELECT "type" FROM "notification_opt_outs" WHERE user_id = \$1`
+	selectPreferencesSQL = `SELECT \* FROM "user_preferences" WHERE user_id = \$1 LIMIT \$2`
+)
+
+func optOutRows(types ...string) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"type"})
+	for _, notificationType := range types {
+		rows.AddRow(notificationType)
+	}
+	return rows
+}
+
+func settingEnabled(settings []NotificationSetting, notificationType string) (enabled, found bool) {
+	for _, setting := range settings {
+		if setting.Type == notificationType {
+			return setting.Enabled, true
+		}
+	}
+	return false, false
+}
+
+func TestNotificationSettingsAreOptOutAndScopedByRole(t *testing.T) {
+	db, mock := newMockGorm(t)
+	svc := NewProfileService(repo.NewUserRepo(db), repo.NewPreferencesRepo(db), repo.NewNotificationRepo(db))
+	ctx := context.Background()
+
+	// письма о порогах роли user недоступны вовсе, включить их нельзя
+	mock.ExpectQuery(selectOptOutsSQL).WithArgs(1).WillReturnRows(optOutRows())
+	settings, err := svc.NotificationSettings(ctx, 1, models.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settings) != 1 || settings[0].Type != models.NotificationEmailVerification || !settings[0].Enabled {
+		t.Fatalf("user settings = %+v, want only the required email_verification", settings)
+	}
+	_, err = svc.SetNotification(ctx, 1, models.RoleUser, models.NotificationThresholdExceeded, true)
+	if !errors.Is(err, ErrNotificationNotAllowed) {
+		t.Fatalf("user enable error = %v, want %v", err, ErrNotificationNotAllowed)
+	}
+
+	// без отказов администратор получает все доступные письма
+	mock.ExpectQuery(selectOptOutsSQL).WithArgs(2).WillReturnRows(optOutRows())
+	settings, err = svc.NotificationSettings(ctx, 2, models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminTypes := []string{models.NotificationThresholdApproaching, models.NotificationThresholdExceeded}
+	for _, notificationType := range adminTypes {
+		if enabled, found := settingEnabled(settings, notificationType); !found || !enabled {
+			t.Fatalf("admin settings = %+v, want %s enabled by default", settings, notificationType)
+		}
+	}
+
+	// выключение сохраняет отказ
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "notification_opt_outs" \("user_id","type","created_at"\) .* ON CONFLICT DO NOTHING`).
+		WithArgs(2, models.NotificationThresholdExceeded, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(selectOptOutsSQL).WithArgs(2).WillReturnRows(optOutRows(models.NotificationThresholdExceeded))
+	settings, err = svc.SetNotification(ctx, 2, models.RoleAdmin, models.NotificationThresholdExceeded, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enabled, _ := settingEnabled(settings, models.NotificationThresholdExceeded); enabled {
+		t.Fatalf("admin settings after opt-out = %+v, want threshold_exceeded disabled", settings)
+	}
+	if enabled, _ := settingEnabled(settings, models.NotificationThresholdApproaching); !enabled {
+		t.Fatalf("admin settings after opt-out = %+v, want threshold_approaching still enabled", settings)
+	}
+
+	// включение обратно удаляет отказ
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "notification_opt_outs" WHERE user_id = \$1 AND type = \$2`).
+		WithArgs(2, models.NotificationThresholdExceeded).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(selectOptOutsSQL).WithArgs(2).WillReturnRows(optOutRows())
+	if _, err := svc.SetNotification(ctx, 2, models.RoleAdmin, models.NotificationThresholdExceeded, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdatePreferencesValidatesAndKeepsUnsetFields(t *testing.T) {
+	db, mock := newMockGorm(t)
+	svc := NewProfileService(repo.NewUserRepo(db), repo.NewPreferencesRepo(db), repo.NewNotificationRepo(db))
+	ctx := context.Background()
+
+	expectStored := func() {
+		mock.ExpectQuery(selectPreferencesSQL).WithArgs(1, 1).WillReturnRows(
+			sqlmock.NewRows([]string{"user_id", "timezone", "language", "default_as_of"}).
+				AddRow(1, "UTC", models.LanguageEN, models.AsOfLastRepair))
+	}
+	ptr := func(s string) *string { return &s }
+
+	invalid := []struct {
+		name  string
+		patch PreferencesPatch
+		want  error
+	}{
+		{name: "unknown timezone", patch: PreferencesPatch{Timezone: ptr("Mars/Olympus")}, want: ErrInvalidTimezone},
+		// Local — часовой пояс сервера, а не пользователя
+		{name: "local timezone", patch: PreferencesPatch{Timezone: ptr("Local")}, want: ErrInvalidTimezone},
+		{name: "empty timezone", patch: PreferencesPatch{Timezone: ptr(" ")}, want: ErrInvalidTimezone},
+		{name: "unknown language", patch: PreferencesPatch{Language: ptr("de")}, want: ErrInvalidLanguage},
+		{name: "unknown as of", patch: PreferencesPatch{DefaultAsOf: ptr("yesterday")}, want: ErrInvalidDefaultAsOf},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStored()
+			if _, err := svc.UpdatePreferences(ctx, 1, tt.patch); !errors.Is(err, tt.want) {
+				t.Fatalf("UpdatePreferences() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// меняется только переданное поле, язык и default_as_of остаются сохраненными
+	expectStored()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "user_preferences" .* ON CONFLICT \("user_id"\) DO UPDATE SET`).
+		WithArgs("Europe/Moscow", models.LanguageEN, models.AsOfLastRepair, sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(1, 1).
+		WillReturnRows(userRows(models.User{ID: 1, Email: "a@example.com", Role: models.RoleUser, IsActive: true}))
+	mock.ExpectQuery(selectPreferencesSQL).WithArgs(1, 1).WillReturnRows(
+		sqlmock.NewRows([]string{"user_id", "timezone", "language", "default_as_of"}).
+			AddRow(1, "Europe/Moscow", models.LanguageEN, models.AsOfLastRepair))
+
+	profile, err := svc.UpdatePreferences(ctx, 1, PreferencesPatch{Timezone: ptr(" Europe/Moscow ")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Preferences.Timezone != "Europe/Moscow" || profile.Preferences.Language != models.LanguageEN {
+		t.Fatalf("preferences = %+v", profile.Preferences)
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/config"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+var (
+	ErrRegistrationInviteOnly   = errors.New("registration is invite-only")
+	ErrEmailDomainNotAllowed    = errors.New("email domain is not allowed")
+	ErrInvalidInvitation        = errors.New("invalid or expired invitation code")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrInvalidRole              = errors.New("invalid role")
+)
+
+type RegistrationPolicy struct {
+	Mode                      string
+	AllowedDomains            []string
+	EmailVerificationRequired bool
+	EmailVerificationTTL      time.Duration
+	InvitationTTL             time.Duration
+}
+
+type CreatedInvitation struct {
+	Invitation models.Invitation
+	Code       string
+}
+
+type RegistrationService struct {
+	userRepo           *repo.UserRepo
+	invitationRepo     *repo.InvitationRepo
+	verificationRepo   *repo.EmailVerificationRepo
+	txManager          *repo.TxManager
+	verificationSender VerificationSender
+	policy             RegistrationPolicy
+	passwordHashers    *auth.PasswordHashers
+	passwordPolicy     *auth.PasswordPolicy
+	nowFn              func() time.Time
+}
+
+func NewRegistrationService(
+	userRepo *repo.UserRepo,
+	invitationRepo *repo.InvitationRepo,
+	verificationRepo *repo.EmailVerificationRepo,
+	txManager *repo.TxManager,
+	verificationSender VerificationSender,
+	passwordHashers *auth.PasswordHashers,
+	passwordPolicy *auth.PasswordPolicy,
+	policy RegistrationPolicy,
+) *RegistrationService {
+	for i, domain := range policy.AllowedDomains {
+		policy.AllowedDomains[i] = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+	}
+
+	return &RegistrationService{
+		userRepo:           userRepo,
+		invitationRepo:     invitationRepo,
+		verificationRepo:   verificationRepo,
+		txManager:          txManager,
+		verificationSender: verificationSender,
+		policy:             policy,
+		passwordHashers:    passwordHashers,
+		passwordPolicy:     passwordPolicy,
+		nowFn:              time.Now,
+	}
+}
+
+func (s *RegistrationService) Register(ctx context.Context, email, password, inviteCode string) (*models.User, error) {
+	email = normalizeEmail(email)
+
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+	if err := s.passwordPolicy.Validate(password, email); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWeakPassword, err)
+	}
+
+	var invitation *models.Invitation
+	switch s.policy.Mode {
+	case config.RegistrationModeDomain:
+		if !slices.Contains(s.policy.AllowedDomains, emailDomain(email)) {
+			return nil, ErrEmailDomainNotAllowed
+		}
+	case config.RegistrationModeInvite:
+		var err error
+		invitation, err = s.lookupInvitation(ctx, email, inviteCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hash, err := s.passwordHashers.Hash(password)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+
+	user := &models.User{
+		Email:        email,
+		PasswordHash: hash,
+		Role:         models.RoleUser,
+		IsActive:     !s.policy.EmailVerificationRequired,
+	}
+	if invitation != nil {
+		user.Role = invitation.Role
+		user.DealerCode = invitation.DealerCode
+	}
+	if !s.policy.EmailVerificationRequired {
+		now := s.nowFn()
+		user.EmailVerifiedAt = &now
+	}
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			// Если пользователь уже есть.
+			// На сервисном слое нормализуем DB-ошибку в бизнес-ошибку.
+			if isUniqueViolation(err) {
+				return ErrEmailAlreadyExists
+			}
+			return fmt.Errorf("create user: %w", err)
+		}
+
+		if invitation != nil {
+			if err := s.invitationRepo.MarkUsed(ctx, invitation.ID, user.ID, s.nowFn()); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrInvalidInvitation
+				}
+				return fmt.Errorf("mark invitation used: %w", err)
+			}
+		}
+
+		if s.policy.EmailVerificationRequired {
+			return s.sendVerification(ctx, user)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// VerifyEmail подтверждает email по токену и активирует аккаунт.
+func (s *RegistrationService) VerifyEmail(ctx context.Context, token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return ErrInvalidVerificationToken
+	}
+
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		now := s.nowFn()
+		verification, err := s.verificationRepo.Consume(ctx, auth.HashOpaqueToken(token), now)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return fmt.Errorf("consume email verification: %w", err)
+		}
+
+		if err := s.userRepo.MarkEmailVerified(ctx, verification.UserID, now); err != nil {
+			return fmt.Errorf("mark email verified: %w", err)
+		}
+		return nil
+	})
+}
+
+// ResendVerification выпускает новый токен подтверждения.
+// Ничего не сообщает о существовании аккаунта, чтобы email нельзя было перебрать.
+func (s *RegistrationService) ResendVerification(ctx context.Context, email string) error {
+	if !s.policy.EmailVerificationRequired {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get user by email: %w", err)
+	}
+	if !user.IsPendingVerification() {
+		return nil
+	}
+
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return s.sendVerification(ctx, user)
+	})
+}
+
+// CreateInvitation выпускает код приглашения. Код возвращается только один раз, в БД хранится хеш.
+func (s *RegistrationService) CreateInvitation(
+	ctx context.Context,
+	createdBy int64,
+	email string,
+	role string,
+	dealerCode string,
+) (*CreatedInvitation, error) {
+	if role == "" {
+		role = models.RoleUser
+	}
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return nil, ErrInvalidRole
+	}
+
+	invitation := models.Invitation{
+		Role:      role,
+		CreatedBy: &createdBy,
+		ExpiresAt: s.nowFn().Add(s.policy.InvitationTTL),
+	}
+
+	email = normalizeEmail(email)
+	if email != "" {
+		if err := validateEmail(email); err != nil {
+			return nil, err
+		}
+		invitation.Email = &email
+	}
+	if dealerCode = strings.TrimSpace(dealerCode); dealerCode != "" {
+		invitation.DealerCode = &dealerCode
+	}
+
+	code, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate invitation code: %w", err)
+	}
+	invitation.CodeHash = auth.HashOpaqueToken(code)
+
+	if err := s.invitationRepo.Create(ctx, &invitation); err != nil {
+		return nil, fmt.Errorf("create invitation: %w", err)
+	}
+
+	return &CreatedInvitation{Invitation: invitation, Code: code}, nil
+}
+
+func (s *RegistrationService) ListInvitations(ctx context.Context) ([]models.Invitation, error) {
+	invitations, err := s.invitationRepo.List(ctx, 100)
+	if err != nil {
+		return nil, fmt.Errorf("list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+func (s *RegistrationService) lookupInvitation(ctx context.Context, email, code string) (*models.Invitation, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, ErrRegistrationInviteOnly
+	}
+
+	invitation, err := s.invitationRepo.GetByCodeHash(ctx, auth.HashOpaqueToken(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, fmt.Errorf("get invitation: %w", err)
+	}
+
+	if invitation.UsedAt != nil || !invitation.ExpiresAt.After(s.nowFn()) {
+		return nil, ErrInvalidInvitation
+	}
+	// Инвайт, выпущенный на конкретный адрес, нельзя использовать для другого email.
+	if invitation.Email != nil && normalizeEmail(*invitation.Email) != email {
+		return nil, ErrInvalidInvitation
+	}
+
+	return invitation, nil
+}
+
+// sendVerification выпускает токен и ставит письмо с ним в очередь. Вызывается в транзакции: письмо
+// сохраняется вместе с аккаунтом и токеном или не сохраняется ничего, и повторная регистрация не упрется
+// в email_already_exists.
+func (s *RegistrationService) sendVerification(ctx context.Context, user *models.User) error {
+	token, err := s.issueVerification(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.verificationSender.SendEmailVerification(ctx, user.Email, token); err != nil {
+		return fmt.Errorf("send email verification: %w", err)
+	}
+	return nil
+}
+
+func (s *RegistrationService) issueVerification(ctx context.Context, userID int64) (string, error) {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("generate verification token: %w", err)
+	}
+
+	err = s.verificationRepo.Create(ctx, &models.EmailVerification{
+		UserID:    userID,
+		TokenHash: auth.HashOpaqueToken(token),
+		ExpiresAt: s.nowFn().Add(s.policy.EmailVerificationTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("create email verification: %w", err)
+	}
+
+	return token, nil
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return email[at+1:]
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/config"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+const (
+	testRegistrationPassword = "correct horse battery"
+	testVerificationTTL      = time.Hour
+
+	insertUserSQL         = `INSERT INTO "users"`
+	insertVerificationSQL = `INSERT INTO "email_verifications"`
+	selectVerificationSQL = `SELECT \* FROM "email_verifications" WHERE token_hash = \$1 AND used_at IS NULL ` +
+		`AND expires_at > \$2`
+	consumeVerificationSQL = `UPDATE "email_verifications" SET "used_at"=\$1 WHERE id = \$2 AND used_at IS NULL`
+	// подтверждение включает только аккаунт, который еще ждет его
+	markEmailVerifiedSQL = `UPDATE "users" SET "email_verified_at"=\$1,"is_active"=\$2,"updated_at"=\$3 ` +
+		`WHERE id = \$4 AND email_verified_at IS NULL`
+	selectInvitationSQL = `SELECT \* FROM "invitations" WHERE code_hash = \$1`
+	markInvitationSQL   = `UPDATE "invitations" SET "used_at"=\$1,"used_by"=\$2 ` +
+		`WHERE id = \$3 AND used_at IS NULL AND expires_at > \$4`
+)
+
+var testRegistrationNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type recordingVerificationSender struct {
+	tokens map[string]string
+	err    error
+}
+
+func (s *recordingVerificationSender) SendEmailVerification(_ context.Context, email, token string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.tokens[email] = token
+	return nil
+}
+
+func newTestRegistration(
+	t *testing.T,
+	sender VerificationSender,
+	policy RegistrationPolicy,
+) (*RegistrationService, *AuthService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := newMockGorm(t)
+	userRepo := repo.NewUserRepo(db)
+	hashers, err := auth.NewPasswordHashersFor(auth.PasswordAlgorithmBcrypt, auth.Argon2idParams{}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registration := NewRegistrationService(
+		userRepo,
+		repo.NewInvitationRepo(db),
+		repo.NewEmailVerificationRepo(db),
+		repo.NewTxManager(db),
+		sender,
+		hashers,
+		auth.NewPasswordPolicy(8, 72, hashers.MaxPasswordBytes(), false),
+		policy,
+	)
+	registration.nowFn = func() time.Time { return testRegistrationNow }
+	jwtSvc := auth.NewJWTService("secret", "test", time.Minute, time.Hour)
+	return registration, NewAuthService(userRepo, repo.NewMFARepo(db), jwtSvc, hashers), mock
+}
+
+func openVerifiedPolicy() RegistrationPolicy {
+	return RegistrationPolicy{
+		Mode:                      config.RegistrationModeOpen,
+		EmailVerificationRequired: true,
+		EmailVerificationTTL:      testVerificationTTL,
+	}
+}
+
+// expectInsertUser ждет INSERT аккаунта с ролью role. Подтвержденный (verified) аккаунт сразу активен,
+// неподтвержденный создается выключенным и без email_verified_at.
+func expectInsertUser(mock sqlmock.Sqlmock, id int64, email, role string, verified bool, hash *string) {
+	var verifiedAt any
+	if verified {
+		verifiedAt = testRegistrationNow
+	}
+	mock.ExpectQuery(insertUserSQL).
+		WithArgs(email, captureString{hash}, role, sqlmock.AnyArg(), verified, verifiedAt, nil, nil, 0,
+			sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+}
+
+func expectInsertVerification(mock sqlmock.Sqlmock, userID int64, tokenHash *string) {
+	mock.ExpectQuery(insertVerificationSQL).
+		WithArgs(userID, captureString{tokenHash}, testRegistrationNow.Add(testVerificationTTL), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+}
+
+func TestRegisterWithVerificationRequiredBlocksLogin(t *testing.T) {
+	sender := &recordingVerificationSender{tokens: map[string]string{}}
+	registration, authSvc, mock := newTestRegistration(t, sender, openVerifiedPolicy())
+
+	var passwordHash, tokenHash string
+	mock.ExpectBegin()
+	expectInsertUser(mock, 10, "new.user@example.com", models.RoleUser, false, &passwordHash)
+	expectInsertVerification(mock, 10, &tokenHash)
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	user, err := registration.Register(ctx, "New.User@example.com", testRegistrationPassword, "")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if !user.IsPendingVerification() {
+		t.Fatalf("registered user = %+v, want pending verification", user)
+	}
+	token := sender.tokens["new.user@example.com"]
+	if token == "" || auth.HashOpaqueToken(token) != tokenHash {
+		t.Fatalf("sent token %q does not match stored hash %q", token, tokenHash)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(userRows(models.User{
+		ID: 10, Email: "new.user@example.com", PasswordHash: passwordHash, Role: models.RoleUser,
+	}))
+	_, err = authSvc.Login(ctx, "new.user@example.com", testRegistrationPassword)
+	if !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("Login() error = %v, want ErrEmailNotVerified", err)
+	}
+}
+
+func TestRegisterRollsBackWhenVerificationNotQueued(t *testing.T) {
+	sender := &recordingVerificationSender{tokens: map[string]string{}, err: errors.New("queue is down")}
+	registration, _, mock := newTestRegistration(t, sender, openVerifiedPolicy())
+
+	var passwordHash, tokenHash string
+	// аккаунта без письма не остается: повторная регистрация не получит email_already_exists
+	mock.ExpectBegin()
+	expectInsertUser(mock, 10, "new.user@example.com", models.RoleUser, false, &passwordHash)
+	expectInsertVerification(mock, 10, &tokenHash)
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	if _, err := registration.Register(ctx, "new.user@example.com", testRegistrationPassword, ""); err == nil {
+		t.Fatal("Register() error = nil, want error from sender")
+	}
+
+	sender.err = nil
+	mock.ExpectBegin()
+	expectInsertUser(mock, 11, "new.user@example.com", models.RoleUser, false, &passwordHash)
+	expectInsertVerification(mock, 11, &tokenHash)
+	mock.ExpectCommit()
+	if _, err := registration.Register(ctx, "new.user@example.com", testRegistrationPassword, ""); err != nil {
+		t.Fatalf("retry Register() error = %v", err)
+	}
+	if sender.tokens["new.user@example.com"] == "" {
+		t.Fatal("verification token was not sent on retry")
+	}
+}
+
+func TestRegisterWithInvitation(t *testing.T) {
+	const code = "invite-code"
+	invitedEmail := "invited@example.com"
+
+	tests := []struct {
+		name       string
+		email      string
+		expiresAt  time.Time
+		usedAt     *time.Time
+		wantInsert bool
+		markedRows int64
+		wantErr    error
+	}{
+		{
+			name:       "valid invitation is marked used in the same transaction",
+			email:      invitedEmail,
+			expiresAt:  testRegistrationNow.Add(time.Hour),
+			wantInsert: true,
+			markedRows: 1,
+		},
+		{
+			name:       "invitation used by a concurrent registration",
+			email:      invitedEmail,
+			expiresAt:  testRegistrationNow.Add(time.Hour),
+			wantInsert: true,
+			markedRows: 0,
+			wantErr:    ErrInvalidInvitation,
+		},
+		{
+			name:      "expired invitation",
+			email:     invitedEmail,
+			expiresAt: testRegistrationNow,
+			wantErr:   ErrInvalidInvitation,
+		},
+		{
+			name:      "already used invitation",
+			email:     invitedEmail,
+			expiresAt: testRegistrationNow.Add(time.Hour),
+			usedAt:    &testRegistrationNow,
+			wantErr:   ErrInvalidInvitation,
+		},
+		{
+			name:      "invitation for another email",
+			email:     "someone.else@example.com",
+			expiresAt: testRegistrationNow.Add(time.Hour),
+			wantErr:   ErrInvalidInvitation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registration, _, mock := newTestRegistration(t, nil, RegistrationPolicy{
+				Mode:          config.RegistrationModeInvite,
+				InvitationTTL: time.Hour,
+			})
+
+			mock.ExpectQuery(selectInvitationSQL).
+				WithArgs(auth.HashOpaqueToken(code), 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "code_hash", "email", "role", "expires_at", "used_at"}).
+					AddRow(3, auth.HashOpaqueToken(code), invitedEmail, models.RoleAdmin, tt.expiresAt, tt.usedAt))
+			if tt.wantInsert {
+				var passwordHash string
+				mock.ExpectBegin()
+				expectInsertUser(mock, 10, tt.email, models.RoleAdmin, true, &passwordHash)
+				mock.ExpectExec(markInvitationSQL).
+					WithArgs(testRegistrationNow, 10, 3, testRegistrationNow).
+					WillReturnResult(sqlmock.NewResult(0, tt.markedRows))
+				if tt.wantErr == nil {
+					mock.ExpectCommit()
+				} else {
+					mock.ExpectRollback()
+				}
+			}
+
+			user, err := registration.Register(context.Background(), tt.email, testRegistrationPassword, code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Register() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && user.Role != models.RoleAdmin {
+				t.Fatalf("registered role = %q, want role from invitation", user.Role)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	const token = "verification-token"
+
+	tests := []struct {
+		name         string
+		found        bool
+		consumedRows int64
+		wantErr      error
+	}{
+		{name: "token is consumed and email marked verified", found: true, consumedRows: 1},
+		// истекший или уже использованный токен отсекает условие выборки
+		{name: "expired or used token", found: false, wantErr: ErrInvalidVerificationToken},
+		{name: "token consumed concurrently", found: true, consumedRows: 0, wantErr: ErrInvalidVerificationToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registration, _, mock := newTestRegistration(t, nil, openVerifiedPolicy())
+
+			rows := sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "used_at"})
+			if tt.found {
+				rows.AddRow(5, 10, auth.HashOpaqueToken(token), testRegistrationNow.Add(time.Minute), nil)
+			}
+			mock.ExpectBegin()
+			mock.ExpectQuery(selectVerificationSQL).
+				WithArgs(auth.HashOpaqueToken(token), testRegistrationNow, 1).
+				WillReturnRows(rows)
+			if tt.found {
+				mock.ExpectExec(consumeVerificationSQL).
+					WithArgs(testRegistrationNow, 5).
+					WillReturnResult(sqlmock.NewResult(0, tt.consumedRows))
+			}
+			if tt.wantErr == nil {
+				mock.ExpectExec(markEmailVerifiedSQL).
+					WithArgs(testRegistrationNow, true, sqlmock.AnyArg(), 10).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			if err := registration.VerifyEmail(context.Background(), " "+token+" "); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyEmail() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
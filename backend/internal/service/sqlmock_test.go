@@ -0,0 +1,72 @@
+package service
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"warranty_days/internal/models"
+)
+
+// newMockGorm открывает gorm поверх sqlmock. Запросы сверяются с ожиданиями по порядку, регулярным
+// выражением; в конце теста все ожидания должны быть выполнены. Запись через GORM вне WithinTx идет
+// в своей транзакции, поэтому ее ждем через expectWrite.
+func newMockGorm(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		_ = sqlDB.Close()
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// expectWrite ждет одиночный UPDATE/DELETE в собственной транзакции GORM и отвечает числом измененных строк.
+func expectWrite(mock sqlmock.Sqlmock, query string, rowsAffected int64, args ...driver.Value) {
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+	mock.ExpectCommit()
+}
+
+// userRows — ответ на SELECT из users.
+func userRows(users ...models.User) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "email", "password_hash", "role", "dealer_code", "is_active", "email_verified_at",
+		"totp_secret", "totp_enabled_at", "totp_last_step",
+	})
+	for _, u := range users {
+		rows.AddRow(
+			u.ID, u.Email, u.PasswordHash, u.Role, u.DealerCode, u.IsActive, u.EmailVerifiedAt,
+			u.TOTPSecret, u.TOTPEnabledAt, u.TOTPLastStep,
+		)
+	}
+	return rows
+}
+
+// captureString принимает любую непустую строку и запоминает ее, чтобы сверить с результатом вызова.
+type captureString struct {
+	value *string
+}
+
+func (a captureString) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	*a.value = s
+	return ok && s != ""
+}
@@ -0,0 +1,9 @@
+package service
+
+import "context"
+
+// VerificationSender доставляет пользователю токен подтверждения email. Вызывается внутри транзакции
+// регистрации, поэтому должен только ставить письмо в очередь в той же БД, а не отправлять его.
+type VerificationSender interface {
+	SendEmailVerification(ctx context.Context, email, token string) error
+}
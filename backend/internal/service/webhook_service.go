@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidEventType        = errors.New("unknown event type")
+	ErrInvalidDeliveryStatus   = errors.New("invalid delivery status")
+)
+
+// webhookSecretPrefix — по префиксу секрет легко узнать в конфиге получателя и в сканерах утечек.
+const webhookSecretPrefix = "whsec_"
+
+type WebhookInput struct {
+	URL         string
+	Description string
+	EventTypes  []string
+	// IsActive — nil оставляет как есть (новая подписка включена).
+	IsActive *bool
+}
+
+// CreatedWebhook — секрет в открытом виде есть только в ответе на создание.
+type CreatedWebhook struct {
+	Subscription models.WebhookSubscription
+	Secret       string
+}
+
+// WebhookService — подписки на вебхуки и журнал их доставок для администратора.
+type WebhookService struct {
+	webhookRepo *repo.WebhookRepo
+	secretBox   *auth.SecretBox
+	nowFn       func() time.Time
+}
+
+func NewWebhookService(webhookRepo *repo.WebhookRepo, secretBox *auth.SecretBox) *WebhookService {
+	return &WebhookService{webhookRepo: webhookRepo, secretBox: secretBox, nowFn: time.Now}
+}
+
+func (s *WebhookService) Create(ctx context.Context, createdBy int64, input WebhookInput) (CreatedWebhook, error) {
+	sub := models.WebhookSubscription{CreatedBy: &createdBy, IsActive: true}
+	if err := applyWebhookInput(&sub, input); err != nil {
+		return CreatedWebhook{}, err
+	}
+
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return CreatedWebhook{}, err
+	}
+	secret := webhookSecretPrefix + token
+	sub.SecretCiphertext, err = s.secretBox.Seal(secret)
+	if err != nil {
+		return CreatedWebhook{}, err
+	}
+
+	if err := s.webhookRepo.CreateSubscription(ctx, &sub); err != nil {
+		return CreatedWebhook{}, err
+	}
+	return CreatedWebhook{Subscription: sub, Secret: secret}, nil
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.webhookRepo.ListSubscriptions(ctx)
+}
+
+func (s *WebhookService) Get(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	sub, err := s.webhookRepo.GetSubscription(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, err
+}
+
+func (s *WebhookService) Update(
+	ctx context.Context,
+	id int64,
+	input WebhookInput,
+) (*models.WebhookSubscription, error) {
+	sub, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookInput(sub, input); err != nil {
+		return nil, err
+	}
+	sub.UpdatedAt = s.nowFn()
+
+	if err := s.webhookRepo.UpdateSubscription(ctx, sub); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+	err := s.webhookRepo.DeleteSubscription(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// Deliveries — журнал доставок подписки. status пустой или один из models.Delivery*.
+func (s *WebhookService) Deliveries(
+	ctx context.Context,
+	id int64,
+	status string,
+	limit int,
+) ([]models.WebhookDelivery, error) {
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		return nil, ErrInvalidDeliveryStatus
+	}
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.ListDeliveries(ctx, id, status, limit)
+}
+
+// Redeliver ставит доставку (обычно из dead) в очередь заново. Получатель отличит повтор по event_id.
+func (s *WebhookService) Redeliver(ctx context.Context, id int64, deliveryID int64) (*models.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.RequeueDelivery(ctx, id, deliveryID, s.nowFn())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+func applyWebhookInput(sub *models.WebhookSubscription, input WebhookInput) error {
+	target, err := url.Parse(strings.TrimSpace(input.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	eventTypes := make(models.StringList, 0, len(input.EventTypes))
+	for _, eventType := range input.EventTypes {
+		if !events.KnownType(eventType) {
+			return ErrInvalidEventType
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if len(eventTypes) == 0 {
+		return ErrInvalidEventType
+	}
+
+	sub.URL = target.String()
+	sub.Description = strings.TrimSpace(input.Description)
+	sub.EventTypes = eventTypes
+	if input.IsActive != nil {
+		sub.IsActive = *input.IsActive
+	}
+	return nil
+}
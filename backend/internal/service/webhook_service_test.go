@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+func TestWebhookCreateKeepsIsActive(t *testing.T) {
+	secretBox, err := auth.NewSecretBox("webhook-secret-key-for-tests-0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, active := range []bool{true, false} {
+		db, mock := newMockGorm(t)
+		svc := NewWebhookService(repo.NewWebhookRepo(db), secretBox)
+
+		// явное is_active=false уходит в INSERT, а не заменяется значением по умолчанию таблицы
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "webhook_subscriptions"`).
+			WithArgs("https://example.com/hook", "", sqlmock.AnyArg(), sqlmock.AnyArg(), active, 1,
+				sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectCommit()
+
+		created, err := svc.Create(context.Background(), 1, WebhookInput{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{events.ClaimCreated},
+			IsActive:   &active,
+		})
+		if err != nil {
+			t.Fatalf("Create(is_active=%v) error = %v", active, err)
+		}
+		if created.Subscription.IsActive != active || created.Subscription.ID != 4 {
+			t.Fatalf("Create(is_active=%v) returned %+v", active, created.Subscription)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWebhookRedeliverClearsLastError(t *testing.T) {
+	db, mock := newMockGorm(t)
+	svc := NewWebhookService(repo.NewWebhookRepo(db), nil)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.nowFn = func() time.Time { return now }
+
+	// новый круг попыток начинается без ошибки прошлого: иначе в журнале pending выглядел бы упавшим
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "webhook_deliveries" SET "attempts"=\$1,"last_error"=\$2,"next_attempt_at"=\$3,`+
+		`"status"=\$4,"updated_at"=\$5 WHERE id = \$6 AND subscription_id = \$7 RETURNING \*`).
+		WithArgs(0, nil, now, models.DeliveryPending, now, 9, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "status", "attempts", "last_error"}).
+			AddRow(9, 4, models.DeliveryPending, 0, nil))
+	mock.ExpectCommit()
+
+	delivery, err := svc.Redeliver(context.Background(), 4, 9)
+	if err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	if delivery.LastError != nil || delivery.Status != models.DeliveryPending {
+		t.Fatalf("Redeliver() = %+v, want pending without last_error", delivery)
+	}
+}
@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress — адрес получателя во внутренней сети, а в WEBHOOK_ALLOWED_NETWORKS его нет.
+var ErrForbiddenAddress = errors.New("webhook receiver address is not allowed")
+
+// newHTTPClient собирает клиент, который не ходит во внутреннюю сеть. Проверка стоит в Control диалера:
+// там уже разрешенный IP, так что ни DNS rebinding, ни имя, указывающее на 127.0.0.1, ее не обходят.
+func newHTTPClient(timeout time.Duration, allowed []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkAddress(address, allowed)
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// без прокси из окружения: иначе Control проверял бы адрес прокси, а не получателя
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   2,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		// редирект считаем ошибкой: подпись сделана для адреса из подписки
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// checkAddress пропускает публичные адреса и адреса из allowed.
+func checkAddress(address string, allowed []netip.Prefix) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("parse dial address %q: %w", address, err)
+	}
+	addr := addrPort.Addr().Unmap()
+	if slices.ContainsFunc(allowed, func(p netip.Prefix) bool { return p.Contains(addr) }) {
+		return nil
+	}
+	if isInternal(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
+
+func isInternal(addr netip.Addr) bool {
+	return addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() ||
+		sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace — CGNAT (RFC 6598), IsPrivate его не считает, но снаружи он тоже недоступен.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCheckAddress(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("10.20.0.0/16")}
+
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "[2606:2800:220:1:248:1893:25c8:1946]:443"},
+		{address: "127.0.0.1:80", wantErr: true},
+		{address: "[::1]:80", wantErr: true},
+		{address: "10.0.0.5:443", wantErr: true},
+		{address: "172.16.3.4:443", wantErr: true},
+		{address: "192.168.1.1:443", wantErr: true},
+		{address: "169.254.169.254:80", wantErr: true},
+		{address: "100.64.0.1:443", wantErr: true},
+		{address: "0.0.0.0:80", wantErr: true},
+		{address: "[fd00::1]:443", wantErr: true},
+		{address: "[fe80::1]:443", wantErr: true},
+		// IPv4-mapped IPv6 не обходит проверку
+		{address: "[::ffff:127.0.0.1]:80", wantErr: true},
+		// внутренний получатель из WEBHOOK_ALLOWED_NETWORKS
+		{address: "10.20.1.2:443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := checkAddress(tt.address, allowed)
+			if tt.wantErr != errors.Is(err, ErrForbiddenAddress) {
+				t.Fatalf("checkAddress(%q) error = %v, want forbidden = %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPClientRefusesLoopbackUnlessAllowed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// имя localhost разрешается в 127.0.0.1 уже при соединении, проверка видит итоговый IP
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://localhost:" + port
+
+	_, err = newHTTPClient(time.Second, nil).Get(url)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Get(%s) error = %v, want ErrForbiddenAddress", url, err)
+	}
+
+	allowed := []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+	resp, err := newHTTPClient(time.Second, allowed).Get(url)
+	if err != nil {
+		t.Fatalf("Get(%s) with allowlist error = %v", url, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,57 @@
+// Package webhook доставляет доменные события подписчикам: очередь доставок в Postgres, подпись
+// HMAC-SHA256, повторы с экспоненциальной паузой и dead-letter после последней попытки.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"warranty_days/internal/events"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+)
+
+// Dispatcher — events.Publisher, который ставит событие в очередь каждой подписке на его тип.
+// Сама отправка — дело Worker.
+type Dispatcher struct {
+	webhookRepo *repo.WebhookRepo
+	nowFn       func() time.Time
+}
+
+func NewDispatcher(webhookRepo *repo.WebhookRepo) *Dispatcher {
+	return &Dispatcher{webhookRepo: webhookRepo, nowFn: time.Now}
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, published ...events.Event) error {
+	now := d.nowFn()
+
+	var deliveries []models.WebhookDelivery
+	for _, event := range published {
+		subs, err := d.webhookRepo.ActiveSubscriptionsFor(ctx, event.Type)
+		if err != nil {
+			return err
+		}
+		if len(subs) == 0 {
+			continue
+		}
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			deliveries = append(deliveries, models.WebhookDelivery{
+				SubscriptionID: sub.ID,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				VIN:            event.VIN,
+				Payload:        payload,
+				Status:         models.DeliveryPending,
+				NextAttemptAt:  now,
+			})
+		}
+	}
+
+	return d.webhookRepo.CreateDeliveries(ctx, deliveries)
+}
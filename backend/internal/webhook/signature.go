@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Заголовки запроса к подписчику.
+const (
+	HeaderEventID    = "X-Webhook-Id"
+	HeaderEventType  = "X-Webhook-Event"
+	HeaderDeliveryID = "X-Webhook-Delivery"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// Sign возвращает значение X-Webhook-Signature: "sha256=" и HMAC-SHA256 от "<timestamp>.<тело>"
+// в hex. Метка времени входит в подпись, чтобы перехваченный запрос нельзя было повторить позже.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	const body = `{"type":"claim.created"}`
+
+	// ожидаемые значения посчитаны независимо: HMAC-SHA256(secret, "<timestamp>.<body>") в hex
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{
+			name:      "event body",
+			secret:    "whsec_test",
+			timestamp: 1700000000,
+			body:      body,
+			want:      "sha256=c8786f7b1d8bad8b3378bf73af716c50ebf57ca185c2018d5832139cd6c5bd64",
+		},
+		{
+			name:      "empty body",
+			secret:    "whsec_test",
+			timestamp: 1700000000,
+			want:      "sha256=5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc",
+		},
+		{
+			name:      "utf-8 secret and next second",
+			secret:    "другой секрет",
+			timestamp: 1700000001,
+			body:      body,
+			want:      "sha256=6ab91a5e535afd5641437b489702ecca857a7e238193587fc1b7823f18240760",
+		},
+		{
+			name: "empty secret and zero timestamp",
+			body: "payload",
+			want: "sha256=ec0d73b48bbd16a9a95fa06691e2ef279981c7d8cceb87928113ed5104cea4bf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Fatalf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Метка времени отделена точкой: перенос цифр между меткой и телом дает другую подпись.
+func TestSignTimestampBoundary(t *testing.T) {
+	if Sign("whsec_test", 12, []byte("3.payload")) == Sign("whsec_test", 123, []byte("payload")) {
+		t.Fatal("Sign() gives equal signatures for different timestamp/body splits")
+	}
+}
@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"warranty_days/internal/auth"
+	"warranty_days/internal/buildinfo"
+	"warranty_days/internal/config"
+	"warranty_days/internal/models"
+	"warranty_days/internal/repo"
+	"warranty_days/internal/worker"
+)
+
+type Config struct {
+	// PollInterval — как часто искать доставки, у которых подошло время попытки.
+	PollInterval time.Duration
+	BatchSize    int
+	// Timeout — на одну попытку, включая чтение ответа.
+	Timeout     time.Duration
+	MaxAttempts int
+	Backoff     worker.Backoff
+	// AllowedNetworks — внутренние подсети, куда доставлять можно; в остальные частные адреса нельзя.
+	AllowedNetworks []netip.Prefix
+}
+
+func ConfigFromApp(cfg config.Config) Config {
+	return Config{
+		PollInterval: cfg.WebhookPollInterval,
+		BatchSize:    cfg.WebhookBatchSize,
+		Timeout:      cfg.WebhookTimeout,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		Backoff:      worker.Backoff{Initial: cfg.WebhookBackoffInitial, Max: cfg.WebhookBackoffMax},
+
+		AllowedNetworks: cfg.WebhookAllowedNetworks,
+	}
+}
+
+// Worker отправляет доставки из очереди. Инстансов может быть несколько: доставку забирает один из них.
+type Worker struct {
+	cfg         Config
+	webhookRepo *repo.WebhookRepo
+	secretBox   *auth.SecretBox
+	client      *http.Client
+	logger      *slog.Logger
+	userAgent   string
+	poller      *worker.Poller
+}
+
+func NewWorker(cfg Config, webhookRepo *repo.WebhookRepo, secretBox *auth.SecretBox, logger *slog.Logger) *Worker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	w := &Worker{
+		cfg:         cfg,
+		webhookRepo: webhookRepo,
+		secretBox:   secretBox,
+		client:      newHTTPClient(cfg.Timeout, cfg.AllowedNetworks),
+		logger:      logger,
+		userAgent:   "warranty_days-webhooks/" + buildinfo.Get().Version,
+	}
+	w.poller = &worker.Poller{Interval: cfg.PollInterval, Poll: w.poll}
+	return w
+}
+
+func (w *Worker) Start() {
+	w.poller.Start()
+}
+
+// Shutdown прерывает текущие попытки и ждет выхода из цикла. Прерванные доставки снова возьмутся
+// после аренды — получатель может увидеть событие дважды и отбросит повтор по X-Webhook-Id.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	return w.poller.Shutdown(ctx)
+}
+
+func (w *Worker) poll(ctx context.Context) {
+	// пока пачка полная, в очереди, скорее всего, есть еще
+	for ctx.Err() == nil {
+		lease := w.cfg.Timeout + 30*time.Second
+		deliveries, err := w.webhookRepo.ClaimDueDeliveries(ctx, w.cfg.BatchSize, lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.WarnContext(ctx, "failed to claim webhook deliveries", "error", err)
+			}
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		w.deliverBatch(ctx, deliveries)
+		if len(deliveries) < w.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+func (w *Worker) deliverBatch(ctx context.Context, deliveries []models.WebhookDelivery) {
+	ids := make([]int64, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.SubscriptionID)
+	}
+	subs, err := w.webhookRepo.GetSubscriptions(ctx, ids)
+	if err != nil {
+		w.logger.WarnContext(ctx, "failed to load webhook subscriptions", "error", err)
+		return
+	}
+	byID := make(map[int64]models.WebhookSubscription, len(subs))
+	for _, sub := range subs {
+		byID[sub.ID] = sub
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		sub, ok := byID[delivery.SubscriptionID]
+		if !ok {
+			// подписку удалили между выборкой и загрузкой, доставки удалены каскадом
+			continue
+		}
+		// ClaimDueDeliveries отдает не больше одной доставки на подписку и VIN: параллельность порядок не ломает
+		wg.Go(func() { w.deliver(ctx, sub, delivery) })
+	}
+	wg.Wait()
+}
+
+func (w *Worker) deliver(ctx context.Context, sub models.WebhookSubscription, delivery models.WebhookDelivery) {
+	statusCode, sendErr := w.send(ctx, sub, delivery)
+	if ctx.Err() != nil {
+		// остановка сервера — попытку не засчитываем
+		return
+	}
+
+	now := time.Now()
+	attempt := repo.DeliveryAttempt{
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: now,
+	}
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+
+	switch {
+	case sendErr == nil:
+		attempt.Status = models.DeliveryDelivered
+		attempt.DeliveredAt = &now
+	case attempt.Attempts >= w.cfg.MaxAttempts:
+		attempt.Status = models.DeliveryDead
+		msg := sendErr.Error()
+		attempt.Error = &msg
+		w.logger.WarnContext(ctx, "webhook delivery moved to dead letter",
+			"delivery_id", delivery.ID,
+			"subscription_id", sub.ID,
+			"event_type", delivery.EventType,
+			"attempts", attempt.Attempts,
+			"error", sendErr,
+		)
+	default:
+		attempt.Status = models.DeliveryPending
+		attempt.NextAttemptAt = now.Add(w.cfg.Backoff.Delay(attempt.Attempts))
+		msg := sendErr.Error()
+		attempt.Error = &msg
+	}
+
+	if err := w.webhookRepo.RecordAttempt(ctx, delivery.ID, attempt); err != nil {
+		w.logger.WarnContext(ctx, "failed to record webhook attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send возвращает код ответа (0, если ответа не было) и ошибку, если доставка не удалась.
+func (w *Worker) send(
+	ctx context.Context,
+	sub models.WebhookSubscription,
+	delivery models.WebhookDelivery,
+) (int, error) {
+	secret, err := w.secretBox.Open(sub.SecretCiphertext)
+	if err != nil {
+		return 0, fmt.Errorf("decrypt webhook secret: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", w.userAgent)
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// тело не нужно, но дочитываем немного, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,82 @@
+// Package worker — общие части фоновых воркеров: цикл опроса очереди и пауза перед повтором.
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// Backoff — пауза после n-й неудачной попытки: Initial * 2^(n-1), но не больше Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// Delay — пауза перед следующей попыткой после attempts неудачных.
+func (b Backoff) Delay(attempts int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < attempts && delay < b.Max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.Max)
+}
+
+// Poller крутит цикл воркера в своей горутине: Poll сразу после Start и дальше раз в Interval,
+// после него Prune, но не чаще раза в PruneInterval.
+type Poller struct {
+	Interval time.Duration
+	// Poll забирает и обрабатывает все, чему подошло время; ctx отменяется при Shutdown.
+	Poll func(ctx context.Context)
+	// Prune чистит журнал обработанного; nil — без чистки.
+	Prune         func(ctx context.Context)
+	PruneInterval time.Duration
+
+	lastPrune time.Time
+	stop      context.CancelFunc
+	done      chan struct{}
+}
+
+func (p *Poller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stop = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		for {
+			p.Poll(ctx)
+			p.prune(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown отменяет контекст Poll и Prune и ждет выхода из цикла. До Start ничего не делает.
+func (p *Poller) Shutdown(ctx context.Context) error {
+	if p.stop == nil {
+		return nil
+	}
+	p.stop()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Poller) prune(ctx context.Context) {
+	now := time.Now()
+	if p.Prune == nil || ctx.Err() != nil || now.Sub(p.lastPrune) < p.PruneInterval {
+		return
+	}
+	p.lastPrune = now
+	p.Prune(ctx)
+}
@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := Backoff{Initial: time.Second, Max: 10 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Second},
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 1000, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := backoff.Delay(tt.attempts); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestPollerPrunesAtMostOncePerInterval(t *testing.T) {
+	var polls, prunes atomic.Int64
+	p := &Poller{
+		Interval:      time.Millisecond,
+		Poll:          func(context.Context) { polls.Add(1) },
+		Prune:         func(context.Context) { prunes.Add(1) },
+		PruneInterval: time.Hour,
+	}
+
+	p.Start()
+	for polls.Load() < 5 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := prunes.Load(); got != 1 {
+		t.Fatalf("prunes = %d after %d polls, want 1", got, polls.Load())
+	}
+}
+
+func TestPollerShutdownBeforeStart(t *testing.T) {
+	if err := (&Poller{}).Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,42 @@
+-- =========================
+-- UP
+-- =========================
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+-- существующие аккаунты считаем подтвержденными
+UPDATE users SET email_verified_at = created_at WHERE is_active;
+
+CREATE TABLE invitations (
+    id BIGSERIAL PRIMARY KEY,
+    code_hash TEXT NOT NULL,
+    email TEXT,
+    role TEXT NOT NULL DEFAULT 'user',
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    used_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_invitations_code_hash ON invitations(code_hash);
+
+CREATE TABLE email_verifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_email_verifications_token_hash ON email_verifications(token_hash);
+CREATE INDEX idx_email_verifications_user_id ON email_verifications(user_id);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE email_verifications;
+-- DROP TABLE invitations;
+-- ALTER TABLE users DROP COLUMN email_verified_at;
+-- ALTER TABLE users DROP COLUMN role;
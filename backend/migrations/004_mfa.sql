@@ -0,0 +1,33 @@
+-- =========================
+-- UP
+-- =========================
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE mfa_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_mfa_recovery_codes_user_code ON mfa_recovery_codes(user_id, code_hash);
+
+-- роли, для которых 2FA обязательна
+CREATE TABLE mfa_role_policies (
+    role TEXT PRIMARY KEY,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE mfa_role_policies;
+-- DROP TABLE mfa_recovery_codes;
+-- ALTER TABLE users DROP COLUMN totp_last_step;
+-- ALTER TABLE users DROP COLUMN totp_enabled_at;
+-- ALTER TABLE users DROP COLUMN totp_secret;
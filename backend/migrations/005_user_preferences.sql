@@ -0,0 +1,22 @@
+-- =========================
+-- UP
+-- =========================
+ALTER TABLE users ADD COLUMN dealer_code TEXT;
+ALTER TABLE invitations ADD COLUMN dealer_code TEXT;
+
+CREATE TABLE user_preferences (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    language TEXT NOT NULL DEFAULT 'ru',
+    -- today | last_repair
+    default_as_of TEXT NOT NULL DEFAULT 'today',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE user_preferences;
+-- ALTER TABLE invitations DROP COLUMN dealer_code;
+-- ALTER TABLE users DROP COLUMN dealer_code;
@@ -0,0 +1,16 @@
+-- =========================
+-- UP
+-- =========================
+-- версия схемы для readiness-проверки: каждая следующая миграция
+-- должна заканчиваться INSERT INTO schema_migrations (version) VALUES (<номер>);
+CREATE TABLE schema_migrations (
+    version INT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO schema_migrations (version) VALUES (1), (2), (3), (4), (5), (6);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE schema_migrations;
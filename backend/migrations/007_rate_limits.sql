@@ -0,0 +1,18 @@
+-- =========================
+-- UP
+-- =========================
+-- корзины token bucket для RATE_LIMIT_BACKEND=postgres; key — "<группа>:user:<id>" или "<группа>:ip:<адрес>"
+CREATE UNLOGGED TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
+
+INSERT INTO schema_migrations (version) VALUES (7);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE rate_limit_buckets;
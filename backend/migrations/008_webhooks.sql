@@ -0,0 +1,48 @@
+-- =========================
+-- UP
+-- =========================
+CREATE TABLE webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    secret_ciphertext TEXT NOT NULL,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- status: pending -> delivered | dead; строки остаются как журнал доставок
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    vin TEXT NOT NULL DEFAULT '',
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code INT,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- одно событие доставляется подписчику один раз, даже если публикация повторилась
+CREATE UNIQUE INDEX idx_webhook_deliveries_subscription_event ON webhook_deliveries (subscription_id, event_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+-- доставки одной подписки по одному VIN уходят по очереди, в порядке id
+CREATE INDEX idx_webhook_deliveries_pending_vin ON webhook_deliveries (subscription_id, vin, id)
+    WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id, id DESC);
+
+INSERT INTO schema_migrations (version) VALUES (8);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE webhook_deliveries;
+-- DROP TABLE webhook_subscriptions;
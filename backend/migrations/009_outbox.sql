@@ -0,0 +1,33 @@
+-- =========================
+-- UP
+-- =========================
+-- outbox: события пишутся в одной транзакции с изменением заявок, relay отдает их получателям.
+-- Неотправленное сообщение ждет повтора; после OUTBOX_MAX_ATTEMPTS неудач получает dead_at и больше
+-- не отправляется и не задерживает следующие события своего VIN.
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id TEXT NOT NULL UNIQUE,
+    event_type TEXT NOT NULL,
+    vin TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT,
+    published_at TIMESTAMPTZ,
+    dead_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- очередь неотправленных: по порядку id и по VIN для проверки порядка
+CREATE INDEX idx_outbox_unpublished ON outbox (id) WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX idx_outbox_unpublished_vin ON outbox (vin, id) WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX idx_outbox_published_at ON outbox (published_at) WHERE published_at IS NOT NULL;
+CREATE INDEX idx_outbox_dead_at ON outbox (dead_at) WHERE dead_at IS NOT NULL;
+
+INSERT INTO schema_migrations (version) VALUES (9);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE outbox;
@@ -0,0 +1,52 @@
+-- =========================
+-- UP
+-- =========================
+-- журнал изменений заявок: триггер пишет строку и рассылает ее через pg_notify всем инстансам API,
+-- по журналу слушатель добирает изменения, пропущенные, пока соединения не было
+CREATE TABLE claim_changes (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    claim_id BIGINT NOT NULL,
+    vin TEXT NOT NULL,
+    previous_vin TEXT,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_claim_changes_changed_at ON claim_changes (changed_at);
+
+CREATE FUNCTION notify_claim_change() RETURNS trigger AS $$
+DECLARE
+    change claim_changes;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO claim_changes (event_type, claim_id, vin)
+        VALUES ('claim.created', NEW.id, NEW.vin)
+        RETURNING * INTO change;
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO claim_changes (event_type, claim_id, vin, previous_vin)
+        VALUES ('claim.updated', NEW.id, NEW.vin, NULLIF(OLD.vin, NEW.vin))
+        RETURNING * INTO change;
+    ELSE
+        INSERT INTO claim_changes (event_type, claim_id, vin)
+        VALUES ('claim.deleted', OLD.id, OLD.vin)
+        RETURNING * INTO change;
+    END IF;
+
+    -- уведомление уходит при коммите транзакции и не уходит при откате
+    PERFORM pg_notify('claim_changes', row_to_json(change)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER claims_notify_change
+AFTER INSERT OR UPDATE OR DELETE ON claims
+FOR EACH ROW EXECUTE FUNCTION notify_claim_change();
+
+INSERT INTO schema_migrations (version) VALUES (10);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TRIGGER claims_notify_change ON claims;
+-- DROP FUNCTION notify_claim_change();
+-- DROP TABLE claim_changes;
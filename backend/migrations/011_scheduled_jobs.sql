@@ -0,0 +1,54 @@
+-- =========================
+-- UP
+-- =========================
+-- состояние периодических задач; строка появляется при первой паузе
+CREATE TABLE scheduled_jobs (
+    name TEXT PRIMARY KEY,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- история запусков; status: queued -> running -> succeeded | failed
+CREATE TABLE job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name TEXT NOT NULL,
+    source TEXT NOT NULL,
+    status TEXT NOT NULL,
+    scheduled_for TIMESTAMPTZ,
+    triggered_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    result JSONB,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- слот расписания запускается один раз, даже если лидер сменился посреди слота
+CREATE UNIQUE INDEX idx_job_runs_slot ON job_runs (job_name, scheduled_for) WHERE scheduled_for IS NOT NULL;
+-- у задачи не больше одного запуска в очереди или в работе
+CREATE UNIQUE INDEX idx_job_runs_active ON job_runs (job_name) WHERE status IN ('queued', 'running');
+CREATE INDEX idx_job_runs_job_name ON job_runs (job_name, id);
+CREATE INDEX idx_job_runs_created_at ON job_runs (created_at);
+
+-- статус гарантийного года VIN, его пересчитывает задача warranty_status_recalc
+CREATE TABLE vin_warranty_status (
+    vin TEXT PRIMARY KEY,
+    retail_date DATE NOT NULL,
+    warranty_start DATE NOT NULL,
+    warranty_end DATE NOT NULL,
+    total_days INT NOT NULL,
+    status TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_vin_warranty_status_status ON vin_warranty_status (status);
+
+INSERT INTO schema_migrations (version) VALUES (11);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE vin_warranty_status;
+-- DROP TABLE job_runs;
+-- DROP TABLE scheduled_jobs;
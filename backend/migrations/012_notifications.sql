@@ -0,0 +1,43 @@
+-- =========================
+-- UP
+-- =========================
+-- очередь писем; status: pending -> sent | dead. Тело письма стирается после отправки:
+-- в письмах бывают одноразовые токены
+CREATE TABLE notifications (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    event_id TEXT,
+    recipient TEXT NOT NULL,
+    language TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- письмо о событии уходит адресату один раз, даже если outbox повторил публикацию
+CREATE UNIQUE INDEX idx_notifications_event_recipient ON notifications (event_id, recipient);
+CREATE INDEX idx_notifications_due ON notifications (next_attempt_at) WHERE status = 'pending';
+
+-- отказ пользователя от необязательных писем; строки нет — письма этого типа приходят
+CREATE TABLE notification_opt_outs (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, type)
+);
+
+INSERT INTO schema_migrations (version) VALUES (12);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE notification_opt_outs;
+-- DROP TABLE notifications;
@@ -0,0 +1,21 @@
+-- =========================
+-- UP
+-- =========================
+-- челленджи второго шага логина: id совпадает с jti в mfa_token
+CREATE TABLE mfa_challenges (
+    id TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_mfa_challenges_expires_at ON mfa_challenges(expires_at);
+
+INSERT INTO schema_migrations (version) VALUES (13);
+
+-- =========================
+-- DOWN (на будущее)
+-- =========================
+-- DROP TABLE mfa_challenges;
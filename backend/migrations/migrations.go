@@ -0,0 +1,33 @@
+// Package migrations embeds SQL migrations to know the schema version the binary expects
+package migrations
+
+import (
+	"embed"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// LatestVersion — номер последней миграции (префикс имени файла, например 006_...sql -> 6).
+func LatestVersion() int {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return 0
+	}
+
+	latest := 0
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, version)
+	}
+	return latest
+}